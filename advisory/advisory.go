@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package advisory exports findings as a minimal CSAF 2.0 VEX advisory, for
+// organizations that distribute internal advisories in a standardized format.
+// Advisories name the secret class and location of each finding but never
+// include the secret itself.
+package advisory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackloklabs/secret-scanning-api/patterns"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Status is the remediation status of a finding, using the CSAF product
+// status names
+type Status string
+
+const (
+	// StatusAffected marks a secret that is exposed and has not been rotated
+	StatusAffected Status = "known_affected"
+	// StatusFixed marks a secret that has been revoked or rotated
+	StatusFixed Status = "fixed"
+	// StatusUnderInvestigation marks a finding that has not been confirmed
+	StatusUnderInvestigation Status = "under_investigation"
+)
+
+// Finding is a detected secret in an artifact
+type Finding struct {
+	// Artifact is the file, image, or package the secret was found in
+	Artifact string
+	Result   scanner.Result
+	// Status defaults to StatusAffected
+	Status Status
+}
+
+// Option configures the advisory document
+type Option func(*options)
+
+type options struct {
+	publisher  string
+	namespace  string
+	trackingID string
+	title      string
+	date       time.Time
+	skipped    []Note
+}
+
+// WithPublisher sets the publisher name and namespace URL
+func WithPublisher(name, namespace string) Option {
+	return func(o *options) {
+		o.publisher = name
+		o.namespace = namespace
+	}
+}
+
+// WithTrackingID sets the advisory identifier (default derived from the release date)
+func WithTrackingID(id string) Option {
+	return func(o *options) {
+		o.trackingID = id
+	}
+}
+
+// WithTitle sets the advisory title
+func WithTitle(title string) Option {
+	return func(o *options) {
+		o.title = title
+	}
+}
+
+// WithSkipped notes an artifact that was not scanned, or scanned only in
+// part, and why
+func WithSkipped(artifact, reason string) Option {
+	return func(o *options) {
+		o.skipped = append(o.skipped, Note{Category: "other", Title: "Skipped artifact", Text: artifact + ": " + reason})
+	}
+}
+
+// WithDate sets the release date of the advisory (default now)
+func WithDate(date time.Time) Option {
+	return func(o *options) {
+		o.date = date
+	}
+}
+
+// Document is a CSAF 2.0 document using the csaf_vex profile
+type Document struct {
+	Document        Meta            `json:"document"`
+	ProductTree     ProductTree     `json:"product_tree"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Meta is the document-level metadata of an advisory
+type Meta struct {
+	Category    string    `json:"category"`
+	CSAFVersion string    `json:"csaf_version"`
+	Publisher   Publisher `json:"publisher"`
+	Title       string    `json:"title"`
+	Tracking    Tracking  `json:"tracking"`
+	Notes       []Note    `json:"notes"`
+}
+
+// Publisher identifies the organization issuing the advisory
+type Publisher struct {
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Tracking holds the identifier and release history of an advisory
+type Tracking struct {
+	ID                 string     `json:"id"`
+	Status             string     `json:"status"`
+	Version            string     `json:"version"`
+	InitialReleaseDate string     `json:"initial_release_date"`
+	CurrentReleaseDate string     `json:"current_release_date"`
+	RevisionHistory    []Revision `json:"revision_history"`
+}
+
+// Revision is an entry of the revision history
+type Revision struct {
+	Date    string `json:"date"`
+	Number  string `json:"number"`
+	Summary string `json:"summary"`
+}
+
+// ProductTree lists the affected artifacts
+type ProductTree struct {
+	FullProductNames []Product `json:"full_product_names"`
+}
+
+// Product is an artifact referenced by vulnerabilities
+type Product struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+}
+
+// Vulnerability describes a single finding
+type Vulnerability struct {
+	Title         string              `json:"title"`
+	IDs           []ID                `json:"ids"`
+	CWE           CWE                 `json:"cwe"`
+	Notes         []Note              `json:"notes"`
+	ProductStatus map[Status][]string `json:"product_status"`
+	Remediations  []Remediation       `json:"remediations,omitempty"`
+}
+
+// ID is a non-CVE identifier of a vulnerability
+type ID struct {
+	SystemName string `json:"system_name"`
+	Text       string `json:"text"`
+}
+
+// CWE is the weakness class of a vulnerability
+type CWE struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Note is free-form text attached to a vulnerability
+type Note struct {
+	Category string `json:"category"`
+	Title    string `json:"title,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Remediation is the action required to resolve a vulnerability
+type Remediation struct {
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// New builds an advisory with one vulnerability per finding
+func New(findings []Finding, opts ...Option) *Document {
+	o := &options{
+		publisher: "secret-scanning-api",
+		namespace: "https://github.com/stackloklabs/secret-scanning-api",
+		title:     "Exposed secrets",
+		date:      time.Now(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	date := o.date.UTC().Format(time.RFC3339)
+	if o.trackingID == "" {
+		o.trackingID = "secret-scan-" + o.date.UTC().Format("20060102T150405Z")
+	}
+
+	doc := &Document{
+		Document: Meta{
+			Category:    "csaf_vex",
+			CSAFVersion: "2.0",
+			Publisher:   Publisher{Category: "other", Name: o.publisher, Namespace: o.namespace},
+			Title:       o.title,
+			Tracking: Tracking{
+				ID:                 o.trackingID,
+				Status:             "final",
+				Version:            "1",
+				InitialReleaseDate: date,
+				CurrentReleaseDate: date,
+				RevisionHistory:    []Revision{{Date: date, Number: "1", Summary: "Initial version"}},
+			},
+			Notes: append([]Note{{Category: "general", Title: "Ruleset", Text: "Detection ruleset version " + scanner.RulesetVersion}}, o.skipped...),
+		},
+		Vulnerabilities: []Vulnerability{},
+	}
+
+	products := make(map[string]string)
+	for _, f := range findings {
+		if _, ok := products[f.Artifact]; !ok {
+			products[f.Artifact] = "ARTIFACT-" + strconv.Itoa(len(products)+1)
+		}
+	}
+	doc.ProductTree.FullProductNames = make([]Product, 0, len(products))
+	for name, id := range products {
+		doc.ProductTree.FullProductNames = append(doc.ProductTree.FullProductNames, Product{ProductID: id, Name: name})
+	}
+	sort.Slice(doc.ProductTree.FullProductNames, func(i, j int) bool {
+		return doc.ProductTree.FullProductNames[i].ProductID < doc.ProductTree.FullProductNames[j].ProductID
+	})
+
+	for _, f := range findings {
+		doc.Vulnerabilities = append(doc.Vulnerabilities, vulnerability(f, products[f.Artifact]))
+	}
+	return doc
+}
+
+func vulnerability(f Finding, productID string) Vulnerability {
+	status := f.Status
+	if status == "" {
+		status = StatusAffected
+	}
+	location := fmt.Sprintf("%s:%d", f.Artifact, f.Result.LineNumber)
+	taxonomy := patterns.Classify(f.Result.Type)
+	provider := taxonomy.Provider
+	if provider == "" {
+		provider = "unknown"
+	}
+
+	ids := []ID{{SystemName: "secret-scanning-api", Text: f.Result.Type + ":" + location}}
+	if f.Result.RuleID != "" {
+		ids = append(ids, ID{SystemName: "secret-scanning-api-rules", Text: f.Result.RuleID})
+	}
+
+	v := Vulnerability{
+		Title: fmt.Sprintf("%s in %s", f.Result.Type, location),
+		IDs:   ids,
+		CWE:   CWE{ID: taxonomy.CWE, Name: taxonomy.CWEName},
+		Notes: []Note{
+			{
+				Category: "description",
+				Text:     fmt.Sprintf("%s at line %d of %s (confidence %.2f)", f.Result.Description, f.Result.LineNumber, f.Artifact, f.Result.Confidence),
+			},
+			{
+				Category: "details",
+				Text: fmt.Sprintf("Credential class: %s; provider: %s; persistence: %s; MITRE ATT&CK: %s",
+					taxonomy.Class, provider, taxonomy.Persistence, strings.Join(taxonomy.Attack, ", ")),
+			},
+		},
+		ProductStatus: map[Status][]string{status: {productID}},
+	}
+	// The VEX profile requires an action statement for affected products
+	if status == StatusAffected {
+		v.Remediations = []Remediation{{
+			Category:   "mitigation",
+			Details:    fmt.Sprintf("Revoke and rotate the exposed %s credential, then remove it from %s", f.Result.Type, f.Artifact),
+			ProductIDs: []string{productID},
+		}}
+	}
+	return v
+}
+
+// Write encodes the advisory as indented JSON
+func (d *Document) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(d); err != nil {
+		return fmt.Errorf("failed to encode advisory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package alert raises an alert when the rate of new unique findings spikes
+// above a baseline, which may indicate a mass-leak event. It is meant for
+// long-running services that embed the scanner, such as gateways using the
+// httpfilter or grpcfilter packages.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Alert describes a spike of new unique findings
+type Alert struct {
+	Time time.Time `json:"time"`
+	// NewFindings is the number of new unique findings within the window
+	NewFindings int `json:"new_findings"`
+	// Window is the length of the window, such as "1m0s"
+	Window    string  `json:"window"`
+	Baseline  float64 `json:"baseline"`
+	Threshold float64 `json:"threshold"`
+	// Types are the distinct secret types of the new findings
+	Types []string `json:"types"`
+}
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// Webhook posts alerts as JSON to a URL
+type Webhook struct {
+	URL string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Notify posts the alert and fails on a non-2xx response
+func (w *Webhook) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Option configures a Monitor
+type Option func(*Monitor)
+
+// WithWindow sets the sliding window over which new findings are counted
+// (default 1 minute)
+func WithWindow(d time.Duration) Option {
+	return func(m *Monitor) {
+		if d > 0 {
+			m.window = d
+		}
+	}
+}
+
+// WithBaseline sets the expected number of new unique findings per window
+// (default 10)
+func WithBaseline(n float64) Option {
+	return func(m *Monitor) {
+		if n > 0 {
+			m.baseline = n
+		}
+	}
+}
+
+// WithSpikeFactor sets how many times the baseline must be exceeded to alert
+// (default 3)
+func WithSpikeFactor(f float64) Option {
+	return func(m *Monitor) {
+		if f > 0 {
+			m.factor = f
+		}
+	}
+}
+
+// WithRetention sets how long a fingerprint is remembered; a secret seen
+// again after that counts as new (default 24 hours)
+func WithRetention(d time.Duration) Option {
+	return func(m *Monitor) {
+		if d > 0 {
+			m.retention = d
+		}
+	}
+}
+
+// WithMaxFingerprints bounds how many fingerprints are remembered; when more
+// are seen within the retention period, the least recently seen are forgotten
+// and count as new if seen again (default 100000)
+func WithMaxFingerprints(n int) Option {
+	return func(m *Monitor) {
+		if n > 0 {
+			m.maxSeen = n
+		}
+	}
+}
+
+// Monitor tracks new unique findings and notifies when their rate spikes. At
+// most one alert is sent per window.
+type Monitor struct {
+	notifier  Notifier
+	window    time.Duration
+	baseline  float64
+	factor    float64
+	retention time.Duration
+	maxSeen   int
+	now       func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // fingerprint to last seen
+	// sightings are the times fingerprints were seen, oldest first, so that
+	// they are forgotten in order. Those superseded by a later sighting of
+	// the same fingerprint are stale.
+	sightings []sighting
+	events    []event // new findings, oldest first
+	lastAlert time.Time
+}
+
+type sighting struct {
+	fingerprint string
+	at          time.Time
+}
+
+type event struct {
+	at  time.Time
+	typ string
+}
+
+// NewMonitor creates a Monitor that sends alerts to n
+func NewMonitor(n Notifier, opts ...Option) *Monitor {
+	m := &Monitor{
+		notifier:  n,
+		window:    time.Minute,
+		baseline:  10,
+		factor:    3,
+		retention: 24 * time.Hour,
+		maxSeen:   100000,
+		now:       time.Now,
+		seen:      make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Observe records the results of a scan and notifies if the number of new
+// unique findings within the window exceeds the baseline times the spike
+// factor. Results without a fingerprint are ignored.
+func (m *Monitor) Observe(ctx context.Context, results []scanner.Result) error {
+	a, ok := m.record(results)
+	if !ok {
+		return nil
+	}
+	if err := m.notifier.Notify(ctx, a); err != nil {
+		return fmt.Errorf("failed to notify alert: %w", err)
+	}
+	return nil
+}
+
+// record updates the state and returns the alert to send, if any
+func (m *Monitor) record(results []scanner.Result) (Alert, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.forget(func(s sighting) bool { return now.Sub(s.at) > m.retention })
+	for _, result := range results {
+		if result.Fingerprint == "" {
+			continue
+		}
+		if _, ok := m.seen[result.Fingerprint]; !ok {
+			m.events = append(m.events, event{at: now, typ: result.Type})
+		}
+		m.seen[result.Fingerprint] = now
+		m.sightings = append(m.sightings, sighting{fingerprint: result.Fingerprint, at: now})
+	}
+	m.forget(func(sighting) bool { return len(m.seen) > m.maxSeen })
+	// Drop stale sightings once they outnumber the fingerprints, so that
+	// secrets seen over and over do not grow the queue
+	if len(m.sightings) > 2*len(m.seen) {
+		live := make([]sighting, 0, len(m.seen))
+		for _, s := range m.sightings {
+			if m.seen[s.fingerprint].Equal(s.at) {
+				live = append(live, s)
+			}
+		}
+		m.sightings = live
+	}
+
+	start := 0
+	for start < len(m.events) && now.Sub(m.events[start].at) > m.window {
+		start++
+	}
+	m.events = m.events[start:]
+
+	threshold := m.baseline * m.factor
+	if float64(len(m.events)) <= threshold || (!m.lastAlert.IsZero() && now.Sub(m.lastAlert) < m.window) {
+		return Alert{}, false
+	}
+	m.lastAlert = now
+
+	seenTypes := make(map[string]bool)
+	var types []string
+	for _, e := range m.events {
+		if !seenTypes[e.typ] {
+			seenTypes[e.typ] = true
+			types = append(types, e.typ)
+		}
+	}
+	sort.Strings(types)
+
+	return Alert{
+		Time:        now,
+		NewFindings: len(m.events),
+		Window:      m.window.String(),
+		Baseline:    m.baseline,
+		Threshold:   threshold,
+		Types:       types,
+	}, true
+}
+
+// forget forgets the fingerprints of the oldest sightings while expired
+// reports true for them
+func (m *Monitor) forget(expired func(sighting) bool) {
+	for len(m.sightings) > 0 && expired(m.sightings[0]) {
+		s := m.sightings[0]
+		if m.seen[s.fingerprint].Equal(s.at) {
+			delete(m.seen, s.fingerprint)
+		}
+		m.sightings = m.sightings[1:]
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+type recordingNotifier struct {
+	alerts []Alert
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, a Alert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+func findings(typ string, from, to int) []scanner.Result {
+	var results []scanner.Result
+	for i := from; i < to; i++ {
+		results = append(results, scanner.Result{Type: typ, Fingerprint: typ + strconv.Itoa(i)})
+	}
+	return results
+}
+
+func TestMonitor(t *testing.T) {
+	n := &recordingNotifier{}
+	m := NewMonitor(n, WithWindow(time.Minute), WithBaseline(2), WithSpikeFactor(2))
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		results []scanner.Result
+		alerts  int
+	}{
+		{name: "At threshold", results: findings("aws_access_key", 0, 4), alerts: 0},
+		{name: "Repeated findings are not new", results: findings("aws_access_key", 0, 4), alerts: 0},
+		{name: "Above threshold", advance: 10 * time.Second, results: findings("github_token", 0, 1), alerts: 1},
+		{name: "Cooldown within window", advance: 10 * time.Second, results: findings("github_token", 1, 5), alerts: 1},
+		{name: "Window expired", advance: 2 * time.Minute, results: findings("slack_token", 0, 3), alerts: 1},
+		{name: "New spike", advance: 10 * time.Second, results: findings("slack_token", 3, 5), alerts: 2},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if err := m.Observe(ctx, step.results); err != nil {
+			t.Fatalf("%s: Observe failed: %v", step.name, err)
+		}
+		if len(n.alerts) != step.alerts {
+			t.Fatalf("%s: %d alerts, want %d", step.name, len(n.alerts), step.alerts)
+		}
+	}
+
+	first := n.alerts[0]
+	if first.NewFindings != 5 || first.Threshold != 4 {
+		t.Errorf("First alert = %+v, want 5 new findings over threshold 4", first)
+	}
+	if len(first.Types) != 2 || first.Types[0] != "aws_access_key" || first.Types[1] != "github_token" {
+		t.Errorf("First alert types = %v, want [aws_access_key github_token]", first.Types)
+	}
+}
+
+func TestMonitorForgets(t *testing.T) {
+	m := NewMonitor(&recordingNotifier{}, WithRetention(time.Hour), WithMaxFingerprints(3), WithBaseline(100))
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		results []scanner.Result
+		seen    []string
+	}{
+		{name: "Remembered", results: findings("key", 0, 2), seen: []string{"key0", "key1"}},
+		{name: "Seen again", advance: 40 * time.Minute, results: findings("key", 1, 2), seen: []string{"key0", "key1"}},
+		{name: "Expired", advance: 40 * time.Minute, seen: []string{"key1"}},
+		{name: "Least recently seen forgotten when full", results: findings("key", 2, 5), seen: []string{"key2", "key3", "key4"}},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if err := m.Observe(ctx, step.results); err != nil {
+			t.Fatalf("%s: Observe failed: %v", step.name, err)
+		}
+		var seen []string
+		for fp := range m.seen {
+			seen = append(seen, fp)
+		}
+		sort.Strings(seen)
+		if !reflect.DeepEqual(seen, step.seen) {
+			t.Errorf("%s: remembered %v, want %v", step.name, seen, step.seen)
+		}
+		if len(m.sightings) > 2*len(m.seen) {
+			t.Errorf("%s: %d sightings kept for %d fingerprints", step.name, len(m.sightings), len(m.seen))
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %s, want application/json", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode alert: %v", err)
+		}
+		if got.NewFindings > 100 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	hook := &Webhook{URL: server.URL}
+	if err := hook.Notify(context.Background(), Alert{NewFindings: 42, Types: []string{"aws_access_key"}}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got.NewFindings != 42 {
+		t.Errorf("Webhook received %+v, want 42 new findings", got)
+	}
+	if err := hook.Notify(context.Background(), Alert{NewFindings: 500}); err == nil {
+		t.Error("Notify succeeded on a 500 response")
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package apispec extracts string values from OpenAPI/Swagger specs and Postman
+// collection and environment exports, located by JSON pointer
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document kinds recognized by Extract
+const (
+	KindOpenAPI           = "openapi"
+	KindSwagger           = "swagger"
+	KindPostmanCollection = "postman-collection"
+	KindPostmanEnv        = "postman-environment"
+)
+
+// ErrUnknownFormat is returned for documents that are not an API spec or Postman export
+var ErrUnknownFormat = errors.New("not an OpenAPI/Swagger spec or Postman export")
+
+// Field is a string value of an API document
+type Field struct {
+	// Pointer is the RFC 6901 JSON pointer of the value
+	Pointer string
+	// Name is the property name of the value, or the "key" of a Postman
+	// key/value entry such as an auth parameter or environment variable
+	Name  string
+	Value string
+}
+
+// Text returns the field as "name: value" so that patterns keyed on names such
+// as "password" or "api_key" can match
+func (f Field) Text() string {
+	if f.Name == "" {
+		return f.Value
+	}
+	return f.Name + ": " + f.Value
+}
+
+// ExtractFile reads the API document at path
+func ExtractFile(path string) (string, []Field, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read API document: %w", err)
+	}
+	return Extract(data)
+}
+
+// Extract parses a JSON or YAML API document and returns its kind and every
+// string value in it, including examples, auth sections, and variables
+func Extract(data []byte) (string, []Field, error) {
+	var doc interface{}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return "", nil, fmt.Errorf("failed to parse API document: %w", err)
+		}
+	} else if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", nil, fmt.Errorf("failed to parse API document: %w", err)
+	}
+
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return "", nil, ErrUnknownFormat
+	}
+	kind := detectKind(root)
+	if kind == "" {
+		return "", nil, ErrUnknownFormat
+	}
+
+	var fields []Field
+	walk(root, "", "", &fields)
+	return kind, fields, nil
+}
+
+func detectKind(root map[string]interface{}) string {
+	switch {
+	case root["openapi"] != nil:
+		return KindOpenAPI
+	case root["swagger"] != nil:
+		return KindSwagger
+	case root["_postman_variable_scope"] != nil:
+		return KindPostmanEnv
+	}
+	if info, ok := root["info"].(map[string]interface{}); ok {
+		if schema, _ := info["schema"].(string); strings.Contains(schema, "getpostman.com") || info["_postman_id"] != nil {
+			return KindPostmanCollection
+		}
+	}
+	return ""
+}
+
+// walk appends the string leaves below node to fields
+func walk(node interface{}, pointer, name string, fields *[]Field) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		// Postman stores auth parameters, headers, and variables as key/value objects
+		if key, ok := v["key"].(string); ok {
+			if value, ok := v["value"].(string); ok {
+				*fields = append(*fields, Field{Pointer: pointer + "/value", Name: key, Value: value})
+				return
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walk(v[k], pointer+"/"+escape(k), k, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walk(item, pointer+"/"+strconv.Itoa(i), name, fields)
+		}
+	case string:
+		if v != "" {
+			*fields = append(*fields, Field{Pointer: pointer, Name: name, Value: v})
+		}
+	}
+}
+
+// escape encodes a reference token as described in RFC 6901
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
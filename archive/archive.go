@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package archive extracts the text files of zip, tar, and compressed tar
+// archives, including archives nested in them, within limits that guard
+// against decompression bombs
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+var (
+	// ErrUnknownFormat is returned for data that is not a supported archive
+	ErrUnknownFormat = errors.New("unknown archive format")
+	// ErrTooLarge is returned when the entries of an archive, including
+	// nested archives, exceed Limits.MaxTotalSize. The files extracted up to
+	// the limit are returned with it.
+	ErrTooLarge = errors.New("archive exceeds size limit")
+)
+
+// Limits bound the work of extracting an archive
+type Limits struct {
+	// MaxDepth is how many levels of archives are opened; 1 opens the outer
+	// archive but not archives inside it. Compression layers, as of a
+	// .tar.gz, do not count, but at most maxCompressionLayers of them are
+	// peeled at each level.
+	MaxDepth int
+	// MaxEntrySize bounds how much of a single entry is read
+	MaxEntrySize int64
+	// MaxTotalSize bounds the uncompressed size of all entries read
+	MaxTotalSize int64
+}
+
+// DefaultLimits are the limits used for archives found while scanning
+var DefaultLimits = Limits{
+	MaxDepth:     3,
+	MaxEntrySize: 10 * 1024 * 1024,
+	MaxTotalSize: 256 * 1024 * 1024,
+}
+
+// maxCompressionLayers bounds the gzip or bzip2 layers peeled at one archive
+// level, as a .tar.gz.gz has two, so that data that decompresses to itself is
+// not opened without end
+const maxCompressionLayers = 2
+
+// File is a text file of an archive. Path locates it as in
+// release.zip!config/app.env, with one ! per archive level.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Archive formats, detected by their magic bytes
+const (
+	formatNone = iota
+	formatZip
+	formatTar
+	formatGzip
+	formatBzip2
+)
+
+func format(data []byte) int {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return formatZip
+	case bytes.HasPrefix(data, []byte("\x1f\x8b")):
+		return formatGzip
+	case bytes.HasPrefix(data, []byte("BZh")) && len(data) > 3 && data[3] >= '1' && data[3] <= '9':
+		return formatBzip2
+	case len(data) >= 262 && bytes.Equal(data[257:262], []byte("ustar")):
+		return formatTar
+	}
+	return formatNone
+}
+
+// IsArchive reports whether data starts like a supported archive
+func IsArchive(data []byte) bool {
+	return format(data) != formatNone
+}
+
+// ExtractFile reads the archive file name and extracts its text files, with
+// paths prefixed by name
+func ExtractFile(name string, limits Limits) ([]File, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return Extract(name, data, limits)
+}
+
+// Extract extracts the text files of an archive, opening nested archives up
+// to limits.MaxDepth. Binary files are skipped, as are nested archives that
+// fail to open.
+func Extract(name string, data []byte, limits Limits) ([]File, error) {
+	if !IsArchive(data) {
+		return nil, ErrUnknownFormat
+	}
+	e := extractor{limits: limits}
+	err := e.extract(name, data, 0, 0)
+	return e.files, err
+}
+
+type extractor struct {
+	limits Limits
+	total  int64
+	files  []File
+}
+
+// extract adds the files of the archive data, named name, at depth, within
+// layers compression layers of that level
+func (e *extractor) extract(name string, data []byte, depth, layers int) error {
+	switch format(data) {
+	case formatZip:
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+			content, err := e.read(rc)
+			rc.Close()
+			if errors.Is(err, ErrTooLarge) {
+				return err
+			}
+			if err != nil {
+				continue
+			}
+			if err := e.add(name+"!"+f.Name, content, depth); err != nil {
+				return err
+			}
+		}
+	case formatTar:
+		tr := tar.NewReader(bytes.NewReader(data))
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				// io.EOF, or a corrupt stream whose remaining entries cannot
+				// be found
+				return nil
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			content, err := e.read(tr)
+			if errors.Is(err, ErrTooLarge) {
+				return err
+			}
+			if err := e.add(name+"!"+strings.TrimPrefix(hdr.Name, "./"), content, depth); err != nil {
+				return err
+			}
+		}
+	case formatGzip, formatBzip2:
+		if layers >= maxCompressionLayers {
+			return nil
+		}
+		var r io.Reader
+		inner := strings.TrimSuffix(strings.TrimSuffix(path.Base(name), ".gz"), ".bz2")
+		if format(data) == formatGzip {
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", name, err)
+			}
+			if zr.Name != "" {
+				inner = zr.Name
+			}
+			r = zr
+		} else {
+			r = bzip2.NewReader(bytes.NewReader(data))
+		}
+		content, err := e.read(r)
+		if err != nil && !errors.Is(err, ErrTooLarge) {
+			return fmt.Errorf("failed to decompress %s: %w", name, err)
+		}
+		tooLarge := err
+		switch format(content) {
+		case formatTar, formatGzip, formatBzip2:
+			// A compressed archive, such as a .tar.gz, is one level
+			err = e.extract(name, content, depth, layers+1)
+		default:
+			err = e.add(name+"!"+inner, content, depth)
+		}
+		if err != nil {
+			return err
+		}
+		return tooLarge
+	}
+	return nil
+}
+
+// add adds an entry as a file, or the files of a nested archive
+func (e *extractor) add(name string, content []byte, depth int) error {
+	if IsArchive(content) {
+		if depth+1 >= e.limits.MaxDepth {
+			return nil
+		}
+		if err := e.extract(name, content, depth+1, 0); errors.Is(err, ErrTooLarge) {
+			return err
+		}
+		return nil
+	}
+	if scanner.IsBinary(content) {
+		return nil
+	}
+	e.files = append(e.files, File{Path: name, Content: string(content)})
+	return nil
+}
+
+// read reads an entry up to MaxEntrySize, counting it against MaxTotalSize
+func (e *extractor) read(r io.Reader) ([]byte, error) {
+	n := min(e.limits.MaxEntrySize, e.limits.MaxTotalSize-e.total+1)
+	data, err := io.ReadAll(io.LimitReader(r, n))
+	e.total += int64(len(data))
+	if e.total > e.limits.MaxTotalSize {
+		return data[:len(data)-int(e.total-e.limits.MaxTotalSize)], fmt.Errorf("%w of %d bytes", ErrTooLarge, e.limits.MaxTotalSize)
+	}
+	return data, err
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package binstrings extracts printable ASCII and UTF-16LE strings from binary
+// files, like the strings utility, so that binaries that cannot be skipped,
+// such as release artifacts under audit, are scanned without matching patterns
+// against raw machine code
+package binstrings
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// DefaultMinLength is the default minimum length of an extracted string, which
+// is short enough for passwords and long enough to skip most opcode noise
+const DefaultMinLength = 8
+
+// String is a run of printable characters in binary data
+type String struct {
+	// Offset is the byte offset of the string in the data
+	Offset int
+	Text   string
+	// Wide marks a UTF-16LE string, whose characters take two bytes each
+	Wide bool
+}
+
+func printable(c byte) bool {
+	return c == '\t' || (c >= 0x20 && c < 0x7f)
+}
+
+// Extract returns the printable ASCII and UTF-16LE strings of at least minLen
+// characters in data, ordered by offset
+func Extract(data []byte, minLen int) []String {
+	var strs []String
+
+	start := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && printable(data[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minLen {
+			strs = append(strs, String{Offset: start, Text: string(data[start:i])})
+		}
+		start = -1
+	}
+
+	for i := 0; i+1 < len(data); {
+		if !printable(data[i]) || data[i+1] != 0 {
+			i++
+			continue
+		}
+		start := i
+		var text strings.Builder
+		for i+1 < len(data) && printable(data[i]) && data[i+1] == 0 {
+			text.WriteByte(data[i])
+			i += 2
+		}
+		if text.Len() >= minLen {
+			strs = append(strs, String{Offset: start, Text: text.String(), Wide: true})
+		}
+	}
+
+	sort.SliceStable(strs, func(i, j int) bool { return strs[i].Offset < strs[j].Offset })
+	return strs
+}
+
+// Scan scans the strings of data with s and reports results at byte offsets
+// of data. Results have no line number.
+func Scan(ctx context.Context, s *scanner.Scanner, data []byte, minLen int) ([]scanner.Result, error) {
+	strs := Extract(data, minLen)
+	if len(strs) == 0 {
+		return nil, nil
+	}
+
+	// Scan the strings as one text, one per line, and map positions back
+	var text strings.Builder
+	starts := make([]int, len(strs))
+	for i, str := range strs {
+		starts[i] = text.Len()
+		text.WriteString(str.Text)
+		text.WriteByte('\n')
+	}
+	results, err := s.Scan(ctx, text.String())
+	if err != nil {
+		return nil, err
+	}
+
+	offset := func(pos int) int {
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > pos }) - 1
+		width := 1
+		if strs[i].Wide {
+			width = 2
+		}
+		return strs[i].Offset + (pos-starts[i])*width
+	}
+	for i := range results {
+		r := &results[i]
+		r.StartIndex, r.EndIndex = offset(r.StartIndex), offset(r.EndIndex)
+		r.LineNumber, r.EndLineNumber = 0, 0
+		for j := range r.Locations {
+			loc := &r.Locations[j]
+			loc.StartIndex, loc.EndIndex, loc.LineNumber = offset(loc.StartIndex), offset(loc.EndIndex), 0
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package binstrings
+
+import (
+	"bytes"
+	"context"
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/pe"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// ErrUnknownFormat is returned when data is neither an ELF nor a PE binary
+var ErrUnknownFormat = errors.New("not an ELF or PE binary")
+
+// Section is a data section of a binary
+type Section struct {
+	Name string
+	// Offset is the file offset of the section
+	Offset int
+	Data   []byte
+}
+
+// Finding is a result located in a section of a binary. StartIndex and
+// EndIndex of the result are file offsets.
+type Finding struct {
+	// Section is the section name, such as .rodata, or the Go build setting
+	// the secret was found in, such as "go build setting -ldflags"
+	Section string
+	scanner.Result
+}
+
+// metadataSections are ELF sections of compiler and runtime metadata, such as
+// symbol names and DWARF, where matches are noise rather than secrets
+var metadataSections = []string{".debug_", ".zdebug_", ".gopclntab", ".gosymtab", ".go.type", ".typelink", ".itablink", ".note", ".comment"}
+
+func isMetadata(name string) bool {
+	for _, prefix := range metadataSections {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sections returns the sections of an ELF or PE binary that hold data, such
+// as .rodata, .data, and .rsrc. Code and metadata sections are skipped, since
+// regexing machine code or symbol tables only produces noise.
+func Sections(data []byte) ([]Section, error) {
+	if ef, err := elf.NewFile(bytes.NewReader(data)); err == nil {
+		defer ef.Close()
+		var sections []Section
+		for _, sec := range ef.Sections {
+			if sec.Type != elf.SHT_PROGBITS || sec.Flags&elf.SHF_EXECINSTR != 0 || isMetadata(sec.Name) {
+				continue
+			}
+			if s, ok := section(data, sec.Name, sec.Offset, sec.Size); ok {
+				sections = append(sections, s)
+			}
+		}
+		return sections, nil
+	}
+
+	if pf, err := pe.NewFile(bytes.NewReader(data)); err == nil {
+		defer pf.Close()
+		var sections []Section
+		for _, sec := range pf.Sections {
+			if sec.Characteristics&pe.IMAGE_SCN_CNT_CODE != 0 || isMetadata(sec.Name) {
+				continue
+			}
+			if s, ok := section(data, sec.Name, uint64(sec.Offset), uint64(sec.Size)); ok {
+				sections = append(sections, s)
+			}
+		}
+		return sections, nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func section(data []byte, name string, offset, size uint64) (Section, bool) {
+	if size == 0 || offset == 0 || offset+size > uint64(len(data)) || offset+size < offset {
+		return Section{}, false
+	}
+	return Section{Name: name, Offset: int(offset), Data: data[offset : offset+size]}, true
+}
+
+// span is a region of a binary attributed to a Go build info entry
+type span struct {
+	name       string
+	start, end int
+}
+
+// buildInfoSpans locates the lines of the Go build info embedded in data, so
+// that findings in them, such as a key injected with -ldflags -X, are
+// attributed to the build setting rather than the section
+func buildInfoSpans(data []byte) []span {
+	info, err := buildinfo.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	// The Go version is stored apart from the rest of the build info
+	_, text, _ := strings.Cut(info.String(), "\n")
+	if text == "" {
+		return nil
+	}
+
+	var spans []span
+	for base := 0; ; {
+		i := bytes.Index(data[base:], []byte(text))
+		if i < 0 {
+			return spans
+		}
+		start := base + i
+		for _, line := range strings.SplitAfter(text, "\n") {
+			fields := strings.SplitN(strings.TrimSuffix(line, "\n"), "\t", 3)
+			name := "go build info"
+			switch {
+			case len(fields) == 2 && fields[0] == "build":
+				key, _, _ := strings.Cut(fields[1], "=")
+				name = "go build setting " + key
+			case len(fields) >= 2 && (fields[0] == "mod" || fields[0] == "dep"):
+				name = "go module " + fields[1]
+			}
+			spans = append(spans, span{name: name, start: start, end: start + len(line)})
+			start += len(line)
+		}
+		base = start
+	}
+}
+
+// ScanSections scans the strings of each data section of an ELF or PE binary
+// with s and reports findings with their section and file offsets
+func ScanSections(ctx context.Context, s *scanner.Scanner, data []byte, minLen int) ([]Finding, error) {
+	sections, err := Sections(data)
+	if err != nil {
+		return nil, err
+	}
+	spans := buildInfoSpans(data)
+
+	var findings []Finding
+	for _, sec := range sections {
+		results, err := Scan(ctx, s, sec.Data, minLen)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].StartIndex < results[j].StartIndex
+		})
+		for _, result := range results {
+			result.StartIndex += sec.Offset
+			result.EndIndex += sec.Offset
+			for i := range result.Locations {
+				result.Locations[i].StartIndex += sec.Offset
+				result.Locations[i].EndIndex += sec.Offset
+			}
+			findings = append(findings, Finding{Section: attribute(spans, sec.Name, result.StartIndex), Result: result})
+		}
+	}
+	return findings, nil
+}
+
+// attribute returns the name of the build info span containing offset, or the
+// section name
+func attribute(spans []span, section string, offset int) string {
+	for _, sp := range spans {
+		if sp.start <= offset && offset < sp.end {
+			return sp.name
+		}
+	}
+	return section
+}
@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main runs the scanner as a gRPC sidecar exposing only the scan
+// service and the standard gRPC health service.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/stackloklabs/secret-scanning-api/patterns"
+	"github.com/stackloklabs/secret-scanning-api/rules"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/sidecar"
+)
+
+const (
+	// connectionTimeout bounds the connection handshake, including TLS
+	connectionTimeout = 10 * time.Second
+	// maxConnectionIdle closes connections without calls for this long
+	maxConnectionIdle = 5 * time.Minute
+	// keepaliveTime and keepaliveTimeout ping idle connections and close
+	// those whose client stops answering
+	keepaliveTime    = time.Minute
+	keepaliveTimeout = 20 * time.Second
+	// minPingInterval is the most often clients may ping; connections that
+	// ping more often are closed
+	minPingInterval = 30 * time.Second
+)
+
+func main() {
+	var (
+		addr         string
+		patternsFile string
+		reload       time.Duration
+		secure       bool
+		workers      int
+		maxMessage   int
+		healthCheck  bool
+		tlsFiles     tlsFiles
+	)
+
+	flag.StringVar(&addr, "addr", "127.0.0.1:50051", "Address to listen on; the loopback by default, as the sidecar serves its own pod")
+	flag.StringVar(&patternsFile, "patterns-file", "", "YAML or JSON pattern or rule file to use instead of the embedded rule packs")
+	flag.DurationVar(&reload, "reload-interval", 0, "How often to check -patterns-file for changes and reload its rules; 0 disables reloading")
+	flag.BoolVar(&secure, "secure", true, "Never keep raw secret values in memory or responses")
+	flag.IntVar(&workers, "workers", 4, "Number of concurrent scan workers per request")
+	flag.IntVar(&maxMessage, "max-message-size", 4<<20, "Maximum request size in bytes")
+	flag.BoolVar(&healthCheck, "health-check", false, "Check the health of a running sidecar at -addr and exit")
+	flag.StringVar(&tlsFiles.cert, "tls-cert", "", "PEM certificate to serve TLS with; requires -tls-key")
+	flag.StringVar(&tlsFiles.key, "tls-key", "", "PEM private key of -tls-cert")
+	flag.StringVar(&tlsFiles.clientCA, "tls-client-ca", "", "PEM CA certificates that client certificates must be signed by (mTLS); requires -tls-cert")
+	flag.Parse()
+
+	if healthCheck {
+		if err := probe(addr, tlsFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Unhealthy: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(addr, tlsFiles, patternsFile, reload, secure, workers, maxMessage); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr string, tlsFiles tlsFiles, patternsFile string, reload time.Duration, secure bool, workers, maxMessage int) error {
+	creds, err := tlsFiles.server()
+	if err != nil {
+		return err
+	}
+	if reload > 0 && patternsFile == "" {
+		return errors.New("-reload-interval requires -patterns-file")
+	}
+
+	s := scanner.New(scanner.WithSecureMode(secure), scanner.WithWorkers(workers))
+	if patternsFile != "" {
+		loaded, err := patterns.LoadRules(patternsFile)
+		if err != nil {
+			return err
+		}
+		err = s.ReplaceRules(loaded)
+	} else {
+		var pats map[string]string
+		if pats, err = rules.LoadAll(); err != nil {
+			return err
+		}
+		err = s.ReplacePatterns(pats)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load patterns: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if reload > 0 {
+		errs := s.WatchRules(ctx, patternsFile, reload, patterns.LoadRules)
+		go func() {
+			for err := range errs {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}()
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	srv := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessage),
+		grpc.Creds(creds),
+		grpc.ConnectionTimeout(connectionTimeout),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: maxConnectionIdle,
+			Time:              keepaliveTime,
+			Timeout:           keepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             minPingInterval,
+			PermitWithoutStream: true,
+		}),
+	)
+	sidecar.Register(srv, sidecar.NewService(s))
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	hs.SetServingStatus(sidecar.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	// Report NOT_SERVING first so that traffic drains before the server stops
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		hs.Shutdown()
+		srv.GracefulStop()
+	}()
+
+	info := rules.Manifest()
+	fmt.Fprintf(os.Stderr, "Serving %s on %s with %d patterns (ruleset %s, rules %s)\n",
+		sidecar.ServiceName, lis.Addr(), len(s.Rules()), info.Version, info.Digest)
+	return srv.Serve(lis)
+}
+
+// probe calls the health service of the sidecar listening on addr
+func probe(addr string, tlsFiles tlsFiles) error {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	creds, err := tlsFiles.probe()
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: sidecar.ServiceName})
+	if err != nil {
+		return fmt.Errorf("failed to check health: %w", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("status %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// tlsFiles are the PEM files of the TLS flags
+type tlsFiles struct {
+	cert, key, clientCA string
+}
+
+// server returns the transport credentials of the server: TLS if a
+// certificate is given, requiring client certificates signed by clientCA if
+// given, and plaintext otherwise
+func (f tlsFiles) server() (credentials.TransportCredentials, error) {
+	if f.cert == "" && f.key == "" {
+		if f.clientCA != "" {
+			return nil, errors.New("-tls-client-ca requires -tls-cert and -tls-key")
+		}
+		return insecure.NewCredentials(), nil
+	}
+	cert, err := f.keyPair()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if f.clientCA != "" {
+		pem, err := os.ReadFile(f.clientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", f.clientCA)
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
+// probe returns the transport credentials of the health check, which
+// presents the server's own certificate when client certificates are
+// required. The probe checks a local process and sends nothing sensitive, so
+// the server certificate, issued for the name clients use, is not verified.
+func (f tlsFiles) probe() (credentials.TransportCredentials, error) {
+	if f.cert == "" && f.key == "" {
+		return insecure.NewCredentials(), nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}
+	if f.clientCA != "" {
+		cert, err := f.keyPair()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
+func (f tlsFiles) keyPair() (tls.Certificate, error) {
+	if f.cert == "" || f.key == "" {
+		return tls.Certificate{}, errors.New("-tls-cert and -tls-key must be given together")
+	}
+	cert, err := tls.LoadX509KeyPair(f.cert, f.key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+	return cert, nil
+}
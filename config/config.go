@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package config loads the YAML configuration of the secret-scanner CLI, so
+// that teams can commit their scanning policy next to their code
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// DefaultFile is the configuration file the CLI reads from the current
+// directory when no -config flag is given
+const DefaultFile = ".secret-scanner.yaml"
+
+// Config is the scanning policy of a repository
+type Config struct {
+	// Groups enables or disables the built-in pattern groups
+	Groups Groups `yaml:"groups"`
+	// Rules are custom patterns, by name, added to the enabled groups
+	Rules map[string]string `yaml:"rules"`
+	// Allowlist suppresses known findings
+	Allowlist Allowlist `yaml:"allowlist"`
+	// Exclude lists paths to skip when scanning a directory, in gitignore
+	// syntax, in addition to those of its .secretignore file
+	Exclude []string `yaml:"exclude"`
+	// Format is the output format, text, csaf, csv, github, gitlab-sast,
+	// junit, or markdown
+	Format string `yaml:"format"`
+	// MinSeverity drops findings below this severity
+	MinSeverity scanner.Severity `yaml:"min_severity"`
+	// FailOn is the lowest severity of the findings that make the CLI exit
+	// with status 1; any finding does if it is empty
+	FailOn scanner.Severity `yaml:"fail_on"`
+	// Workers is the number of concurrent scan workers
+	Workers int `yaml:"workers"`
+}
+
+// Groups enables or disables the built-in pattern groups, which are all
+// enabled unless set to false
+type Groups struct {
+	APIKeys     *bool `yaml:"apikeys"`
+	Passwords   *bool `yaml:"passwords"`
+	PrivateKeys *bool `yaml:"privatekeys"`
+}
+
+// Allowlist suppresses findings by rule, secret value, or fingerprint
+type Allowlist struct {
+	// Rules are rule names that are never reported
+	Rules []string `yaml:"rules"`
+	// Values are regular expressions matched against secret values, such as
+	// ^AKIA.*EXAMPLE$ for documentation keys
+	Values []string `yaml:"values"`
+	// Fingerprints are the fingerprints of accepted secrets; see
+	// scanner.Scanner.Fingerprint
+	Fingerprints []string `yaml:"fingerprints"`
+
+	values []*regexp.Regexp
+}
+
+// Load reads and validates a configuration file
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return Parse(content)
+}
+
+// Find loads DefaultFile from dir, returning nil if there is none
+func Find(dir string) (*Config, error) {
+	c, err := Load(filepath.Join(dir, DefaultFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Parse parses and validates a YAML configuration. Unknown keys are rejected
+// so that misspelled settings are not silently ignored.
+func Parse(content []byte) (*Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	for name, pattern := range c.Rules {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", name, err)
+		}
+	}
+	for _, value := range c.Allowlist.Values {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist value %q: %w", value, err)
+		}
+		c.Allowlist.values = append(c.Allowlist.values, re)
+	}
+	if c.MinSeverity != "" {
+		if _, err := scanner.ParseSeverity(string(c.MinSeverity)); err != nil {
+			return nil, fmt.Errorf("invalid min_severity: %w", err)
+		}
+	}
+	if c.FailOn != "" {
+		if _, err := scanner.ParseSeverity(string(c.FailOn)); err != nil {
+			return nil, fmt.Errorf("invalid fail_on: %w", err)
+		}
+	}
+	if c.Workers < 0 {
+		return nil, fmt.Errorf("invalid workers %d", c.Workers)
+	}
+	return &c, nil
+}
+
+// Allowed reports whether the allowlist suppresses result. Results without a
+// value, as in secure mode, are matched by rule and fingerprint only.
+func (a *Allowlist) Allowed(result scanner.Result) bool {
+	for _, rule := range a.Rules {
+		if result.Type == rule {
+			return true
+		}
+	}
+	for _, fingerprint := range a.Fingerprints {
+		if result.Fingerprint != "" && result.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	if result.Value != "" {
+		for _, re := range a.values {
+			if re.MatchString(result.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dirscan extracts the text files of a directory tree, including those
+// in archives, for scanning and groups files with identical content, so that
+// vendored copies and build outputs are scanned once
+package dirscan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/stackloklabs/secret-scanning-api/archive"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// maxFileSize bounds how much of a single file is read
+const maxFileSize = 10 * 1024 * 1024
+
+// skippedDirs are version control directories, which hold history rather than
+// the files of the tree
+var skippedDirs = map[string]bool{
+	".git": true,
+	".hg":  true,
+	".svn": true,
+}
+
+// File is a text file of a directory tree
+type File struct {
+	Path    string
+	Content string
+	// Size is the size of the file, which exceeds the length of Content for
+	// files larger than the 10 MiB that is read, or than WithMaxSize
+	Size int64
+	// Err is why the file or directory at Path could not be read, such as a
+	// permission error; such files have no Content
+	Err error
+}
+
+// Option configures Extract
+type Option func(*options)
+
+type options struct {
+	exclude    []string
+	ignoreFile bool
+	maxSize    int64
+}
+
+// WithExclude skips the paths matching patterns, in gitignore syntax as in
+// vendor/, *.min.js, or !keep.lock; see ParseIgnore. They apply after those of
+// the .secretignore file, so they can override it.
+func WithExclude(patterns ...string) Option {
+	return func(o *options) {
+		o.exclude = append(o.exclude, patterns...)
+	}
+}
+
+// WithMaxSize leaves files larger than n bytes unread: they are returned with
+// their Size but no Content, so that callers can report them. A size of 0,
+// the default, reads every file.
+func WithMaxSize(n int64) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// WithIgnoreFile sets whether the .secretignore file at the root of the tree
+// is read, which it is by default
+func WithIgnoreFile(enabled bool) Option {
+	return func(o *options) {
+		o.ignoreFile = enabled
+	}
+}
+
+// ExtractDir returns the text files under root, with paths joined to root.
+// Binary files and version control directories are skipped, and the files of
+// archives are returned with paths such as dist/release.zip!config/app.env.
+// A path that cannot be read fails the extraction.
+func ExtractDir(root string, opts ...Option) ([]File, error) {
+	var files []File
+	err := WalkDir(root, func(f File) error {
+		if f.Err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Path, f.Err)
+		}
+		files = append(files, f)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Extract returns the text files of fsys, skipping the paths excluded by its
+// .secretignore file and WithExclude. A path that cannot be read fails the
+// extraction.
+func Extract(fsys fs.FS, opts ...Option) ([]File, error) {
+	var files []File
+	err := Walk(fsys, func(f File) error {
+		if f.Err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Path, f.Err)
+		}
+		files = append(files, f)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// WalkDir calls fn with each text file under root as it is read, with paths
+// joined to root, so that a large tree need not be held in memory. Errors
+// returned by fn stop the walk.
+func WalkDir(root string, fn func(File) error, opts ...Option) error {
+	return Walk(os.DirFS(root), func(f File) error {
+		f.Path = filepath.Join(root, filepath.FromSlash(f.Path))
+		return fn(f)
+	}, opts...)
+}
+
+// Walk calls fn with each text file of fsys as it is read, skipping the paths
+// excluded by its .secretignore file and WithExclude. Files and directories
+// that cannot be read, such as for lack of permission, are passed with Err set
+// and the walk goes on. Errors returned by fn stop the walk.
+func Walk(fsys fs.FS, fn func(File) error, opts ...Option) error {
+	o := options{ignoreFile: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ignore, err := loadIgnore(fsys, o)
+	if err != nil {
+		return err
+	}
+
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == "." {
+				return err
+			}
+			// An unreadable directory is skipped along with its files
+			return fn(File{Path: p, Err: err})
+		}
+		if d.IsDir() {
+			if skippedDirs[d.Name()] || p != "." && ignore.Match(p, true) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ignore.Match(p, false) {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fn(File{Path: p, Err: err})
+		}
+		if o.maxSize > 0 && info.Size() > o.maxSize {
+			return fn(File{Path: p, Size: info.Size()})
+		}
+
+		content, err := readFile(fsys, p, maxFileSize)
+		if err != nil {
+			return fn(File{Path: p, Err: err})
+		}
+		if archive.IsArchive(content) {
+			if len(content) == maxFileSize {
+				if content, err = readFile(fsys, p, archive.DefaultLimits.MaxTotalSize); err != nil {
+					return fn(File{Path: p, Err: err})
+				}
+			}
+			// Archives that fail to open are skipped like other binary files,
+			// and those over the size limit are scanned up to it
+			inner, _ := archive.Extract(p, content, archive.DefaultLimits)
+			for _, f := range inner {
+				if err := fn(File{Path: f.Path, Content: f.Content, Size: int64(len(f.Content))}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if scanner.IsBinary(content) {
+			return nil
+		}
+		return fn(File{Path: p, Content: string(content), Size: info.Size()})
+	})
+}
+
+// loadIgnore returns the exclusions of the .secretignore file of fsys, if
+// enabled and present, followed by those of WithExclude
+func loadIgnore(fsys fs.FS, o options) (*Ignore, error) {
+	ignore := &Ignore{}
+	if o.ignoreFile {
+		content, err := fs.ReadFile(fsys, IgnoreFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+		}
+		if ignore, err = ParseIgnore(string(content)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", IgnoreFile, err)
+		}
+	}
+	for _, pattern := range o.exclude {
+		if err := ignore.Add(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return ignore, nil
+}
+
+func readFile(fsys fs.FS, p string, limit int64) ([]byte, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit))
+}
+
+// Blob is a distinct file content and every path it was found at
+type Blob struct {
+	// Hash is the hex-encoded SHA-256 of the content
+	Hash    string
+	Paths   []string
+	Content string
+}
+
+// Unique groups files by content hash, in order of first occurrence, so that
+// each distinct content is scanned once and its findings attributed to all of
+// its paths
+func Unique(files []File) []Blob {
+	var blobs []Blob
+	index := make(map[string]int)
+	for _, f := range files {
+		hash := Hash(f.Content)
+		if i, ok := index[hash]; ok {
+			blobs[i].Paths = append(blobs[i].Paths, f.Path)
+			continue
+		}
+		index[hash] = len(blobs)
+		blobs = append(blobs, Blob{Hash: hash, Paths: []string{f.Path}, Content: f.Content})
+	}
+	return blobs
+}
+
+// Hash returns the hex-encoded SHA-256 of content, which identifies copies of
+// a file
+func Hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package dirscan
+
+import (
+	"bufio"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the file at the root of a tree that lists paths
+// to skip, in gitignore syntax
+const IgnoreFile = ".secretignore"
+
+// Ignore is a list of gitignore-style patterns matched against slash-separated
+// paths relative to the root of a tree
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ParseIgnore parses patterns in gitignore syntax, one per line: blank lines
+// and lines starting with # are skipped, a leading ! re-includes paths that an
+// earlier pattern excluded, a trailing / matches directories only, and
+// patterns without a slash but the trailing one match at any depth. *, ?, and
+// [...] match within a path segment and ** matches across segments.
+func ParseIgnore(text string) (*Ignore, error) {
+	ig := &Ignore{}
+	lines := bufio.NewScanner(strings.NewReader(text))
+	for n := 1; lines.Scan(); n++ {
+		if err := ig.Add(lines.Text()); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return ig, nil
+}
+
+// Add appends a pattern, as a line of a .secretignore file, to the list
+func (ig *Ignore) Add(line string) error {
+	line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " ")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	var re strings.Builder
+	re.WriteString("^")
+	if strings.HasPrefix(line, "/") {
+		line = line[1:]
+	} else if !strings.Contains(line, "/") {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			re.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	pattern, err := regexp.Compile(re.String())
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", line, err)
+	}
+	rule.pattern = pattern
+	ig.rules = append(ig.rules, rule)
+	return nil
+}
+
+// Match reports whether the slash-separated path p, a directory if dir is
+// set, is excluded: either by the last pattern that matches it, or because a
+// directory it is in is excluded
+func (ig *Ignore) Match(p string, dir bool) bool {
+	if ig == nil || len(ig.rules) == 0 {
+		return false
+	}
+	p = strings.Trim(p, "/")
+	for parent := path.Dir(p); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		if ig.match(parent, true) {
+			return true
+		}
+	}
+	return ig.match(p, dir)
+}
+
+// match applies the patterns to p alone
+func (ig *Ignore) match(p string, dir bool) bool {
+	excluded := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !dir || rule.negate != excluded {
+			continue
+		}
+		if rule.pattern.MatchString(p) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
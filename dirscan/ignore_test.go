@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package dirscan
+
+import (
+	"testing"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	ig, err := ParseIgnore(`# dependencies
+vendor/
+node_modules
+
+*.min.js
+/build
+docs/**/fixtures
+test?data/*.json
+*.lock
+!keep.lock
+\#notes
+`)
+	if err != nil {
+		t.Fatalf("ParseIgnore failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		dir  bool
+		want bool
+	}{
+		{path: "vendor", dir: true, want: true},
+		{path: "lib/vendor/config.json", want: true},
+		{path: "vendor", want: false},
+		{path: "web/node_modules/pkg/index.js", want: true},
+		{path: "static/app.min.js", want: true},
+		{path: "static/app.js", want: false},
+		{path: "build/out.txt", want: true},
+		{path: "src/build/out.txt", want: false},
+		{path: "docs/fixtures/key.pem", want: true},
+		{path: "docs/api/v1/fixtures/key.pem", want: true},
+		{path: "test-data/secrets.json", want: true},
+		{path: "test-data/nested/secrets.json", want: false},
+		{path: "yarn.lock", want: true},
+		{path: "keep.lock", want: false},
+		{path: "#notes", want: true},
+		{path: "config/app.env", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := ig.Match(tt.path, tt.dir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreInvalidPattern(t *testing.T) {
+	if _, err := ParseIgnore("ok\n[z-a]\n"); err == nil {
+		t.Error("ParseIgnore() error = nil, want an error for an invalid range")
+	}
+}
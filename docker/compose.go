@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/structscan"
+)
+
+// IsCompose reports whether path names a docker-compose file, such as
+// docker-compose.yml, compose.yaml, or docker-compose.prod.yml
+func IsCompose(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+	return strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose.") || strings.HasPrefix(base, "compose-")
+}
+
+// ParseCompose returns the environment variables and build args with values
+// of the services of a docker-compose file, in both map and KEY=value list
+// form, in document order
+func ParseCompose(content string) ([]Variable, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	var vars []Variable
+	services := mapping(doc.Content[0], "services")
+	if services == nil {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name, service := services.Content[i].Value, services.Content[i+1]
+		path := "services." + name
+		if env := mapping(service, "environment"); env != nil {
+			vars = append(vars, entries(content, env, RuleEnv, path+".environment")...)
+		}
+		if build := mapping(service, "build"); build != nil {
+			if args := mapping(build, "args"); args != nil {
+				vars = append(vars, entries(content, args, RuleBuildArg, path+".build.args")...)
+			}
+		}
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Start < vars[j].Start })
+	return vars, nil
+}
+
+// mapping returns the value of key in a mapping node, or nil
+func mapping(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// entries returns the variables of an environment or args node
+func entries(content string, node *yaml.Node, rule, path string) []Variable {
+	var vars []Variable
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if v.Kind != yaml.ScalarNode || v.Tag == "!!null" || v.Value == "" {
+				continue
+			}
+			start := structscan.ValueOffset(content, v)
+			vars = append(vars, Variable{Rule: rule, Name: k.Value, Value: v.Value, Start: start, End: start + len(v.Value), Path: path + "." + k.Value})
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			name, value, ok := strings.Cut(item.Value, "=")
+			if item.Kind != yaml.ScalarNode || !ok || value == "" {
+				continue
+			}
+			start := structscan.ValueOffset(content, item) + len(name) + 1
+			vars = append(vars, Variable{Rule: rule, Name: name, Value: value, Start: start, End: start + len(value), Path: path + "." + name})
+		}
+	}
+	return vars
+}
+
+// ScanCompose scans a docker-compose file with s and adds a result for the
+// value of each sensitive environment variable or build arg that no pattern
+// matched
+func ScanCompose(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	vars, err := ParseCompose(content)
+	if err != nil {
+		return nil, err
+	}
+	results, err := structscan.Scan(ctx, s, content)
+	if err != nil {
+		return nil, err
+	}
+	return flag(s, content, results, vars), nil
+}
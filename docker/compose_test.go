@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestScanCompose(t *testing.T) {
+	content := `services:
+  web:
+    build:
+      context: .
+      args:
+        - NPM_TOKEN=abcdef0123456789
+    environment:
+      LOG_LEVEL: debug
+      STRIPE_SECRET: "whsec_0123456789abcdef"
+      DB_PASSWORD: ${DB_PASSWORD}
+  worker:
+    environment:
+      - QUEUE_URL=amqp://worker:s3cr3t@mq:5672/
+`
+	results, err := ScanCompose(context.Background(), scanner.New(), content)
+	if err != nil {
+		t.Fatalf("ScanCompose failed: %v", err)
+	}
+
+	want := []struct {
+		rule, path, value string
+		line              int
+	}{
+		{RuleBuildArg, "services.web.build.args.NPM_TOKEN", "abcdef0123456789", 6},
+		{RuleEnv, "services.web.environment.STRIPE_SECRET", "whsec_0123456789abcdef", 9},
+		{RuleEnv, "services.worker.environment.QUEUE_URL", "amqp://worker:s3cr3t@mq:5672/", 13},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("ScanCompose found %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Type != w.rule || got.Path != w.path || got.LineNumber != w.line {
+			t.Errorf("results[%d] = %s at %s line %d, want %s at %s line %d", i, got.Type, got.Path, got.LineNumber, w.rule, w.path, w.line)
+		}
+		if span := content[got.StartIndex:got.EndIndex]; span != w.value {
+			t.Errorf("results[%d] span = %q, want %q", i, span, w.value)
+		}
+	}
+}
+
+func TestIsCompose(t *testing.T) {
+	for path, want := range map[string]bool{
+		"docker-compose.yml":      true,
+		"deploy/compose.yaml":     true,
+		"docker-compose.prod.yml": true,
+		"compose.go":              false,
+		"values.yaml":             false,
+	} {
+		if got := IsCompose(path); got != want {
+			t.Errorf("IsCompose(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package docker detects secrets passed to containers through Dockerfile ENV
+// and ARG instructions and docker-compose environment and build args, which
+// bake keys into image layers, history, and container metadata
+package docker
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/dotenv"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Rules of values flagged by their variable name. Build args are kept in the
+// image history of every image built with them, while environment variables
+// are visible to anything that can inspect the container.
+const (
+	RuleBuildArg = "docker_build_arg_secret"
+	RuleEnv      = "docker_env_secret"
+)
+
+// confidence is the confidence of values flagged by their variable name
+const confidence = 0.7
+
+// Variable is a build arg or environment variable with a value
+type Variable struct {
+	// Rule is RuleBuildArg or RuleEnv
+	Rule  string
+	Name  string
+	Value string
+	// Start and End span the value, without quotes
+	Start, End int
+	// Path locates the variable, as in "ARG GITHUB_TOKEN" or
+	// "services.web.environment.API_KEY"
+	Path string
+}
+
+// IsDockerfile reports whether path names a Dockerfile or Containerfile, such
+// as Dockerfile, Dockerfile.prod, or api.Dockerfile. Variants must be
+// capitalized, so that sources such as dockerfile.go are not mistaken for one.
+func IsDockerfile(path string) bool {
+	base := filepath.Base(path)
+	for _, name := range []string{"Dockerfile", "Containerfile"} {
+		if strings.EqualFold(base, name) || strings.HasPrefix(base, name+".") ||
+			strings.HasSuffix(strings.ToLower(base), "."+strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// instruction matches the ENV or ARG keyword at the start of a line
+var instruction = regexp.MustCompile(`(?im)^[ \t]*(ENV|ARG)[ \t]+`)
+
+// ParseDockerfile returns the variables with values set by the ENV and ARG
+// instructions of a Dockerfile, in both the KEY=value and legacy ENV KEY value
+// forms, following line continuations
+func ParseDockerfile(content string) []Variable {
+	var vars []Variable
+	for _, m := range instruction.FindAllStringSubmatchIndex(content, -1) {
+		rule := RuleEnv
+		if strings.EqualFold(content[m[2]:m[3]], "ARG") {
+			rule = RuleBuildArg
+		}
+		prefix := strings.ToUpper(content[m[2]:m[3]]) + " "
+		end := logicalEnd(content, m[1])
+
+		pos := skipSpace(content, m[1], end)
+		nameEnd := pos
+		for nameEnd < end && !isSpace(content[nameEnd]) && content[nameEnd] != '=' {
+			nameEnd++
+		}
+		if nameEnd < end && content[nameEnd] != '=' && rule == RuleEnv {
+			// Legacy form: ENV KEY value with spaces
+			start := skipSpace(content, nameEnd, end)
+			valueEnd := end
+			for valueEnd > start && isSpace(content[valueEnd-1]) {
+				valueEnd--
+			}
+			if start < valueEnd {
+				name := content[pos:nameEnd]
+				vars = append(vars, Variable{Rule: rule, Name: name, Value: content[start:valueEnd], Start: start, End: valueEnd, Path: prefix + name})
+			}
+			continue
+		}
+
+		for pos < end {
+			nameEnd := pos
+			for nameEnd < end && !isSpace(content[nameEnd]) && content[nameEnd] != '=' {
+				nameEnd++
+			}
+			name := content[pos:nameEnd]
+			if nameEnd >= end || content[nameEnd] != '=' {
+				// ARG without a default
+				pos = skipSpace(content, nameEnd, end)
+				continue
+			}
+			start, valueEnd, next := value(content, nameEnd+1, end)
+			if start < valueEnd {
+				vars = append(vars, Variable{Rule: rule, Name: name, Value: content[start:valueEnd], Start: start, End: valueEnd, Path: prefix + name})
+			}
+			pos = skipSpace(content, next, end)
+		}
+	}
+	return vars
+}
+
+// logicalEnd returns the end of the instruction starting at pos, following
+// backslash line continuations
+func logicalEnd(content string, pos int) int {
+	for {
+		nl := strings.IndexByte(content[pos:], '\n')
+		if nl < 0 {
+			return len(content)
+		}
+		lineEnd := pos + nl
+		if !strings.HasSuffix(strings.TrimRight(content[pos:lineEnd], " \t\r"), `\`) {
+			return lineEnd
+		}
+		pos = lineEnd + 1
+	}
+}
+
+// value returns the span of the possibly quoted value starting at pos, and
+// where scanning resumes after it
+func value(content string, pos, end int) (int, int, int) {
+	if pos < end && (content[pos] == '"' || content[pos] == '\'') {
+		quote := content[pos]
+		for i := pos + 1; i < end; i++ {
+			if content[i] == '\\' && quote == '"' {
+				i++
+				continue
+			}
+			if content[i] == quote {
+				return pos + 1, i, i + 1
+			}
+		}
+		return pos + 1, end, end
+	}
+	i := pos
+	for i < end && !isSpace(content[i]) {
+		i++
+	}
+	return pos, i, i
+}
+
+// skipSpace skips whitespace and line continuations
+func skipSpace(content string, pos, end int) int {
+	for pos < end {
+		switch {
+		case isSpace(content[pos]):
+			pos++
+		case content[pos] == '\\' && pos+1 < len(content) && (content[pos+1] == '\n' || content[pos+1] == '\r'):
+			pos += 2
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// ScanDockerfile scans a Dockerfile with s and adds a result for the value of
+// each sensitive ENV or ARG variable that no pattern matched
+func ScanDockerfile(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	results, err := s.Scan(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	return flag(s, content, results, ParseDockerfile(content)), nil
+}
+
+// flag attributes results to the variables they were found in and adds a
+// result for each sensitive variable without one
+func flag(s *scanner.Scanner, content string, results []scanner.Result, vars []Variable) []scanner.Result {
+	for _, v := range vars {
+		matched := false
+		for i := range results {
+			r := &results[i]
+			if r.StartIndex < v.Start || r.StartIndex >= v.End {
+				continue
+			}
+			if r.Path == "" {
+				r.Path = v.Path
+			}
+			// Low-severity matches, such as generic password shapes, do not
+			// tell where the value leaks
+			matched = matched || r.Severity.AtLeast(scanner.SeverityMedium)
+		}
+		if matched || len(v.Value) < 4 || dotenv.IsReference(v.Value) || scanner.IsPlaceholder(v.Value) || !dotenv.Sensitive(v.Name, v.Value) {
+			continue
+		}
+		line := strings.Count(content[:v.Start], "\n") + 1
+		result := s.NewResult(v.Rule, v.Value, v.Start, line, confidence)
+		result.Path = v.Path
+		results = append(results, result)
+	}
+	return results
+}
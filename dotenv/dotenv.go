@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dotenv parses dotenv-style KEY=value files and flags the values of
+// variables whose names mark them as secrets, such as AWS_SECRET_ACCESS_KEY or
+// STRIPE_TOKEN, even when the value matches no provider pattern
+package dotenv
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Rule is the result type of values flagged by their variable name
+const Rule = "dotenv_secret"
+
+// confidence is the confidence of values flagged by their variable name
+const confidence = 0.7
+
+// Variable is an assignment in a dotenv file
+type Variable struct {
+	Name  string
+	Value string
+	// Start and End span the value, without quotes
+	Start, End int
+	Line       int
+}
+
+// sensitiveNames are variable names that hold secrets whatever their suffix
+var sensitiveNames = map[string]bool{
+	"AWS_SECRET_ACCESS_KEY": true,
+	"AWS_SESSION_TOKEN":     true,
+	"GOOGLE_API_KEY":        true,
+	"NPM_TOKEN":             true,
+	"SECRET_KEY_BASE":       true,
+	"DJANGO_SECRET_KEY":     true,
+	"SENTRY_DSN":            true,
+}
+
+// sensitiveSuffix matches variable names that hold secrets by convention
+var sensitiveSuffix = regexp.MustCompile(`(?i)(?:^|_)(?:TOKEN|SECRET|PASSWORD|PASSWD|PASS|PWD|API_?KEY|ACCESS_KEY|PRIVATE_KEY|SECRET_KEY|CREDENTIALS?|AUTH)$`)
+
+// urlNames are variable names holding connection URLs, which are secrets
+// when they carry a password, as in postgres://app:hunter2@db/app
+var urlNames = regexp.MustCompile(`(?i)(?:^|_)(?:URL|URI|DSN)$`)
+
+// reference matches values that refer to another variable or a secret store
+// instead of holding the secret
+var reference = regexp.MustCompile(`^(?:\$\{?[A-Za-z_][A-Za-z0-9_]*\}?|<[^>]*>)$`)
+
+// IsDotenv reports whether path names a dotenv file, such as .env,
+// .env.production, or app.env
+func IsDotenv(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// assignment matches the start of an assignment line up to the value
+var assignment = regexp.MustCompile(`^[ \t]*(?:export[ \t]+)?([A-Za-z_][A-Za-z0-9_.]*)[ \t]*=[ \t]*`)
+
+// Parse returns the assignments of a dotenv file. Values may be unquoted,
+// with # starting an inline comment, or single- or double-quoted, and
+// double-quoted values may span lines.
+func Parse(content string) []Variable {
+	var vars []Variable
+	line := 1
+	for pos := 0; pos < len(content); {
+		end := strings.IndexByte(content[pos:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += pos
+		}
+		next := end + 1
+
+		if m := assignment.FindStringSubmatchIndex(content[pos:end]); m != nil {
+			v := Variable{Name: content[pos+m[2] : pos+m[3]], Line: line}
+			start := pos + m[1]
+			switch {
+			case start < len(content) && (content[start] == '"' || content[start] == '\''):
+				quote := content[start]
+				closing := strings.IndexByte(content[start+1:], quote)
+				if closing >= 0 && (quote == '"' || !strings.Contains(content[start+1:start+1+closing], "\n")) {
+					v.Start, v.End = start+1, start+1+closing
+					if e := strings.IndexByte(content[v.End:], '\n'); e >= 0 {
+						next = v.End + e + 1
+					} else {
+						next = len(content)
+					}
+				} else {
+					v.Start, v.End = start, end
+				}
+			default:
+				value := content[start:end]
+				if i := strings.Index(value, " #"); i >= 0 {
+					value = value[:i]
+				}
+				value = strings.TrimRight(value, " \t\r")
+				v.Start, v.End = start, start+len(value)
+			}
+			v.Value = content[v.Start:v.End]
+			vars = append(vars, v)
+		}
+		line += strings.Count(content[pos:min(next, len(content))], "\n")
+		pos = next
+	}
+	return vars
+}
+
+// IsReference reports whether value refers to another variable or a secret
+// store, as ${VAULT_TOKEN} does, instead of holding a secret
+func IsReference(value string) bool {
+	return reference.MatchString(value)
+}
+
+// Sensitive reports whether a variable with the given name and value holds a
+// secret by its name: a known secret name, a conventional suffix such as
+// _TOKEN or _PASSWORD, or a connection URL with a password
+func Sensitive(name, value string) bool {
+	upper := strings.ToUpper(name)
+	if sensitiveNames[upper] || sensitiveSuffix.MatchString(upper) {
+		return true
+	}
+	if urlNames.MatchString(upper) {
+		u, err := url.Parse(value)
+		if err != nil || u.User == nil {
+			return false
+		}
+		_, ok := u.User.Password()
+		return ok
+	}
+	return false
+}
+
+// Scan scans a dotenv file with s and adds a result for the value of each
+// sensitive variable that no pattern matched. Results in a variable's value
+// have Path set to the variable name.
+func Scan(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	results, err := s.Scan(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range Parse(content) {
+		matched := false
+		for i := range results {
+			r := &results[i]
+			if r.StartIndex >= max(v.End, v.Start+1) || r.EndIndex <= v.Start || r.Path != "" {
+				continue
+			}
+			r.Path = v.Name
+			// A match that begins before the value, such as one on the whole
+			// assignment, or a low-severity one such as a generic password
+			// shape does not stand for the value itself
+			matched = matched || (r.StartIndex >= v.Start && r.Severity.AtLeast(scanner.SeverityMedium))
+		}
+		if matched || len(v.Value) < 4 || IsReference(v.Value) || scanner.IsPlaceholder(v.Value) || !Sensitive(v.Name, v.Value) {
+			continue
+		}
+		result := s.NewResult(Rule, v.Value, v.Start, v.Line, confidence)
+		result.Path = v.Name
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package extension extracts scannable sources from browser extension packages
+// (CRX, XPI, or unpacked directories), resolving source maps of bundled scripts
+package extension
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maxFileSize bounds how much of a single package file is read
+const maxFileSize = 10 * 1024 * 1024
+
+// File is a source file of an extension package. Sources recovered from a
+// source map are named "<bundle> -> <original source>".
+type File struct {
+	Path    string
+	Content string
+}
+
+var (
+	crxMagic = []byte("Cr24")
+
+	sourceMappingURL = regexp.MustCompile(`(?m)^\s*//[#@]\s*sourceMappingURL=(\S+)\s*$`)
+
+	// Files that are never scanned: signatures and hashes generated by the stores
+	skippedFiles = map[string]bool{
+		"_metadata/verified_contents.json": true,
+		"_metadata/computed_hashes.json":   true,
+		"META-INF/mozilla.rsa":             true,
+		"META-INF/cose.sig":                true,
+	}
+
+	sourceExtensions = map[string]bool{
+		".js":   true,
+		".mjs":  true,
+		".cjs":  true,
+		".json": true,
+		".html": true,
+	}
+)
+
+// ExtractFile reads the extension at path, which may be a .crx or .xpi package
+// or an unpacked extension directory
+func ExtractFile(p string) ([]File, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extension: %w", err)
+	}
+	if info.IsDir() {
+		return Extract(os.DirFS(p))
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extension: %w", err)
+	}
+	zr, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(zr)
+}
+
+// openPackage opens a CRX (version 2 or 3) or plain zip package
+func openPackage(data []byte) (*zip.Reader, error) {
+	if bytes.HasPrefix(data, crxMagic) {
+		if len(data) < 16 {
+			return nil, errors.New("truncated CRX header")
+		}
+		le := binary.LittleEndian
+		var start uint64
+		switch version := le.Uint32(data[4:]); version {
+		case 2:
+			start = 16 + uint64(le.Uint32(data[8:])) + uint64(le.Uint32(data[12:]))
+		case 3:
+			start = 12 + uint64(le.Uint32(data[8:]))
+		default:
+			return nil, fmt.Errorf("unsupported CRX version %d", version)
+		}
+		if start > uint64(len(data)) {
+			return nil, errors.New("truncated CRX header")
+		}
+		data = data[start:]
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open extension package: %w", err)
+	}
+	return zr, nil
+}
+
+// Extract returns the manifest, scripts, and pages of an extension. Scripts
+// with a resolvable source map are followed by their original sources, as
+// the bundle may hold secrets injected at build time that no source has.
+func Extract(fsys fs.FS) ([]File, error) {
+	var files []File
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || skippedFiles[p] || !sourceExtensions[strings.ToLower(path.Ext(p))] {
+			return nil
+		}
+
+		content, err := readFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+
+		files = append(files, File{Path: p, Content: content})
+		if isScript(p) {
+			files = append(files, resolveSourceMap(fsys, p, content)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func readFile(fsys fs.FS, p string) (string, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, maxFileSize))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func isScript(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+// sourceMap holds the fields of a version 3 source map needed to recover sources
+type sourceMap struct {
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+}
+
+// resolveSourceMap returns the original sources embedded in the source map of
+// the script at p, either inline as a data URI or as a file in the package
+func resolveSourceMap(fsys fs.FS, p, content string) []File {
+	matches := sourceMappingURL.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	url := matches[len(matches)-1][1]
+
+	var raw []byte
+	if strings.HasPrefix(url, "data:") {
+		comma := strings.Index(url, ",")
+		if comma < 0 || !strings.HasSuffix(url[:comma], ";base64") {
+			return nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(url[comma+1:])
+		if err != nil {
+			return nil
+		}
+		raw = decoded
+	} else {
+		if strings.Contains(url, "://") {
+			return nil // remote source maps are not fetched
+		}
+		mapPath := path.Join(path.Dir(p), url)
+		mapContent, err := readFile(fsys, mapPath)
+		if err != nil {
+			return nil
+		}
+		raw = []byte(mapContent)
+	}
+
+	var sm sourceMap
+	if err := json.Unmarshal(raw, &sm); err != nil {
+		return nil
+	}
+
+	var files []File
+	for i, source := range sm.Sources {
+		if i >= len(sm.SourcesContent) || sm.SourcesContent[i] == nil {
+			continue
+		}
+		files = append(files, File{
+			Path:    p + " -> " + sm.SourceRoot + source,
+			Content: *sm.SourcesContent[i],
+		})
+	}
+	return files
+}
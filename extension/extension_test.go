@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package extension
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	manifest      = `{"manifest_version": 3, "name": "Test", "background": {"service_worker": "background.js"}}`
+	originalCode  = `export const config = { clientSecret: "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" };`
+	bundledScript = "(()=>{const e={clientSecret:\"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\"}})();\n//# sourceMappingURL=background.js.map\n"
+	sourceMapJSON = `{"version":3,"sourceRoot":"webpack:///","sources":["src/config.js","node_modules/lib/index.js"],"sourcesContent":["` +
+		`export const config = { clientSecret: \"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\" };",null],"mappings":"AAAA"}`
+)
+
+func testFS() fstest.MapFS {
+	inlineMap := base64.StdEncoding.EncodeToString([]byte(sourceMapJSON))
+	return fstest.MapFS{
+		"manifest.json":                    {Data: []byte(manifest)},
+		"background.js":                    {Data: []byte(bundledScript)},
+		"background.js.map":                {Data: []byte(sourceMapJSON)},
+		"content/inline.js":                {Data: []byte("x();\n//# sourceMappingURL=data:application/json;base64," + inlineMap + "\n")},
+		"popup.js":                         {Data: []byte("const apiKey = 'AIzaSyC93b6FxR4r4Q1jIxyz789example12';\n")},
+		"_metadata/verified_contents.json": {Data: []byte(`[{"signed_content": "..."}]`)},
+		"icon.png":                         {Data: []byte("\x89PNG")},
+	}
+}
+
+func paths(files []File) []string {
+	var out []string
+	for _, f := range files {
+		out = append(out, f.Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func checkFiles(t *testing.T, files []File) {
+	t.Helper()
+	want := []string{
+		"background.js",
+		"background.js -> webpack:///src/config.js",
+		"content/inline.js",
+		"content/inline.js -> webpack:///src/config.js",
+		"manifest.json",
+		"popup.js",
+	}
+	got := paths(files)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Extract() paths = %v, want %v", got, want)
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f.Path, "src/config.js") && f.Content != originalCode {
+			t.Errorf("Resolved source %s = %q, want %q", f.Path, f.Content, originalCode)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	files, err := Extract(testFS())
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	checkFiles(t, files)
+}
+
+func buildZip(t *testing.T, fsys fstest.MapFS) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, f := range fsys {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		entry.Write(f.Data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractFile(t *testing.T) {
+	archive := buildZip(t, testFS())
+
+	var crx3 bytes.Buffer
+	crx3.Write(crxMagic)
+	binary.Write(&crx3, binary.LittleEndian, []uint32{3, 5})
+	crx3.WriteString("proto")
+	crx3.Write(archive)
+
+	dir := t.TempDir()
+	for name, f := range testFS() {
+		p := filepath.Join(dir, "unpacked", filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, f.Data, 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "extension.xpi", content: archive},
+		{name: "extension.crx", content: crx3.Bytes()},
+		{name: "unpacked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, tt.name)
+			if tt.content != nil {
+				if err := os.WriteFile(p, tt.content, 0o600); err != nil {
+					t.Fatalf("Failed to write package: %v", err)
+				}
+			}
+
+			files, err := ExtractFile(p)
+			if err != nil {
+				t.Fatalf("ExtractFile failed: %v", err)
+			}
+			checkFiles(t, files)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package firmware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	cpioHeaderSize = 110
+	cpioTrailer    = "TRAILER!!!"
+	modeTypeMask   = 0o170000
+	modeRegular    = 0o100000
+)
+
+var errCpioTruncated = errors.New("truncated cpio archive")
+
+// extractCpio reads one or more concatenated newc/crc cpio archives, as used
+// by Linux initramfs images, inside layers compression layers. A gzip member
+// after the first archive, such as the main archive behind an early microcode
+// archive, is decompressed and read to the end of the image.
+func extractCpio(data []byte, layers int) ([]File, error) {
+	var files []File
+	for off := 0; off < len(data); {
+		// Archives in an initramfs may be separated by NUL padding
+		if data[off] == 0 {
+			off++
+			continue
+		}
+		if off > 0 && bytes.HasPrefix(data[off:], gzipMagic) {
+			if layers >= maxCompressionLayers {
+				return files, errTooManyLayers
+			}
+			decompressed, err := gunzip(data[off:])
+			if err != nil {
+				return files, err
+			}
+			rest, err := extractCpio(decompressed, layers+1)
+			return append(files, rest...), err
+		}
+		if !bytes.HasPrefix(data[off:], cpioMagic) {
+			if off > 0 {
+				// Trailing data that is neither padding nor an archive
+				break
+			}
+			return nil, fmt.Errorf("unsupported cpio format at offset %d", off)
+		}
+		if off+cpioHeaderSize > len(data) {
+			return files, errCpioTruncated
+		}
+
+		header := data[off : off+cpioHeaderSize]
+		mode, err := cpioField(header, 1)
+		if err != nil {
+			return files, err
+		}
+		fileSize, err := cpioField(header, 6)
+		if err != nil {
+			return files, err
+		}
+		nameSize, err := cpioField(header, 11)
+		if err != nil {
+			return files, err
+		}
+
+		nameStart := off + cpioHeaderSize
+		dataStart := align4(nameStart + nameSize)
+		dataEnd := dataStart + fileSize
+		if nameSize == 0 || dataEnd > len(data) {
+			return files, errCpioTruncated
+		}
+		name := strings.TrimRight(string(data[nameStart:nameStart+nameSize]), "\x00")
+		if name == cpioTrailer {
+			off = align4(dataEnd)
+			continue
+		}
+
+		if mode&modeTypeMask == modeRegular && fileSize <= maxFileSize {
+			if f, ok := newFile(strings.TrimPrefix(name, "./"), data[dataStart:dataEnd]); ok {
+				files = append(files, f)
+			}
+		}
+		off = align4(dataEnd)
+	}
+	return files, nil
+}
+
+// cpioField parses the n-th 8-digit hex field following the 6-byte magic
+func cpioField(header []byte, n int) (int, error) {
+	start := 6 + 8*n
+	v, err := strconv.ParseUint(string(header[start:start+8]), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpio header: %w", err)
+	}
+	return int(v), nil
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
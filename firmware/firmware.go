@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package firmware unpacks firmware container formats (gzip squashfs, cpio
+// initramfs) so that the extracted filesystems can be scanned for secrets
+package firmware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+const (
+	// maxFileSize bounds how much of a single extracted file is kept
+	maxFileSize = 10 * 1024 * 1024
+	// maxImageSize bounds the size of a decompressed image
+	maxImageSize = 512 * 1024 * 1024
+	// maxCompressionLayers bounds the gzip layers peeled off an image, so
+	// that data that decompresses to itself is not unpacked without end
+	maxCompressionLayers = 2
+)
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	squashfsMagic = []byte("hsqs")
+	cpioMagic     = []byte("07070")
+)
+
+var (
+	// ErrUnknownFormat is returned when no supported filesystem is found in an image
+	ErrUnknownFormat = errors.New("no supported firmware filesystem found")
+
+	errTooManyLayers = errors.New("too many compression layers")
+)
+
+// File is a regular text file extracted from a firmware image
+type File struct {
+	Path    string
+	Content string
+}
+
+// ExtractFile reads the firmware image at path and extracts its text files
+func ExtractFile(path string) ([]File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read firmware image: %w", err)
+	}
+	return Extract(data)
+}
+
+// Extract unpacks a squashfs or (optionally gzip-compressed) cpio image. If the
+// image does not start with a known format, it is searched for an embedded
+// squashfs or cpio filesystem, as found behind vendor headers and kernels.
+func Extract(data []byte) ([]File, error) {
+	return extract(data, 0)
+}
+
+// extract is Extract for an image inside layers compression layers
+func extract(data []byte, layers int) ([]File, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		if layers >= maxCompressionLayers {
+			return nil, errTooManyLayers
+		}
+		decompressed, err := gunzip(data)
+		if err != nil {
+			return nil, err
+		}
+		return extract(decompressed, layers+1)
+	case bytes.HasPrefix(data, squashfsMagic):
+		return extractSquashfs(data)
+	case bytes.HasPrefix(data, cpioMagic):
+		return extractCpio(data, layers)
+	}
+
+	var files []File
+	found, unsupported := false, false
+	for _, magic := range [][]byte{squashfsMagic, cpioMagic} {
+		for off := bytes.Index(data, magic); off > 0; {
+			extracted, err := extract(data[off:], layers)
+			if err == nil {
+				files = append(files, extracted...)
+				found = true
+				break
+			}
+			unsupported = unsupported || errors.Is(err, errUnsupportedCompress)
+			next := bytes.Index(data[off+1:], magic)
+			if next < 0 {
+				break
+			}
+			off += next + 1
+		}
+	}
+	if !found {
+		if unsupported {
+			// A squashfs with xz, lzma, lzo, or zstd blocks, as most router
+			// images have
+			return nil, errUnsupportedCompress
+		}
+		return nil, ErrUnknownFormat
+	}
+	return files, nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress image: %w", err)
+	}
+	defer zr.Close()
+
+	// Compressed initramfs images may have trailing padding
+	zr.Multistream(false)
+	decompressed, err := io.ReadAll(io.LimitReader(zr, maxImageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress image: %w", err)
+	}
+	return decompressed, nil
+}
+
+// newFile returns a File for extracted content, skipping binary files
+func newFile(path string, content []byte) (File, bool) {
+	if scanner.IsBinary(content) {
+		return File{}, false
+	}
+	return File{Path: path, Content: string(content)}, true
+}
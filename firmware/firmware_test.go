@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package firmware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+const (
+	shadowContent = "root:$6$salt$hashedpassword:19000:0:99999:7:::\n"
+	configContent = "wifi_password=SuperSecret123!\n"
+)
+
+type cpioEntry struct {
+	name    string
+	mode    int
+	content string
+}
+
+func buildCpio(entries []cpioEntry) []byte {
+	var buf bytes.Buffer
+	write := func(e cpioEntry) {
+		name := e.name + "\x00"
+		fmt.Fprintf(&buf, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			0, e.mode, 0, 0, 1, 0, len(e.content), 0, 0, 0, 0, len(name), 0)
+		buf.WriteString(name)
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+		buf.WriteString(e.content)
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	for _, e := range entries {
+		write(e)
+	}
+	write(cpioEntry{name: cpioTrailer})
+	return buf.Bytes()
+}
+
+// buildSquashfs writes a minimal gzip squashfs image containing config (stored
+// in a fragment) and etc/shadow (stored in a compressed data block)
+func buildSquashfs(t *testing.T) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write([]byte(shadowContent))
+	zw.Close()
+
+	shadowStart := uint64(superblockSize)
+	fragmentStart := shadowStart + uint64(compressed.Len())
+
+	inodeHeader := func(b *bytes.Buffer, typ uint16, number uint32) {
+		binary.Write(b, le, []uint16{typ, 0o755, 0, 0})
+		binary.Write(b, le, []uint32{0, number})
+	}
+
+	// Inode table: root dir @0, etc dir @32, shadow @64, config @100
+	var inodes bytes.Buffer
+	inodeHeader(&inodes, inodeDir, 1)
+	binary.Write(&inodes, le, []uint32{0, 2})
+	binary.Write(&inodes, le, []uint16{37 + 3, 0})
+	binary.Write(&inodes, le, uint32(1))
+	inodeHeader(&inodes, inodeDir, 2)
+	binary.Write(&inodes, le, []uint32{0, 2})
+	binary.Write(&inodes, le, []uint16{26 + 3, 37})
+	binary.Write(&inodes, le, uint32(1))
+	inodeHeader(&inodes, inodeFile, 3)
+	binary.Write(&inodes, le, []uint32{uint32(shadowStart), noFragment, 0, uint32(len(shadowContent))})
+	binary.Write(&inodes, le, uint32(compressed.Len()))
+	inodeHeader(&inodes, inodeFile, 4)
+	binary.Write(&inodes, le, []uint32{0, 0, 0, uint32(len(configContent))})
+
+	// Directory table: root listing @0, etc listing @37
+	var dirs bytes.Buffer
+	dirEntry := func(offset, typ uint16, name string) {
+		binary.Write(&dirs, le, []uint16{offset, 0, typ, uint16(len(name) - 1)})
+		dirs.WriteString(name)
+	}
+	binary.Write(&dirs, le, []uint32{1, 0, 1})
+	dirEntry(100, inodeFile, "config")
+	dirEntry(32, inodeDir, "etc")
+	binary.Write(&dirs, le, []uint32{0, 0, 2})
+	dirEntry(64, inodeFile, "shadow")
+
+	var image bytes.Buffer
+	image.Write(make([]byte, superblockSize))
+	image.Write(compressed.Bytes())
+	image.WriteString(configContent)
+
+	metadata := func(b []byte) uint64 {
+		pos := uint64(image.Len())
+		binary.Write(&image, le, uint16(len(b))|metadataUncompressed)
+		image.Write(b)
+		return pos
+	}
+	inodeTable := metadata(inodes.Bytes())
+	dirTable := metadata(dirs.Bytes())
+
+	var fragments bytes.Buffer
+	binary.Write(&fragments, le, fragmentStart)
+	binary.Write(&fragments, le, []uint32{uint32(len(configContent)) | blockUncompressed, 0})
+	fragmentEntries := metadata(fragments.Bytes())
+	fragTable := uint64(image.Len())
+	binary.Write(&image, le, fragmentEntries)
+
+	data := image.Bytes()
+	copy(data, squashfsMagic)
+	le.PutUint32(data[12:], 4096)
+	le.PutUint16(data[20:], compressionGzip)
+	le.PutUint16(data[28:], 4)
+	le.PutUint64(data[32:], 0)
+	le.PutUint64(data[64:], inodeTable)
+	le.PutUint64(data[72:], dirTable)
+	le.PutUint64(data[80:], fragTable)
+	return data
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	initramfs := buildCpio([]cpioEntry{
+		{name: "etc", mode: 0o040755},
+		{name: "etc/shadow", mode: 0o100600, content: shadowContent},
+		{name: "./config", mode: 0o100644, content: configContent},
+		{name: "bin/busybox", mode: 0o100755, content: "\x7fELF\x00\x00binary"},
+	})
+	microcode := buildCpio([]cpioEntry{
+		{name: "kernel/x86/microcode/GenuineIntel.bin", mode: 0o100644, content: "\x00\x01\x02\x03"},
+	})
+	squashfs := buildSquashfs(t)
+
+	tests := []struct {
+		name  string
+		image []byte
+	}{
+		{name: "cpio", image: initramfs},
+		{name: "gzip cpio", image: gzipBytes(initramfs)},
+		{name: "microcode cpio and gzip cpio", image: append(append(microcode, make([]byte, 512)...), gzipBytes(initramfs)...)},
+		{name: "squashfs", image: squashfs},
+		{name: "squashfs behind header", image: append([]byte("uImage header and kernel"), squashfs...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Extract(tt.image)
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+
+			got := map[string]string{}
+			var paths []string
+			for _, f := range files {
+				got[f.Path] = f.Content
+				paths = append(paths, f.Path)
+			}
+			sort.Strings(paths)
+
+			if len(files) != 2 {
+				t.Fatalf("Extract() returned %v, want config and etc/shadow", paths)
+			}
+			if got["etc/shadow"] != shadowContent {
+				t.Errorf("etc/shadow = %q, want %q", got["etc/shadow"], shadowContent)
+			}
+			if got["config"] != configContent {
+				t.Errorf("config = %q, want %q", got["config"], configContent)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	unsupported := buildSquashfs(t)
+	binary.LittleEndian.PutUint16(unsupported[20:], 4) // xz
+	wrapped := buildSquashfs(t)
+	binary.LittleEndian.PutUint64(wrapped[64:], math.MaxUint64-1) // inode table
+
+	tests := []struct {
+		name  string
+		image []byte
+	}{
+		{name: "unknown format", image: []byte("just some bytes")},
+		{name: "unsupported compression", image: unsupported},
+		{name: "truncated squashfs", image: buildSquashfs(t)[:200]},
+		{name: "inode table at the end of the range", image: wrapped},
+		{name: "too many compression layers", image: gzipBytes(gzipBytes(gzipBytes(buildCpio(nil))))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Extract(tt.image); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestExtractEmbeddedUnsupported(t *testing.T) {
+	image := buildSquashfs(t)
+	binary.LittleEndian.PutUint16(image[20:], 4) // xz
+	image = append([]byte("uImage header and kernel"), image...)
+
+	if _, err := Extract(image); !errors.Is(err, errUnsupportedCompress) {
+		t.Errorf("Extract() error = %v, want %v", err, errUnsupportedCompress)
+	}
+}
+
+func TestExtractDirectoryCycle(t *testing.T) {
+	image := buildSquashfs(t)
+	// Point the root's etc entry back at the root inode
+	dirTable := int(binary.LittleEndian.Uint64(image[72:]))
+	binary.LittleEndian.PutUint16(image[dirTable+2+12+8+len("config"):], 0)
+
+	files, err := Extract(image)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "config" {
+		t.Errorf("Extract() returned %d files, want config once", len(files))
+	}
+}
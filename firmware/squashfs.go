@@ -0,0 +1,364 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package firmware
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+)
+
+// squashfs inode types
+const (
+	inodeDir         = 1
+	inodeFile        = 2
+	inodeExtendedDir = 8
+	inodeExtFile     = 9
+)
+
+const (
+	superblockSize       = 96
+	compressionGzip      = 1
+	metadataUncompressed = 0x8000
+	blockUncompressed    = 1 << 24
+	noFragment           = 0xffffffff
+	fragmentEntrySize    = 16
+	maxDirDepth          = 64
+)
+
+var (
+	errSquashfsTruncated   = errors.New("truncated squashfs image")
+	errUnsupportedCompress = errors.New("unsupported squashfs compression (only gzip is supported)")
+)
+
+// squashfs is a read-only view of a squashfs 4.0 image
+type squashfs struct {
+	data       []byte
+	blockSize  uint32
+	inodeTable uint64
+	dirTable   uint64
+	fragTable  uint64
+	// visited holds the refs of the directory inodes already walked, so that
+	// directory entries forming a cycle are walked once
+	visited map[uint64]bool
+}
+
+type inode struct {
+	typ uint16
+
+	// Directories
+	dirStart  uint32
+	dirOffset uint16
+	dirSize   uint32
+
+	// Regular files
+	fileSize    uint64
+	blocksStart uint64
+	fragment    uint32
+	fragOffset  uint32
+	blockSizes  []uint32
+}
+
+func extractSquashfs(data []byte) ([]File, error) {
+	if len(data) < superblockSize {
+		return nil, errSquashfsTruncated
+	}
+	le := binary.LittleEndian
+	if major := le.Uint16(data[28:]); major != 4 {
+		return nil, fmt.Errorf("unsupported squashfs version %d", major)
+	}
+	if le.Uint16(data[20:]) != compressionGzip {
+		return nil, errUnsupportedCompress
+	}
+
+	fs := &squashfs{
+		data:       data,
+		blockSize:  le.Uint32(data[12:]),
+		inodeTable: le.Uint64(data[64:]),
+		dirTable:   le.Uint64(data[72:]),
+		fragTable:  le.Uint64(data[80:]),
+		visited:    make(map[uint64]bool),
+	}
+	if fs.blockSize == 0 || fs.blockSize > 1024*1024 {
+		return nil, fmt.Errorf("invalid squashfs block size %d", fs.blockSize)
+	}
+
+	rootRef := le.Uint64(data[32:])
+	root, err := fs.readInode(rootRef)
+	if err != nil {
+		return nil, err
+	}
+	fs.visited[rootRef] = true
+
+	var files []File
+	if err := fs.walk(root, "", 0, &files); err != nil {
+		return files, err
+	}
+	return files, nil
+}
+
+// walk collects the text files below the directory inode dir
+func (fs *squashfs) walk(dir *inode, prefix string, depth int, files *[]File) error {
+	if depth > maxDirDepth {
+		return errors.New("squashfs directory tree too deep")
+	}
+	if dir.dirSize <= 3 {
+		return nil
+	}
+
+	r, err := fs.newMetaReader(fs.dirTable+uint64(dir.dirStart), int(dir.dirOffset))
+	if err != nil {
+		return err
+	}
+
+	le := binary.LittleEndian
+	for remaining := int(dir.dirSize) - 3; remaining > 0; {
+		header, err := r.read(12)
+		if err != nil {
+			return err
+		}
+		remaining -= 12
+		count := int(le.Uint32(header)) + 1
+		start := le.Uint32(header[4:])
+
+		for i := 0; i < count; i++ {
+			entry, err := r.read(8)
+			if err != nil {
+				return err
+			}
+			name, err := r.read(int(le.Uint16(entry[6:])) + 1)
+			if err != nil {
+				return err
+			}
+			remaining -= 8 + len(name)
+
+			ref := uint64(start)<<16 | uint64(le.Uint16(entry))
+			child, err := fs.readInode(ref)
+			if err != nil {
+				return err
+			}
+
+			childPath := path.Join(prefix, string(name))
+			switch child.typ {
+			case inodeDir, inodeExtendedDir:
+				if fs.visited[ref] {
+					continue
+				}
+				fs.visited[ref] = true
+				if err := fs.walk(child, childPath, depth+1, files); err != nil {
+					return err
+				}
+			case inodeFile, inodeExtFile:
+				if child.fileSize > maxFileSize {
+					continue
+				}
+				content, err := fs.readFile(child)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", childPath, err)
+				}
+				if f, ok := newFile(childPath, content); ok {
+					*files = append(*files, f)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readInode decodes the directory and regular file inodes; other inode types
+// are returned with only their type set
+func (fs *squashfs) readInode(ref uint64) (*inode, error) {
+	r, err := fs.newMetaReader(fs.inodeTable+ref>>16, int(ref&0xffff))
+	if err != nil {
+		return nil, err
+	}
+	header, err := r.read(16)
+	if err != nil {
+		return nil, err
+	}
+
+	le := binary.LittleEndian
+	in := &inode{typ: le.Uint16(header)}
+	switch in.typ {
+	case inodeDir:
+		b, err := r.read(16)
+		if err != nil {
+			return nil, err
+		}
+		in.dirStart = le.Uint32(b)
+		in.dirSize = uint32(le.Uint16(b[8:]))
+		in.dirOffset = le.Uint16(b[10:])
+	case inodeExtendedDir:
+		b, err := r.read(24)
+		if err != nil {
+			return nil, err
+		}
+		in.dirSize = le.Uint32(b[4:])
+		in.dirStart = le.Uint32(b[8:])
+		in.dirOffset = le.Uint16(b[18:])
+	case inodeFile:
+		b, err := r.read(16)
+		if err != nil {
+			return nil, err
+		}
+		in.blocksStart = uint64(le.Uint32(b))
+		in.fragment = le.Uint32(b[4:])
+		in.fragOffset = le.Uint32(b[8:])
+		in.fileSize = uint64(le.Uint32(b[12:]))
+	case inodeExtFile:
+		b, err := r.read(40)
+		if err != nil {
+			return nil, err
+		}
+		in.blocksStart = le.Uint64(b)
+		in.fileSize = le.Uint64(b[8:])
+		in.fragment = le.Uint32(b[28:])
+		in.fragOffset = le.Uint32(b[32:])
+	default:
+		return in, nil
+	}
+
+	if in.typ == inodeFile || in.typ == inodeExtFile {
+		blocks := in.fileSize / uint64(fs.blockSize)
+		if in.fragment == noFragment && in.fileSize%uint64(fs.blockSize) != 0 {
+			blocks++
+		}
+		if blocks > maxFileSize/uint64(fs.blockSize)+1 {
+			// Too large to be scanned, keep the size so the file is skipped
+			return in, nil
+		}
+		b, err := r.read(4 * int(blocks))
+		if err != nil {
+			return nil, err
+		}
+		in.blockSizes = make([]uint32, blocks)
+		for i := range in.blockSizes {
+			in.blockSizes[i] = le.Uint32(b[4*i:])
+		}
+	}
+	return in, nil
+}
+
+// readFile returns the content of a regular file inode
+func (fs *squashfs) readFile(in *inode) ([]byte, error) {
+	var content bytes.Buffer
+	pos := in.blocksStart
+	for _, size := range in.blockSizes {
+		if size == 0 {
+			// Sparse block
+			content.Write(make([]byte, fs.blockSize))
+			continue
+		}
+		n := uint64(size &^ blockUncompressed)
+		block, err := fs.block(pos, n, size&blockUncompressed == 0)
+		if err != nil {
+			return nil, err
+		}
+		content.Write(block)
+		pos += n
+	}
+
+	if in.fragment != noFragment {
+		fragment, err := fs.readFragment(in.fragment)
+		if err != nil {
+			return nil, err
+		}
+		tail := in.fileSize % uint64(fs.blockSize)
+		if uint64(in.fragOffset)+tail > uint64(len(fragment)) {
+			return nil, errSquashfsTruncated
+		}
+		content.Write(fragment[in.fragOffset : uint64(in.fragOffset)+tail])
+	}
+
+	if uint64(content.Len()) > in.fileSize {
+		content.Truncate(int(in.fileSize))
+	}
+	return content.Bytes(), nil
+}
+
+// readFragment returns the decompressed fragment block with the given index
+func (fs *squashfs) readFragment(index uint32) ([]byte, error) {
+	const perBlock = 8192 / fragmentEntrySize
+	ptrPos := fs.fragTable + 8*uint64(index/perBlock)
+	if ptrPos > uint64(len(fs.data))-8 {
+		return nil, errSquashfsTruncated
+	}
+
+	r, err := fs.newMetaReader(binary.LittleEndian.Uint64(fs.data[ptrPos:]), int(index%perBlock)*fragmentEntrySize)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := r.read(fragmentEntrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	start := binary.LittleEndian.Uint64(entry)
+	size := binary.LittleEndian.Uint32(entry[8:])
+	return fs.block(start, uint64(size&^blockUncompressed), size&blockUncompressed == 0)
+}
+
+// block returns n bytes of data at pos, decompressing them if needed
+func (fs *squashfs) block(pos, n uint64, compressed bool) ([]byte, error) {
+	if pos+n > uint64(len(fs.data)) || pos+n < pos {
+		return nil, errSquashfsTruncated
+	}
+	raw := fs.data[pos : pos+n]
+	if !compressed {
+		return raw, nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress squashfs block: %w", err)
+	}
+	defer zr.Close()
+
+	// Metadata blocks hold up to 8KiB, data blocks up to the block size
+	limit := int64(fs.blockSize)
+	if limit < 8192 {
+		limit = 8192
+	}
+	return io.ReadAll(io.LimitReader(zr, limit))
+}
+
+// metaReader reads sequentially through consecutive metadata blocks
+type metaReader struct {
+	fs   *squashfs
+	next uint64
+	buf  []byte
+}
+
+func (fs *squashfs) newMetaReader(start uint64, offset int) (*metaReader, error) {
+	r := &metaReader{fs: fs, next: start}
+	if _, err := r.read(offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *metaReader) read(n int) ([]byte, error) {
+	for len(r.buf) < n {
+		// Written so that a next near the top of the range cannot wrap around
+		if len(r.fs.data) < 2 || r.next > uint64(len(r.fs.data))-2 {
+			return nil, errSquashfsTruncated
+		}
+		header := binary.LittleEndian.Uint16(r.fs.data[r.next:])
+		size := uint64(header &^ metadataUncompressed)
+		block, err := r.fs.block(r.next+2, size, header&metadataUncompressed == 0)
+		if err != nil {
+			return nil, err
+		}
+		r.buf = append(r.buf, block...)
+		r.next += 2 + size
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package grpcfilter provides gRPC server interceptors that scan the string
+// fields, UTF-8 bytes fields, and map keys of protobuf messages for secrets
+// and reject or redact them.
+//
+// Scan results are cached by the scanner; give it a small cache, or none with
+// scanner.WithCacheSize(0), as messages rarely repeat.
+package grpcfilter
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Action is what the interceptors do with a message that contains secrets
+type Action int
+
+const (
+	// Reject fails the call with codes.PermissionDenied
+	Reject Action = iota
+	// Redact replaces each detected secret with a placeholder
+	Redact
+)
+
+// Option configures the interceptors
+type Option func(*filter)
+
+// WithAction sets the action taken when secrets are detected (default Reject)
+func WithAction(a Action) Option {
+	return func(f *filter) {
+		f.action = a
+	}
+}
+
+// WithResponses enables scanning of response messages in addition to requests
+func WithResponses(enabled bool) Option {
+	return func(f *filter) {
+		f.responses = enabled
+	}
+}
+
+type filter struct {
+	scanner   *scanner.Scanner
+	action    Action
+	responses bool
+}
+
+func newFilter(s *scanner.Scanner, opts []Option) *filter {
+	f := &filter{scanner: s, action: Reject}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// UnaryServerInterceptor returns an interceptor that scans unary request (and
+// optionally response) messages with s
+func UnaryServerInterceptor(s *scanner.Scanner, opts ...Option) grpc.UnaryServerInterceptor {
+	f := newFilter(s, opts)
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := f.filter(ctx, req, "request"); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil || !f.responses {
+			return resp, err
+		}
+		if err := f.filter(ctx, resp, "response"); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that scans every message
+// received (and optionally sent) on a stream with s
+func StreamServerInterceptor(s *scanner.Scanner, opts ...Option) grpc.StreamServerInterceptor {
+	f := newFilter(s, opts)
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &filteredStream{ServerStream: ss, filter: f})
+	}
+}
+
+type filteredStream struct {
+	grpc.ServerStream
+	filter *filter
+}
+
+func (s *filteredStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.filter.filter(s.Context(), m, "request")
+}
+
+func (s *filteredStream) SendMsg(m interface{}) error {
+	if s.filter.responses {
+		if err := s.filter.filter(s.Context(), m, "response"); err != nil {
+			return err
+		}
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// filter scans a message and applies the action; messages that are not
+// protobuf messages are passed through
+func (f *filter) filter(ctx context.Context, m interface{}, direction string) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	w := &walker{ctx: ctx, filter: f}
+	w.message(msg.ProtoReflect(), "")
+	if w.err != nil {
+		return status.Errorf(codes.Internal, "failed to scan %s: %v", direction, w.err)
+	}
+	if w.found && f.action == Reject {
+		return status.Errorf(codes.PermissionDenied, "%s contains secrets", direction)
+	}
+	return nil
+}
+
+// walker scans the string fields, UTF-8 bytes fields, and map keys of a
+// message, including those in nested messages, lists, and maps, redacting them
+// in place if configured. It stops at the first finding when rejecting.
+type walker struct {
+	ctx    context.Context
+	filter *filter
+	found  bool
+	err    error
+}
+
+// transparent lists the well-known messages whose fields are scanned under the
+// name of the field or map key that holds them, such as the values of a Struct
+var transparent = map[protoreflect.FullName]bool{
+	"google.protobuf.Value":       true,
+	"google.protobuf.ListValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+// done reports whether the walk must stop
+func (w *walker) done() bool {
+	return w.err != nil || (w.found && w.filter.action == Reject)
+}
+
+// message scans the fields of m, each under its own name unless m is
+// transparent, in which case under name
+func (w *walker) message(m protoreflect.Message, name string) {
+	type update struct {
+		fd    protoreflect.FieldDescriptor
+		value protoreflect.Value
+	}
+	var updates []update
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := name
+		if !transparent[m.Descriptor().FullName()] {
+			name = string(fd.Name())
+		}
+		switch {
+		case fd.IsMap():
+			w.mapEntries(fd, v.Map())
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len() && !w.done(); i++ {
+				w.value(fd, name, list.Get(i), func(v protoreflect.Value) { list.Set(i, v) })
+			}
+		default:
+			w.value(fd, name, v, func(v protoreflect.Value) { updates = append(updates, update{fd, v}) })
+		}
+		return !w.done()
+	})
+
+	// Fields are set after Range, which must not observe its own mutations
+	for _, u := range updates {
+		m.Set(u.fd, u.value)
+	}
+}
+
+// mapEntries scans the keys and values of a map. A redacted key replaces the
+// original one.
+func (w *walker) mapEntries(fd protoreflect.FieldDescriptor, mp protoreflect.Map) {
+	type update struct {
+		key, newKey protoreflect.MapKey
+		value       protoreflect.Value
+	}
+	var updates []update
+
+	mp.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		u := update{key: k, newKey: k, value: v}
+		changed := false
+		w.value(fd.MapKey(), string(fd.Name()), k.Value(), func(key protoreflect.Value) { u.newKey, changed = key.MapKey(), true })
+		if !w.done() {
+			w.value(fd.MapValue(), k.String(), v, func(value protoreflect.Value) { u.value, changed = value, true })
+		}
+		if changed {
+			updates = append(updates, u)
+		}
+		return !w.done()
+	})
+	for _, u := range updates {
+		mp.Clear(u.key)
+		mp.Set(u.newKey, u.value)
+	}
+}
+
+// value scans a string value, or a bytes value that is valid UTF-8, calling
+// set with its redacted form, or recurses into a message value. name is the
+// field name or map key the value is scanned under.
+func (w *walker) value(fd protoreflect.FieldDescriptor, name string, v protoreflect.Value, set func(protoreflect.Value)) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if redacted, ok := w.scan(name, v.String()); ok {
+			set(protoreflect.ValueOfString(redacted))
+		}
+	case protoreflect.BytesKind:
+		// Bytes fields often carry text such as JSON payloads; binary data
+		// is left alone
+		if b := v.Bytes(); utf8.Valid(b) {
+			if redacted, ok := w.scan(name, string(b)); ok {
+				set(protoreflect.ValueOfBytes([]byte(redacted)))
+			}
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		w.message(v.Message(), name)
+	}
+}
+
+// scan scans text as "name: text", so that patterns keyed on names such as
+// "password" still match, returning its redacted form and true if it
+// contains secrets that are to be redacted
+func (w *walker) scan(name, text string) (string, bool) {
+	scanned, prefix := text, 0
+	if name != "" {
+		scanned, prefix = name+": "+text, len(name)+2
+	}
+	found, err := w.filter.scanner.Scan(w.ctx, scanned)
+	if err != nil {
+		w.err = err
+		return "", false
+	}
+	var results []scanner.Result
+	for _, result := range found {
+		if result.EndIndex <= prefix {
+			// The match lies in the name
+			continue
+		}
+		result.StartIndex = max(result.StartIndex-prefix, 0)
+		result.EndIndex -= prefix
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return "", false
+	}
+	w.found = true
+	if w.filter.action != Redact {
+		return "", false
+	}
+	return w.filter.scanner.RedactResults(text, results), true
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package helm scans Helm chart values files and templates, flagging values
+// hard-coded under keys such as adminPassword or apiToken, so that chart
+// releases can be gated on secret hygiene
+package helm
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/dotenv"
+	"github.com/stackloklabs/secret-scanning-api/k8s"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/structscan"
+)
+
+// Rule is the result type of values flagged by their key
+const Rule = "helm_secret_value"
+
+// confidence is the confidence of values flagged by their key
+const confidence = 0.7
+
+// sensitiveKey matches keys whose values are secrets, such as password,
+// adminPassword, apiToken, or secretKey
+var sensitiveKey = regexp.MustCompile(`(?i)(?:password|passwd|secret|token|key)$`)
+
+// referenceKey matches sensitive-looking keys that name where a secret is
+// kept rather than holding it, such as existingSecret, passwordKey, or
+// secretKeyRef, and keys such as key in label selectors
+var referenceKey = regexp.MustCompile(`(?i)^existing|(?:name|ref|path|file)$|(?:password|token|user(?:name)?)key$|^keys?$`)
+
+// IsValues reports whether path names a chart values file, such as
+// values.yaml, values-prod.yaml, or prod-values.yml
+func IsValues(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	if ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+	name := strings.TrimSuffix(base, ext)
+	return name == "values" || strings.HasPrefix(name, "values-") || strings.HasPrefix(name, "values.") ||
+		strings.HasSuffix(name, "-values") || strings.HasSuffix(name, ".values")
+}
+
+// IsTemplate reports whether path names a YAML manifest in a chart's
+// templates directory
+func IsTemplate(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "templates" {
+			return true
+		}
+	}
+	return false
+}
+
+// Sensitive reports whether key holds a secret by its name
+func Sensitive(key string) bool {
+	return sensitiveKey.MatchString(key) && !referenceKey.MatchString(key)
+}
+
+// ScanValues scans a values file with s like structscan.Scan, and adds a
+// result for each value under a sensitive key that no pattern matched
+func ScanValues(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	values, err := structscan.Values(content)
+	if err != nil {
+		return nil, err
+	}
+	results, err := structscan.Scan(ctx, s, content)
+	if err != nil {
+		return nil, err
+	}
+	return flag(s, results, values), nil
+}
+
+// ScanTemplate scans a chart template with s. Rendered templates are scanned
+// like values files, with Kubernetes Secret data decoded as k8s.Scan does.
+// Templates with actions, which are not valid YAML, are scanned as text, with
+// the YAML path of each value followed by indentation; values containing
+// actions are skipped.
+func ScanTemplate(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	if values, err := structscan.Values(content); err == nil {
+		results, err := k8s.Scan(ctx, s, content)
+		if err != nil {
+			return nil, err
+		}
+		return flag(s, results, values), nil
+	}
+
+	results, err := s.Scan(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	return flag(s, results, templateValues(content)), nil
+}
+
+// flag sets the path of results without one to the value they were found in
+// and adds a result for each sensitive value without a match
+func flag(s *scanner.Scanner, results []scanner.Result, values []structscan.Value) []scanner.Result {
+	for _, v := range values {
+		matched := false
+		for i := range results {
+			r := &results[i]
+			if r.StartIndex < v.Start || r.StartIndex >= max(v.End, v.Start+1) {
+				continue
+			}
+			if r.Path == "" {
+				r.Path = v.Path
+			}
+			matched = matched || r.Severity.AtLeast(scanner.SeverityMedium)
+		}
+		if matched || len(v.Value) < 4 || !Sensitive(v.Key) || dotenv.IsReference(v.Value) || scanner.IsPlaceholder(v.Value) || strings.Contains(v.Value, "{{") {
+			continue
+		}
+		result := s.NewResult(Rule, v.Value, v.Start, v.Line, confidence)
+		result.Path = v.Path
+		results = append(results, result)
+	}
+	return results
+}
+
+// line matches a mapping entry of a block YAML line, possibly a sequence item
+var line = regexp.MustCompile(`^( *)((?:- +)?)"?([A-Za-z0-9_.\-/]+)"?:(?:[ \t]+|$)`)
+
+// templateValues returns the single-line values of a template, with key
+// paths followed by indentation. Sequence indexes are not tracked, so paths
+// below sequences name only the keys, as in spec.containers.env.value.
+func templateValues(content string) []structscan.Value {
+	type parent struct {
+		indent int
+		key    string
+	}
+	var values []structscan.Value
+	var parents []parent
+	n := 0
+	for pos := 0; pos < len(content); {
+		n++
+		end := strings.IndexByte(content[pos:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += pos
+		}
+		text := content[pos:end]
+		start := pos
+		pos = end + 1
+
+		m := line.FindStringSubmatchIndex(text)
+		if m == nil {
+			continue
+		}
+		// Keys of a sequence item are indented past the dash
+		indent := m[5]
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		key := text[m[6]:m[7]]
+		path := key
+		if len(parents) > 0 {
+			path = parents[len(parents)-1].key + "." + key
+		}
+
+		value := strings.TrimRight(text[m[1]:], " \t\r")
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimRight(value[:i], " \t")
+		}
+		valueStart := start + m[1]
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+			valueStart++
+		}
+		switch {
+		case value == "" || value == "|" || value == ">" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			parents = append(parents, parent{indent: indent, key: path})
+		default:
+			values = append(values, structscan.Value{Path: path, Key: key, Value: value, Start: valueStart, End: valueStart + len(value), Line: n})
+		}
+	}
+	return values
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestIsValues(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"charts/app/values.yaml", true},
+		{"values-prod.yml", true},
+		{"staging-values.yaml", true},
+		{"charts/app/Chart.yaml", false},
+		{"values.json", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValues(tt.path); got != tt.want {
+			t.Errorf("IsValues(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"adminPassword", true},
+		{"apiToken", true},
+		{"secretKey", true},
+		{"license_key", true},
+		{"existingSecret", false},
+		{"passwordKey", false},
+		{"secretName", false},
+		{"key", false},
+		{"image", false},
+	}
+
+	for _, tt := range tests {
+		if got := Sensitive(tt.key); got != tt.want {
+			t.Errorf("Sensitive(%s) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestScanValues(t *testing.T) {
+	content := "postgresql:\n" +
+		"  auth:\n" +
+		"    existingSecret: db-credentials\n" +
+		"    postgresPassword: \"s3cr3t-Pa55\"\n" +
+		"ingress:\n" +
+		"  hosts:\n" +
+		"    - host: app.example.com\n"
+
+	results, err := ScanValues(context.Background(), scanner.New(), content)
+	if err != nil {
+		t.Fatalf("ScanValues failed: %v", err)
+	}
+	var flagged []scanner.Result
+	for _, r := range results {
+		if r.Type == Rule {
+			flagged = append(flagged, r)
+		}
+	}
+	if len(flagged) != 1 {
+		t.Fatalf("ScanValues flagged %d values, want 1: %+v", len(flagged), flagged)
+	}
+	if got := flagged[0]; got.Path != "postgresql.auth.postgresPassword" || got.LineNumber != 4 || content[got.StartIndex:got.EndIndex] != "s3cr3t-Pa55" {
+		t.Errorf("ScanValues() = %s line %d %q, want postgresql.auth.postgresPassword line 4 \"s3cr3t-Pa55\"",
+			got.Path, got.LineNumber, content[got.StartIndex:got.EndIndex])
+	}
+}
+
+func TestScanTemplate(t *testing.T) {
+	content := "{{- if .Values.enabled }}\n" +
+		"apiVersion: apps/v1\n" +
+		"kind: Deployment\n" +
+		"metadata:\n" +
+		"  name: {{ include \"app.fullname\" . }}\n" +
+		"spec:\n" +
+		"  template:\n" +
+		"    spec:\n" +
+		"      containers:\n" +
+		"        - name: app\n" +
+		"          env:\n" +
+		"            - name: MODE\n" +
+		"              value: {{ .Values.mode | quote }}\n" +
+		"          args:\n" +
+		"            apiToken: 'tok-93b1f0c2d7'\n" +
+		"            adminPassword: {{ .Values.adminPassword }}\n"
+
+	results, err := ScanTemplate(context.Background(), scanner.New(), content)
+	if err != nil {
+		t.Fatalf("ScanTemplate failed: %v", err)
+	}
+	var flagged []scanner.Result
+	for _, r := range results {
+		if r.Type == Rule {
+			flagged = append(flagged, r)
+		}
+	}
+	if len(flagged) != 1 {
+		t.Fatalf("ScanTemplate flagged %d values, want 1: %+v", len(flagged), flagged)
+	}
+	if got := flagged[0]; got.Path != "spec.template.spec.containers.args.apiToken" || got.LineNumber != 15 || content[got.StartIndex:got.EndIndex] != "tok-93b1f0c2d7" {
+		t.Errorf("ScanTemplate() = %s line %d %q, want spec.template.spec.containers.args.apiToken line 15 \"tok-93b1f0c2d7\"",
+			got.Path, got.LineNumber, content[got.StartIndex:got.EndIndex])
+	}
+}
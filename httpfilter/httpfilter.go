@@ -0,0 +1,312 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package httpfilter provides net/http middleware that scans request and
+// response bodies for secrets and blocks, redacts, or annotates them.
+//
+// Scan results are cached by the scanner; give it a small cache, or none with
+// scanner.WithCacheSize(0), as bodies rarely repeat.
+package httpfilter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Action is what the middleware does with a body that contains secrets
+type Action int
+
+const (
+	// Block rejects requests with 403 Forbidden and withholds responses
+	Block Action = iota
+	// Redact replaces each detected secret with a placeholder
+	Redact
+	// Annotate passes the body through unchanged and lists the detected
+	// secret types in the FindingsHeader header
+	Annotate
+)
+
+// FindingsHeader lists the secret types detected in an annotated body
+const FindingsHeader = "X-Secret-Scan-Findings"
+
+// defaultMaxBodySize is the largest body that is scanned
+const defaultMaxBodySize = 10 * 1024 * 1024
+
+// Option configures the middleware
+type Option func(*filter)
+
+// WithAction sets the action taken when secrets are detected (default Block)
+func WithAction(a Action) Option {
+	return func(f *filter) {
+		f.action = a
+	}
+}
+
+// WithResponses enables scanning of response bodies. Responses are buffered
+// before they are written to the client, up to the maximum body size; larger
+// responses, and those compressed other than with gzip, are passed through
+// unscanned.
+func WithResponses(enabled bool) Option {
+	return func(f *filter) {
+		f.responses = enabled
+	}
+}
+
+// WithMaxBodySize sets the largest body that is scanned, before and after
+// decompression. Larger requests are rejected with 413 Request Entity Too Large
+// rather than passed through unscanned.
+func WithMaxBodySize(n int64) Option {
+	return func(f *filter) {
+		if n > 0 {
+			f.maxBodySize = n
+		}
+	}
+}
+
+type filter struct {
+	scanner     *scanner.Scanner
+	action      Action
+	responses   bool
+	maxBodySize int64
+}
+
+// New returns middleware that scans bodies passing through it with s
+func New(s *scanner.Scanner, opts ...Option) func(http.Handler) http.Handler {
+	f := &filter{
+		scanner:     s,
+		action:      Block,
+		maxBodySize: defaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.filterRequest(w, r) {
+				return
+			}
+			if !f.responses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{w: w, limit: f.maxBodySize, header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if !rec.passthrough {
+				f.writeResponse(w, r, rec)
+			}
+		})
+	}
+}
+
+// filterRequest scans the request body and applies the action, returning false
+// if the request has been answered and must not reach the next handler
+func (f *filter) filterRequest(w http.ResponseWriter, r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, f.maxBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return false
+	}
+	if int64(len(body)) > f.maxBodySize {
+		http.Error(w, "request body too large to scan", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	// Clients choose the encoding, so one that cannot be scanned is
+	// rejected rather than let through
+	text, err := decodeBody(r.Header.Get("Content-Encoding"), body, f.maxBodySize)
+	switch {
+	case errors.Is(err, errUnsupportedEncoding):
+		http.Error(w, "request body encoding cannot be scanned", http.StatusUnsupportedMediaType)
+		return false
+	case errors.Is(err, errTooLarge):
+		http.Error(w, "request body too large to scan", http.StatusRequestEntityTooLarge)
+		return false
+	case err != nil:
+		http.Error(w, "failed to decode request body", http.StatusBadRequest)
+		return false
+	}
+
+	results, err := f.scanner.Scan(r.Context(), string(text))
+	if err != nil {
+		http.Error(w, "failed to scan request body", http.StatusInternalServerError)
+		return false
+	}
+
+	if len(results) > 0 {
+		switch f.action {
+		case Block:
+			http.Error(w, "request body contains secrets", http.StatusForbidden)
+			return false
+		case Redact:
+			// The redacted body is passed on decompressed
+			body = []byte(f.scanner.RedactResults(string(text), results))
+			r.Header.Del("Content-Encoding")
+		case Annotate:
+			r.Header.Set(FindingsHeader, findingTypes(results))
+		}
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	if r.Header.Get("Content-Length") != "" {
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return true
+}
+
+// writeResponse scans a buffered response and writes it to the client
+func (f *filter) writeResponse(w http.ResponseWriter, r *http.Request, rec *recorder) {
+	text, err := decodeBody(rec.header.Get("Content-Encoding"), rec.body.Bytes(), f.maxBodySize)
+	if errors.Is(err, errUnsupportedEncoding) || errors.Is(err, errTooLarge) {
+		rec.flush()
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to decode response body", http.StatusBadGateway)
+		return
+	}
+
+	results, err := f.scanner.Scan(r.Context(), string(text))
+	if err != nil {
+		http.Error(w, "failed to scan response body", http.StatusInternalServerError)
+		return
+	}
+
+	if len(results) > 0 {
+		switch f.action {
+		case Block:
+			http.Error(w, "response body contains secrets", http.StatusForbidden)
+			return
+		case Redact:
+			// The redacted body is sent decompressed
+			redacted := f.scanner.RedactResults(string(text), results)
+			rec.body.Reset()
+			rec.body.WriteString(redacted)
+			rec.header.Del("Content-Encoding")
+			if rec.header.Get("Content-Length") != "" {
+				rec.header.Set("Content-Length", strconv.Itoa(len(redacted)))
+			}
+		case Annotate:
+			rec.header.Set(FindingsHeader, findingTypes(results))
+		}
+	}
+	rec.flush()
+}
+
+var (
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+	errTooLarge            = errors.New("decoded body too large")
+)
+
+// decodeBody returns the text of a body sent with the given Content-Encoding,
+// decompressing gzip bodies up to limit bytes
+func decodeBody(encoding string, body []byte, limit int64) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+	default:
+		return nil, errUnsupportedEncoding
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	text, err := io.ReadAll(io.LimitReader(zr, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(text)) > limit {
+		return nil, errTooLarge
+	}
+	return text, nil
+}
+
+// findingTypes returns the sorted, distinct types of results as a header value
+func findingTypes(results []scanner.Result) string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, result := range results {
+		if !seen[result.Type] {
+			seen[result.Type] = true
+			types = append(types, result.Type)
+		}
+	}
+	sort.Strings(types)
+	return strings.Join(types, ",")
+}
+
+// recorder buffers a response so that it can be scanned before it is written.
+// Once the body outgrows limit, what was buffered and the rest of the body
+// are passed through to w unscanned, so that large or streamed responses are
+// not held in memory.
+type recorder struct {
+	w           http.ResponseWriter
+	limit       int64
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	passthrough bool
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if !r.passthrough && int64(r.body.Len()+len(b)) > r.limit {
+		if err := r.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if r.passthrough {
+		return r.w.Write(b)
+	}
+	return r.body.Write(b)
+}
+
+// Flush sends a passed-through response on to the client; a buffered one is
+// sent once it has been scanned
+func (r *recorder) Flush() {
+	if f, ok := r.w.(http.Flusher); ok && r.passthrough {
+		f.Flush()
+	}
+}
+
+// flush writes the header and buffered body to the client and passes the rest
+// of the response through
+func (r *recorder) flush() error {
+	r.passthrough = true
+	for k, v := range r.header {
+		r.w.Header()[k] = v
+	}
+	r.w.WriteHeader(r.status)
+	_, err := r.w.Write(r.body.Bytes())
+	r.body.Reset()
+	return err
+}
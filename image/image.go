@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package image reads container images from a registry or a local tarball and
+// extracts the text files of each layer, along with the Dockerfile instruction
+// that created the layer, so that secrets can be traced to the build step that
+// baked them in
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/archive"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// maxFileSize bounds how much of a single layer file is read
+const maxFileSize = 10 * 1024 * 1024
+
+// ErrUnsupportedLayer is returned for layers in a compression format that
+// cannot be read, such as zstd
+var ErrUnsupportedLayer = errors.New("unsupported layer compression")
+
+// Image is a container image
+type Image struct {
+	// Name is the reference or tarball path the image was read from
+	Name   string
+	Config Config
+	Layers []Layer
+	close  func() error
+}
+
+// Close releases the resources of an image, such as an open tarball
+func (img *Image) Close() error {
+	if img.close == nil {
+		return nil
+	}
+	return img.close()
+}
+
+// Config is the part of an image configuration that describes how the image
+// was built
+type Config struct {
+	Config struct {
+		Env []string `json:"Env"`
+	} `json:"config"`
+	History []History `json:"history"`
+	RootFS  struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// History is a build step of an image
+type History struct {
+	CreatedBy  string `json:"created_by"`
+	EmptyLayer bool   `json:"empty_layer"`
+}
+
+// Layer is a filesystem layer of an image
+type Layer struct {
+	Digest string
+	// Instruction is the Dockerfile instruction that created the layer, as in
+	// COPY . /app, or empty when the image history does not say
+	Instruction string
+	open        func() (io.ReadCloser, error)
+}
+
+// File is a text file of a layer. Files in archives in the layer have paths
+// such as /app/lib.jar!config.properties.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Files returns the text files of the layer. Whiteout files, which mark
+// deletions from lower layers, and binary files are skipped.
+func (l Layer) Files() ([]File, error) {
+	rc, err := l.open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open layer %s: %w", l.Digest, err)
+	}
+	defer rc.Close()
+
+	r, err := decompress(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open layer %s: %w", l.Digest, err)
+	}
+	var files []File
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return files, fmt.Errorf("failed to read layer %s: %w", l.Digest, err)
+		}
+		name := "/" + strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if hdr.Typeflag != tar.TypeReg || strings.HasPrefix(path.Base(name), ".wh.") {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return files, fmt.Errorf("failed to read %s in layer %s: %w", name, l.Digest, err)
+		}
+		if archive.IsArchive(data) {
+			// Archives that fail to open are skipped like other binary files
+			inner, _ := archive.Extract(name, data, archive.DefaultLimits)
+			for _, f := range inner {
+				files = append(files, File{Path: f.Path, Content: f.Content})
+			}
+			continue
+		}
+		if scanner.IsBinary(data) {
+			continue
+		}
+		files = append(files, File{Path: name, Content: string(data)})
+	}
+}
+
+// decompress returns a reader of the uncompressed tar stream of a layer
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte("\x1f\x8b")):
+		return gzip.NewReader(br)
+	case bytes.Equal(magic, []byte("\x28\xb5\x2f\xfd")):
+		return nil, fmt.Errorf("%w: zstd", ErrUnsupportedLayer)
+	}
+	return br, nil
+}
+
+// Instruction returns the Dockerfile instruction of a history entry's
+// created_by, as in RUN npm ci for "/bin/sh -c npm ci" or COPY . /app for
+// "/bin/sh -c #(nop) COPY dir:abc in /app"
+func Instruction(createdBy string) string {
+	s := strings.TrimSpace(strings.TrimSuffix(createdBy, "# buildkit"))
+	if _, rest, ok := buildArgs(s); ok {
+		s = rest
+	}
+	switch {
+	case strings.HasPrefix(s, "/bin/sh -c #(nop) "):
+		return strings.TrimSpace(strings.TrimPrefix(s, "/bin/sh -c #(nop) "))
+	case strings.HasPrefix(s, "/bin/sh -c "):
+		return "RUN " + strings.TrimPrefix(s, "/bin/sh -c ")
+	}
+	return s
+}
+
+// buildArgs splits the build args that the classic builder and BuildKit
+// record before a RUN command, as in "|2 A=1 B=2 /bin/sh -c make" or
+// "RUN |1 A=1 /bin/sh -c make", from the rest of the command
+func buildArgs(s string) ([]string, string, bool) {
+	rest := strings.TrimPrefix(s, "RUN ")
+	if !strings.HasPrefix(rest, "|") {
+		return nil, s, false
+	}
+	fields := strings.SplitN(rest[1:], " ", 2)
+	var n int
+	if _, err := fmt.Sscanf(fields[0], "%d", &n); err != nil || len(fields) < 2 {
+		return nil, s, false
+	}
+	parts := strings.SplitN(fields[1], " ", n+1)
+	if len(parts) < n+1 {
+		return nil, s, false
+	}
+	return parts[:n], parts[n], true
+}
+
+// Dockerfile reconstructs the instructions of the image history as a
+// Dockerfile, with the build args recorded for each RUN step as ARG
+// instructions, so that it can be scanned for ENV and ARG secrets. Images
+// without history yield their environment as ENV instructions.
+func (c Config) Dockerfile() string {
+	var b strings.Builder
+	for _, h := range c.History {
+		if args, _, ok := buildArgs(strings.TrimSpace(h.CreatedBy)); ok {
+			for _, arg := range args {
+				b.WriteString("ARG " + arg + "\n")
+			}
+		}
+		if instruction := Instruction(h.CreatedBy); instruction != "" {
+			b.WriteString(instruction + "\n")
+		}
+	}
+	if len(c.History) == 0 {
+		for _, env := range c.Config.Env {
+			b.WriteString("ENV " + env + "\n")
+		}
+	}
+	return b.String()
+}
+
+// instructions assigns the instructions of the history entries that created
+// layers to n layers, in order
+func (c Config) instructions(n int) []string {
+	instructions := make([]string, n)
+	i := 0
+	for _, h := range c.History {
+		if h.EmptyLayer {
+			continue
+		}
+		if i >= n {
+			break
+		}
+		instructions[i] = Instruction(h.CreatedBy)
+		i++
+	}
+	return instructions
+}
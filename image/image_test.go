@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// layerOf returns a gzip-compressed layer tarball of files, in order
+func layerOf(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for _, f := range files {
+		if err := w.WriteHeader(&tar.Header{Name: f[0], Mode: 0o644, Size: int64(len(f[1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := w.Write([]byte(f[1])); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInstruction(t *testing.T) {
+	tests := []struct {
+		createdBy string
+		want      string
+	}{
+		{"/bin/sh -c #(nop) COPY dir:1a2b in /app ", "COPY dir:1a2b in /app"},
+		{"/bin/sh -c npm ci", "RUN npm ci"},
+		{"|1 NPM_TOKEN=abc /bin/sh -c npm ci", "RUN npm ci"},
+		{"RUN |1 NPM_TOKEN=abc /bin/sh -c npm ci # buildkit", "RUN npm ci"},
+		{"COPY . /app # buildkit", "COPY . /app"},
+		{"ENV PATH=/usr/local/bin", "ENV PATH=/usr/local/bin"},
+	}
+
+	for _, tt := range tests {
+		if got := Instruction(tt.createdBy); got != tt.want {
+			t.Errorf("Instruction(%q) = %q, want %q", tt.createdBy, got, tt.want)
+		}
+	}
+}
+
+func TestDockerfile(t *testing.T) {
+	var c Config
+	c.History = []History{
+		{CreatedBy: "/bin/sh -c #(nop) ENV API_TOKEN=abc123", EmptyLayer: true},
+		{CreatedBy: "RUN |1 NPM_TOKEN=npm-secret /bin/sh -c npm ci # buildkit"},
+	}
+	want := "ENV API_TOKEN=abc123\nARG NPM_TOKEN=npm-secret\nRUN npm ci\n"
+	if got := c.Dockerfile(); got != want {
+		t.Errorf("Dockerfile() = %q, want %q", got, want)
+	}
+}
+
+func TestLayerFiles(t *testing.T) {
+	data := layerOf(t,
+		[2]string{"app/.env", "TOKEN=x\n"},
+		[2]string{"app/.wh.secrets", ""},
+		[2]string{"usr/bin/tool", "\x7fELF\x00"},
+		[2]string{"./etc/config.yaml", "key: value\n"},
+	)
+	l := Layer{Digest: "sha256:abc", open: func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}}
+
+	files, err := l.Files()
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	want := []File{
+		{Path: "/app/.env", Content: "TOKEN=x\n"},
+		{Path: "/etc/config.yaml", Content: "key: value\n"},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Files() = %+v, want %+v", files, want)
+	}
+}
@@ -0,0 +1,279 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// dockerHub is the registry of references without a registry host
+const dockerHub = "registry-1.docker.io"
+
+// manifestTypes are the manifest and index media types accepted when pulling
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference is a parsed image reference
+type Reference struct {
+	// Registry is the registry host, as in ghcr.io or localhost:5000
+	Registry string
+	// Repository is the repository path, as in library/alpine
+	Repository string
+	// Reference is a tag or a digest
+	Reference string
+}
+
+// String returns the reference as registry/repository:tag or
+// registry/repository@digest
+func (r Reference) String() string {
+	if strings.Contains(r.Reference, ":") {
+		return r.Registry + "/" + r.Repository + "@" + r.Reference
+	}
+	return r.Registry + "/" + r.Repository + ":" + r.Reference
+}
+
+// ParseReference parses an image reference such as alpine, ghcr.io/org/app:v1,
+// or localhost:5000/app@sha256:... Docker Hub is the default registry, with
+// single-name repositories under library/, and latest the default tag.
+func ParseReference(ref string) (Reference, error) {
+	r := Reference{Registry: dockerHub}
+	name := ref
+	if i := strings.LastIndexByte(name, '@'); i >= 0 {
+		name, r.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndexByte(name, ':'); i > strings.LastIndexByte(name, '/') {
+		name, r.Reference = name[:i], name[i+1:]
+	}
+	if r.Reference == "" {
+		r.Reference = "latest"
+	}
+	if host, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		r.Registry, name = host, rest
+		if host == "docker.io" || host == "index.docker.io" {
+			r.Registry = dockerHub
+		}
+	}
+	if name == "" || strings.ContainsAny(name, " @:") {
+		return Reference{}, fmt.Errorf("invalid image reference %q", ref)
+	}
+	if r.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	r.Repository = name
+	return r, nil
+}
+
+// descriptor refers to a blob or manifest by digest
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+// manifest is an image manifest, or an index of manifests by platform
+type manifest struct {
+	Manifests []descriptor `json:"manifests"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+}
+
+// platform returns the manifest of an index for platform, as in linux/arm64,
+// or linux and the architecture of the running binary if empty, falling back
+// to the first manifest
+func (m manifest) platform(platform string) descriptor {
+	if platform == "" {
+		platform = "linux/" + runtime.GOARCH
+	}
+	for _, d := range m.Manifests {
+		if d.Platform.OS+"/"+d.Platform.Architecture == platform {
+			return d
+		}
+	}
+	return m.Manifests[0]
+}
+
+// PullOption configures Pull
+type PullOption func(*puller)
+
+// WithClient sets the HTTP client used to talk to the registry
+func WithClient(client *http.Client) PullOption {
+	return func(p *puller) {
+		p.client = client
+	}
+}
+
+// WithPlatform selects the image of a multi-platform index, as in linux/arm64
+func WithPlatform(platform string) PullOption {
+	return func(p *puller) {
+		p.platform = platform
+	}
+}
+
+type puller struct {
+	client   *http.Client
+	platform string
+	ref      Reference
+	token    string
+}
+
+// Pull fetches the manifest and configuration of an image from its registry,
+// authenticating anonymously with a bearer token when the registry asks for
+// one. Layers are downloaded when their files are read.
+func Pull(ctx context.Context, ref string, opts ...PullOption) (*Image, error) {
+	r, err := ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	p := &puller{client: http.DefaultClient, ref: r}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var m manifest
+	if err := p.getJSON(ctx, "/manifests/"+r.Reference, manifestTypes, &m); err != nil {
+		return nil, err
+	}
+	for len(m.Manifests) > 0 {
+		digest := m.platform(p.platform).Digest
+		m = manifest{}
+		if err := p.getJSON(ctx, "/manifests/"+digest, manifestTypes, &m); err != nil {
+			return nil, err
+		}
+	}
+
+	img := &Image{Name: r.String()}
+	if err := p.getJSON(ctx, "/blobs/"+m.Config.Digest, nil, &img.Config); err != nil {
+		return nil, err
+	}
+	instructions := img.Config.instructions(len(m.Layers))
+	for i, layer := range m.Layers {
+		digest := layer.Digest
+		img.Layers = append(img.Layers, Layer{
+			Digest:      digest,
+			Instruction: instructions[i],
+			open: func() (io.ReadCloser, error) {
+				resp, err := p.get(ctx, "/blobs/"+digest, nil)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
+	}
+	return img, nil
+}
+
+func (p *puller) getJSON(ctx context.Context, path string, accept []string, v any) error {
+	resp, err := p.get(ctx, path, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// get fetches a path under the repository, fetching a token and retrying once
+// if the registry asks for authentication
+func (p *puller) get(ctx context.Context, path string, accept []string) (*http.Response, error) {
+	u := "https://" + p.ref.Registry + "/v2/" + p.ref.Repository + path
+	for retried := false; ; retried = true {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", u, err)
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		if p.token != "" {
+			req.Header.Set("Authorization", "Bearer "+p.token)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", u, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && !retried {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := p.authenticate(ctx, challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to fetch %s: %s", u, resp.Status)
+		}
+		return resp, nil
+	}
+}
+
+// challengeParam matches a parameter of a WWW-Authenticate challenge
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate fetches an anonymous pull token from the realm of a Bearer
+// challenge
+func (p *puller) authenticate(ctx context.Context, challenge string) error {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("failed to authenticate to %s: unsupported challenge %q", p.ref.Registry, challenge)
+	}
+	params := make(map[string]string)
+	for _, m := range challengeParam.FindAllStringSubmatch(rest, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("failed to authenticate to %s: no realm in challenge", p.ref.Registry)
+	}
+	if params["scope"] == "" {
+		params["scope"] = "repository:" + p.ref.Repository + ":pull"
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to %s: %w", p.ref.Registry, err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to %s: %w", p.ref.Registry, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate to %s: %s", p.ref.Registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to authenticate to %s: %w", p.ref.Registry, err)
+	}
+	p.token = token.Token
+	if p.token == "" {
+		p.token = token.AccessToken
+	}
+	return nil
+}
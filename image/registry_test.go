@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    Reference
+		wantErr bool
+	}{
+		{ref: "alpine", want: Reference{Registry: dockerHub, Repository: "library/alpine", Reference: "latest"}},
+		{ref: "docker.io/org/app:1.2", want: Reference{Registry: dockerHub, Repository: "org/app", Reference: "1.2"}},
+		{ref: "ghcr.io/org/app/api:v1", want: Reference{Registry: "ghcr.io", Repository: "org/app/api", Reference: "v1"}},
+		{ref: "localhost:5000/app@sha256:abc", want: Reference{Registry: "localhost:5000", Repository: "app", Reference: "sha256:abc"}},
+		{ref: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReference(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReference(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestPull(t *testing.T) {
+	layer := layerOf(t, [2]string{"app/.env", "TOKEN=x\n"})
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"token": "t0k"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t0k" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/v1":
+			w.Write([]byte(`{"manifests": [
+				{"digest": "sha256:arm", "platform": {"os": "linux", "architecture": "arm64"}},
+				{"digest": "sha256:amd", "platform": {"os": "linux", "architecture": "amd64"}}]}`))
+		case "/v2/team/app/manifests/sha256:amd":
+			w.Write([]byte(`{"config": {"digest": "sha256:cfg"}, "layers": [{"digest": "sha256:layer"}]}`))
+		case "/v2/team/app/blobs/sha256:cfg":
+			w.Write([]byte(`{"history": [{"created_by": "/bin/sh -c #(nop) COPY file:env in /app "}]}`))
+		case "/v2/team/app/blobs/sha256:layer":
+			w.Write(layer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+	img, err := Pull(context.Background(), host+"/team/app:v1", WithClient(srv.Client()), WithPlatform("linux/amd64"))
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	defer img.Close()
+
+	if len(img.Layers) != 1 || img.Layers[0].Digest != "sha256:layer" || img.Layers[0].Instruction != "COPY file:env in /app" {
+		t.Fatalf("Pull() layers = %+v, want sha256:layer created by COPY", img.Layers)
+	}
+	files, err := img.Layers[0].Files()
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "/app/.env" {
+		t.Errorf("Files() = %+v, want /app/.env", files)
+	}
+}
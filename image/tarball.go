@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrUnknownFormat is returned for tarballs that are neither docker save
+// output nor an OCI image layout
+var ErrUnknownFormat = errors.New("not a docker save or OCI layout tarball")
+
+// OpenTarball opens an image saved with docker save, or an OCI image layout,
+// as a tarball that may be gzip-compressed. The image must be closed to
+// release the file.
+func OpenTarball(name string) (*Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image tarball: %w", err)
+	}
+	if f, err = uncompressed(f); err != nil {
+		return nil, err
+	}
+	closeFile := func() error {
+		f.Close()
+		if f.Name() != name {
+			return os.Remove(f.Name())
+		}
+		return nil
+	}
+
+	entries, err := index(f)
+	if err != nil {
+		closeFile()
+		return nil, err
+	}
+	img := &Image{Name: name, close: closeFile}
+	if _, ok := entries["manifest.json"]; ok {
+		err = img.readDockerSave(entries)
+	} else if _, ok := entries["index.json"]; ok {
+		err = img.readLayout(entries)
+	} else {
+		err = ErrUnknownFormat
+	}
+	if err != nil {
+		closeFile()
+		return nil, err
+	}
+	return img, nil
+}
+
+// uncompressed returns f, or a temporary file with the decompressed content
+// of f if it is gzip-compressed, since reading entries needs random access
+func uncompressed(f *os.File) (*os.File, error) {
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err != nil || !bytes.Equal(magic, []byte("\x1f\x8b")) {
+		_, err := f.Seek(0, io.SeekStart)
+		return f, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress image tarball: %w", err)
+	}
+	tmp, err := os.CreateTemp("", "secret-scanner-image-*.tar")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress image tarball: %w", err)
+	}
+	if _, err := io.Copy(tmp, zr); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to decompress image tarball: %w", err)
+	}
+	return tmp, nil
+}
+
+// index returns a reader of each regular file of a tarball by name
+func index(f *os.File) (map[string]*io.SectionReader, error) {
+	entries := make(map[string]*io.SectionReader)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image tarball: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// tar.Reader reads no further than the header, so the file offset is
+		// where the entry's content starts
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image tarball: %w", err)
+		}
+		entries[strings.TrimPrefix(hdr.Name, "./")] = io.NewSectionReader(f, offset, hdr.Size)
+	}
+}
+
+func readJSON(entries map[string]*io.SectionReader, name string, v any) error {
+	r, ok := entries[name]
+	if !ok {
+		return fmt.Errorf("failed to read %s: not in tarball", name)
+	}
+	if err := json.NewDecoder(io.NewSectionReader(r, 0, r.Size())).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return nil
+}
+
+// opener returns a function opening entry name
+func opener(entries map[string]*io.SectionReader, name string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		r, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("%s not in tarball", name)
+		}
+		return io.NopCloser(io.NewSectionReader(r, 0, r.Size())), nil
+	}
+}
+
+// readDockerSave reads the first image of docker save output
+func (img *Image) readDockerSave(entries map[string]*io.SectionReader) error {
+	var manifests []struct {
+		Config string   `json:"Config"`
+		Layers []string `json:"Layers"`
+	}
+	if err := readJSON(entries, "manifest.json", &manifests); err != nil {
+		return err
+	}
+	if len(manifests) == 0 {
+		return fmt.Errorf("failed to read manifest.json: no images")
+	}
+	m := manifests[0]
+	if err := readJSON(entries, m.Config, &img.Config); err != nil {
+		return err
+	}
+
+	instructions := img.Config.instructions(len(m.Layers))
+	for i, name := range m.Layers {
+		digest := name
+		if i < len(img.Config.RootFS.DiffIDs) {
+			digest = img.Config.RootFS.DiffIDs[i]
+		}
+		img.Layers = append(img.Layers, Layer{Digest: digest, Instruction: instructions[i], open: opener(entries, name)})
+	}
+	return nil
+}
+
+// readLayout reads the first image of an OCI image layout
+func (img *Image) readLayout(entries map[string]*io.SectionReader) error {
+	var m manifest
+	if err := readJSON(entries, "index.json", &m); err != nil {
+		return err
+	}
+	// Follow indexes down to an image manifest
+	for len(m.Manifests) > 0 {
+		digest := m.platform("").Digest
+		m = manifest{}
+		if err := readJSON(entries, blobPath(digest), &m); err != nil {
+			return err
+		}
+	}
+	if err := readJSON(entries, blobPath(m.Config.Digest), &img.Config); err != nil {
+		return err
+	}
+
+	instructions := img.Config.instructions(len(m.Layers))
+	for i, layer := range m.Layers {
+		img.Layers = append(img.Layers, Layer{Digest: layer.Digest, Instruction: instructions[i], open: opener(entries, blobPath(layer.Digest))})
+	}
+	return nil
+}
+
+// blobPath returns the path of a blob in an OCI image layout
+func blobPath(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTar writes a tarball of entries, in order, to a file in a temporary
+// directory
+func writeTar(t *testing.T, entries ...[2]string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+	defer f.Close()
+	w := tar.NewWriter(f)
+	for _, e := range entries {
+		if err := w.WriteHeader(&tar.Header{Name: e[0], Mode: 0o644, Size: int64(len(e[1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := w.Write([]byte(e[1])); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tarball: %v", err)
+	}
+	return name
+}
+
+func TestOpenTarball(t *testing.T) {
+	config := `{
+  "config": {"Env": ["PATH=/usr/bin"]},
+  "history": [
+    {"created_by": "/bin/sh -c #(nop) ADD file:base in / "},
+    {"created_by": "/bin/sh -c #(nop) ENV MODE=prod", "empty_layer": true},
+    {"created_by": "COPY . /app # buildkit"}
+  ],
+  "rootfs": {"diff_ids": ["sha256:base", "sha256:app"]}
+}`
+	name := writeTar(t,
+		[2]string{"manifest.json", `[{"Config": "config.json", "Layers": ["base/layer.tar", "app/layer.tar"]}]`},
+		[2]string{"config.json", config},
+		[2]string{"base/layer.tar", string(layerOf(t, [2]string{"etc/os-release", "ID=test\n"}))},
+		[2]string{"app/layer.tar", string(layerOf(t, [2]string{"app/.env", "TOKEN=x\n"}))},
+	)
+
+	img, err := OpenTarball(name)
+	if err != nil {
+		t.Fatalf("OpenTarball failed: %v", err)
+	}
+	defer img.Close()
+
+	if len(img.Layers) != 2 {
+		t.Fatalf("OpenTarball found %d layers, want 2", len(img.Layers))
+	}
+	if l := img.Layers[1]; l.Digest != "sha256:app" || l.Instruction != "COPY . /app" {
+		t.Errorf("Layers[1] = %s created by %q, want sha256:app created by \"COPY . /app\"", l.Digest, l.Instruction)
+	}
+	files, err := img.Layers[1].Files()
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "/app/.env" || files[0].Content != "TOKEN=x\n" {
+		t.Errorf("Layers[1].Files() = %+v, want /app/.env", files)
+	}
+}
+
+func TestOpenTarballLayout(t *testing.T) {
+	layer := string(layerOf(t, [2]string{"app/config.json", `{"key": "value"}`}))
+	name := writeTar(t,
+		[2]string{"oci-layout", `{"imageLayoutVersion": "1.0.0"}`},
+		[2]string{"index.json", `{"manifests": [{"digest": "sha256:m1"}]}`},
+		[2]string{"blobs/sha256/m1", `{"config": {"digest": "sha256:c1"}, "layers": [{"digest": "sha256:l1"}]}`},
+		[2]string{"blobs/sha256/c1", `{"history": [{"created_by": "COPY . /app # buildkit"}]}`},
+		[2]string{"blobs/sha256/l1", layer},
+	)
+
+	img, err := OpenTarball(name)
+	if err != nil {
+		t.Fatalf("OpenTarball failed: %v", err)
+	}
+	defer img.Close()
+
+	if len(img.Layers) != 1 || img.Layers[0].Digest != "sha256:l1" || img.Layers[0].Instruction != "COPY . /app" {
+		t.Fatalf("OpenTarball() layers = %+v, want sha256:l1 created by COPY . /app", img.Layers)
+	}
+	files, err := img.Layers[0].Files()
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "/app/config.json" {
+		t.Errorf("Layers[0].Files() = %+v, want /app/config.json", files)
+	}
+}
+
+func TestOpenTarballUnknownFormat(t *testing.T) {
+	name := writeTar(t, [2]string{"notes.txt", "hello"})
+	if _, err := OpenTarball(name); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("OpenTarball() error = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package e2e runs the secret-scanner CLI against fixture directories and
+// compares its output with golden files, one per output format, so that every
+// emitter is checked against the same inputs
+package e2e
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Formats lists the output formats each fixture is checked in
+var Formats = []string{"text", "csaf", "csv", "github", "gitlab-sast", "junit", "markdown"}
+
+var update = flag.Bool("update", false, "Rewrite golden files with the current output")
+
+// Output is the result of a CLI run
+type Output struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// Build compiles the CLI into a temporary directory and returns its path
+func Build(t testing.TB) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "secret-scanner")
+	cmd := exec.Command("go", "build", "-o", bin, "github.com/stackloklabs/secret-scanning-api")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build CLI: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// Run runs the CLI binary in dir with args
+func Run(t testing.TB, bin, dir string, args ...string) Output {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader("")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	out := Output{}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("Failed to run CLI: %v", err)
+		}
+		out.ExitCode = exitErr.ExitCode()
+	}
+	out.Stdout = stdout.Bytes()
+	out.Stderr = stderr.Bytes()
+	return out
+}
+
+// Args reads the CLI arguments of a fixture from its "args" file, one per line
+func Args(t testing.TB, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture args: %v", err)
+	}
+	var args []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			args = append(args, line)
+		}
+	}
+	return args
+}
+
+// ExitCode reads the expected exit code of a fixture from its "exitcode"
+// file, or returns 0 if it has none
+func ExitCode(t testing.TB, dir string) int {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "exitcode"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("Failed to read fixture exit code: %v", err)
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("Invalid fixture exit code: %v", err)
+	}
+	return code
+}
+
+var (
+	trackingID = regexp.MustCompile(`secret-scan-\d{8}T\d{6}Z`)
+	timestamp  = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?`)
+)
+
+// Normalize replaces values that change between runs, such as timestamps
+func Normalize(out []byte) []byte {
+	out = trackingID.ReplaceAll(out, []byte("secret-scan-TIMESTAMP"))
+	return timestamp.ReplaceAll(out, []byte("TIMESTAMP"))
+}
+
+// Golden compares got with the golden file at path, or rewrites the file when
+// the tests run with -update
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output differs from %s (run with -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
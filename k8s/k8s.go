@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package k8s scans Kubernetes manifests, decoding the base64 data of Secret
+// resources and reporting findings with the Secret name and key
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/dotenv"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/structscan"
+)
+
+// Rule is the result type of Secret values flagged without a pattern match:
+// stringData values, and data values under sensitive keys such as password
+const Rule = "kubernetes_secret"
+
+// confidence is the confidence of values flagged without a pattern match
+const confidence = 0.7
+
+// Entry is a key of a Secret's data or stringData
+type Entry struct {
+	// Secret names the Secret as kind/namespace/name, or kind/name without a
+	// namespace, as in Secret/prod/db-credentials
+	Secret string
+	// Field is "data" or "stringData"
+	Field string
+	Key   string
+	// Value is the decoded value
+	Value string
+	// Start and End span the value as written in the manifest
+	Start, End int
+	Line       int
+}
+
+// Path returns the location of the entry, as in
+// Secret/prod/db-credentials.data.password
+func (e Entry) Path() string {
+	return e.Secret + "." + e.Field + "." + e.Key
+}
+
+// Secrets returns the data and stringData entries of the Secret resources of
+// a multi-document manifest, with data values base64-decoded. Entries whose
+// data does not decode are skipped.
+func Secrets(content string) ([]Entry, error) {
+	var entries []Entry
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if len(doc.Content) == 0 || scalar(doc.Content[0], "kind") != "Secret" {
+			continue
+		}
+		root := doc.Content[0]
+		metadata := value(root, "metadata")
+		name := "Secret/" + scalar(metadata, "name")
+		if ns := scalar(metadata, "namespace"); ns != "" {
+			name = "Secret/" + ns + "/" + scalar(metadata, "name")
+		}
+
+		for _, field := range []string{"data", "stringData"} {
+			m := value(root, field)
+			if m == nil || m.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(m.Content); i += 2 {
+				k, v := m.Content[i], m.Content[i+1]
+				if v.Kind != yaml.ScalarNode || v.Value == "" {
+					continue
+				}
+				e := Entry{Secret: name, Field: field, Key: k.Value, Value: v.Value, Line: v.Line}
+				e.Start = structscan.ValueOffset(content, v)
+				e.End = e.Start + len(v.Value)
+				if field == "data" {
+					decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(v.Value), ""))
+					if err != nil {
+						continue
+					}
+					e.Value = string(decoded)
+				}
+				entries = append(entries, e)
+			}
+		}
+	}
+}
+
+// value returns the value of key in a mapping node, or nil
+func value(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalar returns the scalar value of key in a mapping node, or ""
+func scalar(node *yaml.Node, key string) string {
+	if v := value(node, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// Scan scans a manifest with s as a structured document and additionally
+// scans the decoded data of each Secret, as "key: value" so that name-keyed
+// patterns match. Findings in decoded data are reported at the encoded value
+// with Encoded set. stringData values, and data values under sensitive keys,
+// that no pattern matched are reported as Rule.
+func Scan(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	results, err := structscan.Scan(ctx, s, content)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Secrets(content)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		matched := false
+		if e.Field == "data" {
+			decoded, err := s.Scan(ctx, e.Key+": "+e.Value)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range decoded {
+				if decodedBefore(results, r.Type, e) {
+					// The scanner decodes base64 itself
+					continue
+				}
+				r.StartIndex, r.EndIndex = e.Start, e.End
+				r.LineNumber, r.EndLineNumber = e.Line, 0
+				r.Encoded, r.Encoding = true, "base64"
+				r.Path = e.Path()
+				r.Context = ""
+				results = append(results, r)
+				matched = matched || r.Severity.AtLeast(scanner.SeverityMedium)
+			}
+		}
+		for i := range results {
+			r := &results[i]
+			if r.StartIndex >= e.Start && r.StartIndex < e.End {
+				r.Path = e.Path()
+				matched = matched || r.Severity.AtLeast(scanner.SeverityMedium)
+			}
+		}
+
+		sensitive := e.Field == "stringData" || dotenv.Sensitive(e.Key, e.Value)
+		if matched || !sensitive || len(e.Value) < 4 || dotenv.IsReference(e.Value) || scanner.IsPlaceholder(e.Value) {
+			continue
+		}
+		result := s.NewResult(Rule, e.Value, e.Start, e.Line, confidence)
+		result.EndIndex = e.End
+		result.EndLineNumber = 0
+		result.Encoded = e.Field == "data"
+		if result.Encoded {
+			result.Encoding = "base64"
+		}
+		result.Path = e.Path()
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// decodedBefore reports whether results already hold a decoded finding of
+// type typ within the value of e
+func decodedBefore(results []scanner.Result, typ string, e Entry) bool {
+	for _, r := range results {
+		if r.Encoded && r.Type == typ && r.StartIndex >= e.Start && r.StartIndex < e.End {
+			return true
+		}
+	}
+	return false
+}
@@ -7,14 +7,105 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/stackloklabs/secret-scanning-api/advisory"
+	"github.com/stackloklabs/secret-scanning-api/apispec"
+	"github.com/stackloklabs/secret-scanning-api/archive"
+	"github.com/stackloklabs/secret-scanning-api/binstrings"
+	"github.com/stackloklabs/secret-scanning-api/config"
+	"github.com/stackloklabs/secret-scanning-api/credfile"
+	"github.com/stackloklabs/secret-scanning-api/dirscan"
+	"github.com/stackloklabs/secret-scanning-api/docker"
+	"github.com/stackloklabs/secret-scanning-api/dotenv"
+	"github.com/stackloklabs/secret-scanning-api/extension"
+	"github.com/stackloklabs/secret-scanning-api/firmware"
+	"github.com/stackloklabs/secret-scanning-api/helm"
+	"github.com/stackloklabs/secret-scanning-api/image"
+	"github.com/stackloklabs/secret-scanning-api/k8s"
+	"github.com/stackloklabs/secret-scanning-api/mobile"
 	"github.com/stackloklabs/secret-scanning-api/patterns"
+	"github.com/stackloklabs/secret-scanning-api/propagation"
+	"github.com/stackloklabs/secret-scanning-api/registry"
+	"github.com/stackloklabs/secret-scanning-api/report"
+	"github.com/stackloklabs/secret-scanning-api/rules"
 	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/structscan"
+	"github.com/stackloklabs/secret-scanning-api/terraform"
+)
+
+// version is set at build time by the release target of the Makefile
+var version = "dev"
+
+// traceCopies makes scans of extracted files report every other place the
+// value of each secret appears; see the -trace flag
+var traceCopies bool
+
+// format is the output format; see the -format flag. Findings of formats
+// other than text are collected in findings, and the files left unscanned in
+// unscanned, and written once every scan is done. incomplete records whether
+// part of the input could not be read, which exits exitError after the report.
+var (
+	format     string
+	findings   []report.Finding
+	unscanned  []report.Skipped
+	incomplete bool
+)
+
+// formats lists the output formats besides text
+var formats = map[string]func(w io.Writer, findings []report.Finding) error{
+	"csaf":        writeAdvisory,
+	"csv":         report.WriteCSV,
+	"github":      writeGitHub,
+	"gitlab-sast": writeGitLab,
+	"junit":       writeJUnit,
+	"markdown":    writeMarkdown,
+}
+
+// started is when the scan started, for reports that record it
+var started = time.Now()
+
+// maxSize and scanTimeout bound the size of and the time spent on each
+// scanned file; see the -max-size and -timeout flags
+var (
+	maxSize     int64
+	scanTimeout time.Duration
+)
+
+// allowlist, minSeverity, and minConfidence drop findings from every scan;
+// see the allowlist and min_severity settings of the configuration file and
+// the -min-confidence flag
+var (
+	allowlist     *config.Allowlist
+	minSeverity   scanner.Severity
+	minConfidence float64
+)
+
+// Exit codes, so that CI pipelines can gate on a scan without parsing its
+// output. A clean scan exits 0.
+const (
+	// exitFindings means a finding at or above the fail_on severity was reported
+	exitFindings = 1
+	// exitError means the scan could not run, such as on an unreadable input,
+	// or left part of the input unread
+	exitError = 2
+)
+
+// failOn is the lowest severity of the findings that fail the run, or any
+// finding if empty, and failed records whether one was reported; see the
+// fail_on setting of the configuration file
+var (
+	failOn scanner.Severity
+	failed bool
 )
 
 type scanFilters struct {
@@ -26,62 +117,330 @@ type scanFilters struct {
 func main() {
 	var (
 		file        string
+		dir         string
 		text        string
+		app         string
+		fw          string
+		reg         string
+		ext         string
+		apiSpec     string
 		showHelp    bool
+		showVersion bool
 		entropyOnly bool
 		maskSecrets bool
+		binStrings  bool
+		secure      bool
+		salt        string
+		group       bool
+		contextN    int
+		keywordWin  int
+		decode      bool
+		decoders    string
+		decodeDepth int
+		unescape    bool
+		normalize   bool
+		maxSizeFlag string
+		excludes    stringList
+		configFile  string
+		rulesFile   string
+		replaceAll  bool
+		workers     int
+		failOnFlag  string
+		minSevFlag  string
 		filters     scanFilters
 	)
 
 	// File and general flags
-	flag.StringVar(&file, "file", "", "File to scan for secrets")
+	flag.StringVar(&file, "file", "", "File to scan for secrets; zip and tar archives are opened")
+	flag.StringVar(&dir, "dir", "", "Directory to scan for secrets, scanning duplicate files once")
 	flag.StringVar(&text, "text", "", "Text to scan for secrets")
+	flag.StringVar(&app, "app", "", "Mobile app archive (APK/IPA) to scan for secrets")
+	flag.StringVar(&fw, "firmware", "", "Firmware image (gzip squashfs, cpio initramfs) to scan for secrets")
+	flag.StringVar(&reg, "registry", "", "Windows registry export (.reg) or offline hive to scan for secrets")
+	flag.StringVar(&ext, "extension", "", "Browser extension (CRX, XPI, or unpacked directory) to scan for secrets")
+	flag.StringVar(&apiSpec, "apispec", "", "OpenAPI/Swagger spec or Postman collection/environment to scan for secrets")
 	flag.BoolVar(&entropyOnly, "entropy-only", false, "Use only entropy-based detection")
 	flag.BoolVar(&maskSecrets, "mask", true, "Mask secrets in output")
+	flag.BoolVar(&binStrings, "strings", false, "Scan the printable strings of binary input and report byte offsets")
+	flag.BoolVar(&secure, "secure", false, "Never keep raw secret values in memory or output")
+	flag.StringVar(&salt, "fingerprint-salt", "", "Salt for secret fingerprints")
+	flag.BoolVar(&group, "group", false, "Report each distinct secret once with its occurrences")
+	flag.IntVar(&contextN, "context", 0, "Lines of context to show around each finding")
+	flag.IntVar(&keywordWin, "keyword-window", scanner.DefaultKeywordWindow, "Bytes on each side of a match searched for the context keywords of its rule")
+	flag.BoolVar(&decode, "decode-base64", false, "Also scan the decoded content of base64 substrings")
+	flag.StringVar(&decoders, "decode", "", "Comma-separated decoders whose output is also scanned: base64, gzip, url, json")
+	flag.IntVar(&decodeDepth, "decode-depth", scanner.DefaultDecodeDepth, "Layers of nested encoding to decode")
+	flag.BoolVar(&unescape, "unescape", false, "Also match against the text with string escapes such as \\x41 and \\n resolved")
+	flag.BoolVar(&normalize, "normalize", false, "Also match against the text with full-width and look-alike characters replaced and zero-width characters removed")
+	flag.Var(&excludes, "exclude", "Skip paths under -dir matching this gitignore-style pattern, as in vendor/ or *.min.js (repeatable)")
+	flag.StringVar(&maxSizeFlag, "max-size", "0", "Skip files larger than this, as in 512KB or 10MB (0 for no limit)")
+	flag.DurationVar(&scanTimeout, "timeout", 0, "Stop scanning any single file after this long, as in 30s (0 for no limit)")
+	flag.BoolVar(&traceCopies, "trace", false, "Report every copy of each secret, raw or encoded, across the scanned files")
+	flag.StringVar(&format, "format", "text", "Output format: text, csaf, csv, github, gitlab-sast, junit, or markdown")
+	flag.StringVar(&configFile, "config", "", "YAML configuration file (default: "+config.DefaultFile+" in the current directory, if present)")
+	flag.IntVar(&workers, "workers", 0, "Number of concurrent scan workers (default: 4)")
+	flag.StringVar(&failOnFlag, "fail-on", "", "Exit with status 1 only on findings of this severity or above: critical, high, medium, or low")
+	flag.StringVar(&minSevFlag, "min-severity", "", "Drop findings below this severity: critical, high, medium, or low")
+	flag.Float64Var(&minConfidence, "min-confidence", 0, "Drop findings with a confidence below this, from 0 to 1, as in 0.7")
 	flag.BoolVar(&showHelp, "help", false, "Show help message")
+	flag.BoolVar(&showVersion, "version", false, "Show version and embedded rule packs")
 
 	// Pattern type flags
+	flag.StringVar(&rulesFile, "patterns-file", "", "YAML or JSON file of custom rules with regex, severity, keywords, and entropy threshold, or a gitleaks .toml config")
+	flag.BoolVar(&replaceAll, "replace-patterns", false, "Use only the rules of -patterns-file instead of adding them to the built-in patterns")
 	flag.BoolVar(&filters.enablePasswords, "passwords", true, "Enable password detection")
 	flag.BoolVar(&filters.enableAPIKeys, "apikeys", true, "Enable API key detection")
 	flag.BoolVar(&filters.enablePrivateKeys, "privatekeys", true, "Enable private key detection")
 
-	flag.Parse()
+	if len(os.Args) > 1 && os.Args[1] == "patterns" {
+		runPatterns(os.Args[2:])
+		return
+	}
+
+	// The image subcommand takes a reference, before or after the flags
+	var imageRef string
+	if len(os.Args) > 1 && os.Args[1] == "image" {
+		flag.CommandLine.Parse(os.Args[2:])
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: secret-scanner image [options] <reference or tarball>")
+			os.Exit(exitError)
+		}
+		imageRef = flag.Arg(0)
+		flag.CommandLine.Parse(flag.Args()[1:])
+	} else {
+		flag.Parse()
+	}
 
 	if showHelp {
 		printUsage()
 		return
 	}
+	if showVersion {
+		printVersion()
+		return
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if cfg != nil {
+		// Flags given on the command line override the configuration file
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if cfg.Format != "" && !set["format"] {
+			format = cfg.Format
+		}
+		if cfg.Workers > 0 && !set["workers"] {
+			workers = cfg.Workers
+		}
+		for name, group := range map[string]struct {
+			enabled *bool
+			flag    *bool
+		}{
+			"apikeys":     {cfg.Groups.APIKeys, &filters.enableAPIKeys},
+			"passwords":   {cfg.Groups.Passwords, &filters.enablePasswords},
+			"privatekeys": {cfg.Groups.PrivateKeys, &filters.enablePrivateKeys},
+		} {
+			if group.enabled != nil && !set[name] {
+				*group.flag = *group.enabled
+			}
+		}
+		excludes = append(stringList(cfg.Exclude), excludes...)
+		allowlist, minSeverity, failOn = &cfg.Allowlist, cfg.MinSeverity, cfg.FailOn
+	}
+	for _, setting := range []struct {
+		name  string
+		value string
+		dest  *scanner.Severity
+	}{
+		{"fail-on", failOnFlag, &failOn},
+		{"min-severity", minSevFlag, &minSeverity},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if *setting.dest, err = scanner.ParseSeverity(setting.value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -%s: %v\n", setting.name, err)
+			os.Exit(exitError)
+		}
+	}
+	if minConfidence < 0 || minConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -min-confidence: %v is not between 0 and 1\n", minConfidence)
+		os.Exit(exitError)
+	}
+	defer func() {
+		if write, ok := formats[format]; ok {
+			if err := write(os.Stdout, findings); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s report: %v\n", format, err)
+				os.Exit(exitError)
+			}
+		}
+		if incomplete {
+			os.Exit(exitError)
+		}
+		if failed {
+			os.Exit(exitFindings)
+		}
+	}()
+	if replaceAll && rulesFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -replace-patterns requires -patterns-file")
+		os.Exit(exitError)
+	}
+	if maxSize, err = parseSize(maxSizeFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -max-size: %v\n", err)
+		os.Exit(exitError)
+	}
+	if _, ok := formats[format]; !ok && format != "text" {
+		fmt.Fprintf(os.Stderr, "Unknown output format: %s\n", format)
+		os.Exit(exitError)
+	}
+
+	var decoderList []scanner.Decoder
+	if decoders != "" {
+		for _, name := range strings.Split(decoders, ",") {
+			d, err := scanner.LookupDecoder(strings.TrimSpace(name))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			decoderList = append(decoderList, d)
+		}
+	}
 
 	// Initialize scanner
-	s := scanner.New()
+	masking := scanner.DefaultMaskOptions
+	masking.None = !maskSecrets
+	s := scanner.New(
+		scanner.WithMasking(masking),
+		scanner.WithSecureMode(secure),
+		scanner.WithFingerprintSalt([]byte(salt)),
+		scanner.WithGrouping(group),
+		scanner.WithContextLines(contextN),
+		scanner.WithKeywordWindow(keywordWin),
+		scanner.WithBase64Decoding(decode),
+		scanner.WithDecoders(decoderList...),
+		scanner.WithDecodeDepth(decodeDepth),
+		scanner.WithUnescaping(unescape),
+		scanner.WithNormalization(normalize),
+		scanner.WithMaxSize(int(maxSize)),
+		scanner.WithWorkers(workers),
+		scanner.WithMinConfidence(minConfidence),
+	)
 
 	// Add patterns unless entropy-only mode is enabled
 	if !entropyOnly {
-		addPatternsWithFilters(s, filters)
+		if !replaceAll {
+			addPatternsWithFilters(s, filters)
+		}
+		if rulesFile != "" {
+			rules, err := loadRules(rulesFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			for _, rule := range rules {
+				if err := s.AddRule(rule); err != nil {
+					fmt.Fprintf(os.Stderr, "Error adding custom rule %s: %v\n", rule.Name, err)
+				}
+			}
+		}
+		if cfg != nil {
+			for name, pattern := range cfg.Rules {
+				if err := s.AddPattern(name, pattern); err != nil {
+					fmt.Fprintf(os.Stderr, "Error adding custom rule %s: %v\n", name, err)
+				}
+			}
+		}
+	}
+
+	if imageRef != "" {
+		scanImage(s, imageRef)
+		return
+	}
+	if dir != "" {
+		scanDir(s, dir, excludes)
+		return
+	}
+	if app != "" {
+		scanApp(s, app)
+		return
+	}
+	if fw != "" {
+		scanFirmware(s, fw)
+		return
+	}
+	if reg != "" {
+		scanRegistry(s, reg, secure)
+		return
+	}
+	if ext != "" {
+		scanExtension(s, ext, entropyOnly)
+		return
+	}
+	if apiSpec != "" {
+		scanAPISpec(s, apiSpec)
+		return
 	}
 
 	var input string
-	var err error
+	artifact := "stdin"
 
 	switch {
 	case file != "":
+		if info, statErr := os.Stat(file); statErr == nil && maxSize > 0 && info.Size() > maxSize {
+			reportSkipped([]skippedFile{{path: file, reason: fmt.Sprintf("%d bytes exceeds -max-size", info.Size())}})
+			return
+		}
 		input, err = readFile(file)
+		artifact = file
 	case text != "":
 		input = text
+		artifact = "text"
 	default:
 		input, err = readStdin()
 	}
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	if file != "" && !binStrings && archive.IsArchive([]byte(input)) {
+		scanArchive(s, file, []byte(input))
+		return
 	}
 
 	// Perform scan
-	results, err := s.Scan(context.Background(), input)
+	ctx, cancel := fileContext()
+	defer cancel()
+	var results []scanner.Result
+	var sections []binstrings.Finding
+	if binStrings && scanner.IsBinary([]byte(input)) {
+		sections, err = binstrings.ScanSections(ctx, s, []byte(input), binstrings.DefaultMinLength)
+		if errors.Is(err, binstrings.ErrUnknownFormat) {
+			results, err = binstrings.Scan(ctx, s, []byte(input), binstrings.DefaultMinLength)
+		}
+		sections = keepSections(sections)
+		for _, f := range sections {
+			results = append(results, f.Result)
+		}
+	} else {
+		results, err = scanContent(ctx, s, file, input)
+	}
+	results = keep(results)
+	if skip(err) {
+		reportSkipped([]skippedFile{{path: artifact, reason: skipReason(err)}})
+		return
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	sortResults(results)
+
+	if format != "text" {
+		collect(s, artifact, input, results)
+		return
 	}
 
 	// Print results
@@ -91,13 +450,750 @@ func main() {
 	}
 
 	fmt.Printf("Found %d potential secrets:\n\n", len(results))
+	if len(sections) > 0 {
+		printSections(s, sections)
+		return
+	}
+	printResults(s, results)
+}
+
+// scanContent scans the content of file with the detector for its kind, such
+// as dotenv files or Kubernetes manifests
+func scanContent(ctx context.Context, s *scanner.Scanner, file, input string) ([]scanner.Result, error) {
+	// fallback scans input as plain text when a detector cannot parse it
+	fallback := func(results []scanner.Result, err error) ([]scanner.Result, error) {
+		if err != nil && !skip(err) {
+			return s.Scan(ctx, input)
+		}
+		return results, err
+	}
+
+	switch {
+	case docker.IsDockerfile(file):
+		return docker.ScanDockerfile(ctx, s, input)
+	case docker.IsCompose(file):
+		return fallback(docker.ScanCompose(ctx, s, input))
+	case dotenv.IsDotenv(file):
+		return dotenv.Scan(ctx, s, input)
+	case credfile.IsCredentialFile(file):
+		return credfile.Scan(ctx, s, file, input)
+	case terraform.IsState(file):
+		return fallback(terraform.ScanState(ctx, s, input))
+	case terraform.IsVars(file):
+		return fallback(terraform.ScanVars(ctx, s, file, input))
+	case helm.IsTemplate(file):
+		return helm.ScanTemplate(ctx, s, input)
+	case helm.IsValues(file):
+		return fallback(helm.ScanValues(ctx, s, input))
+	case structscan.IsStructured(file):
+		// Documents that are not valid JSON/YAML after all, such as
+		// templates, are scanned as text
+		return fallback(k8s.Scan(ctx, s, input))
+	}
+	return s.Scan(ctx, input)
+}
+
+// loadRules reads a file of custom rules, either a gitleaks .toml
+// configuration or a YAML or JSON pattern file
+func loadRules(path string) ([]scanner.Rule, error) {
+	if strings.HasSuffix(path, ".toml") {
+		return patterns.LoadGitleaksConfig(path)
+	}
+	return patterns.LoadRules(path)
+}
+
+// loadConfig loads the configuration file at path, or the default one in the
+// current directory if path is empty, returning nil if there is none
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return config.Find(".")
+	}
+	return config.Load(path)
+}
+
+// keep drops the results suppressed by the allowlist or below the minimum
+// severity or confidence. Every reported result passes through keep, which
+// records whether one fails the run.
+func keep(results []scanner.Result) []scanner.Result {
+	kept := results[:0:0]
+	for _, result := range results {
+		if allowlist != nil && allowlist.Allowed(result) {
+			continue
+		}
+		if minSeverity != "" && !result.Severity.AtLeast(minSeverity) {
+			continue
+		}
+		// Results of structured files and registry values are made outside
+		// Scan, so WithMinConfidence does not see them
+		if result.Confidence < minConfidence {
+			continue
+		}
+		kept = append(kept, result)
+		failed = failed || failOn == "" || result.Severity.AtLeast(failOn)
+	}
+	return kept
+}
+
+// keepSections drops the binary findings that keep drops
+func keepSections(findings []binstrings.Finding) []binstrings.Finding {
+	var kept []binstrings.Finding
+	for _, f := range findings {
+		if len(keep([]scanner.Result{f.Result})) > 0 {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// stringList is a flag that can be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// skippedFile is a file left unscanned because of a size or time limit
+type skippedFile struct {
+	path   string
+	reason string
+}
+
+// skip reports whether err means a file was left unscanned because of
+// -max-size or -timeout, rather than a failure
+func skip(err error) bool {
+	return errors.Is(err, scanner.ErrTooLarge) || errors.Is(err, scanner.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// skipReason describes why err left a file unscanned
+func skipReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, scanner.ErrTimeout) {
+		return fmt.Sprintf("timed out after %s", scanTimeout)
+	}
+	return err.Error()
+}
+
+// fileContext returns the context of a single file's scan, bounded by
+// -timeout
+func fileContext() (context.Context, context.CancelFunc) {
+	if scanTimeout <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), scanTimeout)
+}
+
+// reportSkipped prints the files left unscanned, and adds them to the report
+func reportSkipped(skipped []skippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+	for _, f := range skipped {
+		unscanned = append(unscanned, report.Skipped{Artifact: f.path, Reason: f.reason})
+	}
+	out := status()
+	fmt.Fprintf(out, "Skipped %d file(s):\n", len(skipped))
+	for _, f := range skipped {
+		fmt.Fprintf(out, "  %s: %s\n", f.path, f.reason)
+	}
+	fmt.Fprintln(out)
+}
+
+// parseSize parses a size such as 1048576, 512KB, 10MB, or 2GB, with binary
+// multiples
+func parseSize(text string) (int64, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	multiple := int64(1)
+	for _, unit := range []struct {
+		suffix   string
+		multiple int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(text, unit.suffix) {
+			text, multiple = strings.TrimSuffix(text, unit.suffix), unit.multiple
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", text)
+	}
+	return n * multiple, nil
+}
+
+// printSections prints binary findings grouped by the section, or Go build
+// setting, they were found in
+func printSections(s *scanner.Scanner, findings []binstrings.Finding) {
+	for len(findings) > 0 {
+		n := 1
+		for n < len(findings) && findings[n].Section == findings[0].Section {
+			n++
+		}
+		results := make([]scanner.Result, 0, n)
+		for _, f := range findings[:n] {
+			results = append(results, f.Result)
+		}
+		fmt.Printf("%s: %d potential secrets\n\n", findings[0].Section, n)
+		printResults(s, results)
+		findings = findings[n:]
+	}
+}
+
+// collect adds the results of artifact, whose text is content, to the
+// findings of the report
+func collect(s *scanner.Scanner, artifact, content string, results []scanner.Result) {
+	findings = append(findings, reportFindings(s, artifact, content, results)...)
+}
+
+// reportFindings returns the report findings of the results of artifact,
+// whose text is content
+func reportFindings(s *scanner.Scanner, artifact, content string, results []scanner.Result) []report.Finding {
+	out := make([]report.Finding, 0, len(results))
+	for _, result := range results {
+		value := ""
+		if result.Value != "" {
+			value = s.Mask(result.Value)
+		}
+		out = append(out, report.NewFinding(artifact, content, value, result))
+	}
+	return out
+}
+
+// printClean reports a scan that found nothing, in text output
+func printClean() {
+	if format == "text" {
+		fmt.Println("No secrets detected")
+	}
+}
+
+// status returns where to print progress such as skipped files: stdout for
+// text output, and stderr for the other formats, to keep reports parseable
+func status() io.Writer {
+	if format == "text" {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// writeAdvisory writes the findings as a CSAF VEX advisory
+func writeAdvisory(w io.Writer, findings []report.Finding) error {
+	vex := make([]advisory.Finding, 0, len(findings))
+	for _, f := range findings {
+		vex = append(vex, advisory.Finding{Artifact: f.Artifact, Result: f.Result})
+	}
+	opts := make([]advisory.Option, 0, len(unscanned))
+	for _, s := range unscanned {
+		opts = append(opts, advisory.WithSkipped(s.Artifact, s.Reason))
+	}
+	return advisory.New(vex, opts...).Write(w)
+}
+
+// writeGitHub writes the findings and skipped files as GitHub Actions
+// annotations
+func writeGitHub(w io.Writer, findings []report.Finding) error {
+	return report.WriteGitHub(w, findings, unscanned...)
+}
+
+// writeGitLab writes the findings as a GitLab SAST security report
+func writeGitLab(w io.Writer, findings []report.Finding) error {
+	return report.WriteGitLabSAST(w, findings, report.GitLabScan{Version: version, Start: started, End: time.Now(), Skipped: unscanned})
+}
+
+// writeJUnit writes the findings as a JUnit XML report, with a skipped test
+// case per skipped file
+func writeJUnit(w io.Writer, findings []report.Finding) error {
+	return report.WriteJUnit(w, findings, unscanned...)
+}
+
+// writeMarkdown writes the findings and skipped files as a Markdown report
+func writeMarkdown(w io.Writer, findings []report.Finding) error {
+	return report.WriteMarkdown(w, findings, unscanned...)
+}
+
+// extractedFile is a file unpacked from an archive or image
+type extractedFile struct {
+	path    string
+	content string
+	// size is the size of the file when only part of it was read
+	size int64
+}
+
+// scanDir scans the text files of a directory tree as they are read, skipping
+// those excluded by its .secretignore file or the -exclude patterns
+func scanDir(s *scanner.Scanner, path string, excludes []string) {
+	e := newExtractedScan(s)
+	err := dirscan.WalkDir(path, func(f dirscan.File) error {
+		if f.Err != nil {
+			e.unreadable(f.Path, f.Err)
+			return nil
+		}
+		e.add(extractedFile{path: f.Path, content: f.Content, size: f.Size})
+		return nil
+	}, dirscan.WithExclude(excludes...), dirscan.WithMaxSize(maxSize))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+	if e.finish() == 0 {
+		printClean()
+	}
+}
+
+// scanApp scans the string resources, plists, and config files of a mobile app archive
+func scanApp(s *scanner.Scanner, path string) {
+	files, err := mobile.ExtractFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	e := newExtractedScan(s)
+	for _, f := range files {
+		if f.Err != nil {
+			e.unreadable(f.Path, f.Err)
+			continue
+		}
+		e.add(extractedFile{path: f.Path, content: f.Content})
+	}
+	if e.finish() == 0 {
+		printClean()
+	}
+}
+
+// scanArchive scans the text files of a zip or tar archive, including nested
+// archives, reporting each as archive.zip!inner/path
+func scanArchive(s *scanner.Scanner, path string, data []byte) {
+	files, err := archive.Extract(path, data, archive.DefaultLimits)
+	e := newExtractedScan(s)
+	if errors.Is(err, archive.ErrTooLarge) {
+		e.incomplete(path, fmt.Sprintf("%v, only the files read up to the limit scanned", err))
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	for _, f := range files {
+		e.add(extractedFile{path: f.Path, content: f.Content})
+	}
+	if e.finish() == 0 {
+		printClean()
+	}
+}
+
+// scanImage scans the history and the text files of each layer of a
+// container image, read from a docker save or OCI layout tarball if ref names
+// a file and pulled from its registry otherwise. Files are reported with the
+// digest of their layer and the instruction that created it.
+func scanImage(s *scanner.Scanner, ref string) {
+	var img *image.Image
+	var err error
+	if info, statErr := os.Stat(ref); statErr == nil && !info.IsDir() {
+		img, err = image.OpenTarball(ref)
+	} else {
+		img, err = image.Pull(context.Background(), ref)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading image: %v\n", err)
+		os.Exit(exitError)
+	}
+	defer img.Close()
+
+	// Build args and ENV values recorded in the image history
+	found := 0
+	results, err := docker.ScanDockerfile(context.Background(), s, img.Config.Dockerfile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+		os.Exit(exitError)
+	}
+	results = keep(results)
+	if len(results) > 0 {
+		sortResults(results)
+		found += len(results)
+		if format != "text" {
+			collect(s, img.Name+" history", img.Config.Dockerfile(), results)
+		} else {
+			fmt.Printf("%s history: %d potential secrets\n\n", img.Name, len(results))
+			printResults(s, results)
+		}
+	}
+
+	for _, layer := range img.Layers {
+		e := newExtractedScan(s)
+		files, err := layer.Files()
+		if err != nil {
+			e.incomplete(img.Name, err.Error())
+		}
+		created := layer.Instruction
+		if len(created) > 60 {
+			created = created[:57] + "..."
+		}
+		for _, f := range files {
+			location := fmt.Sprintf("%s (layer %s", f.Path, layer.Digest)
+			if created != "" {
+				location += ", " + created
+			}
+			e.add(extractedFile{path: location + ")", content: f.Content})
+		}
+		found += e.finish()
+	}
+	if found == 0 {
+		printClean()
+	}
+}
+
+// scanFirmware scans the text files of an unpacked firmware filesystem
+func scanFirmware(s *scanner.Scanner, path string) {
+	files, err := firmware.ExtractFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	extracted := make([]extractedFile, 0, len(files))
+	for _, f := range files {
+		extracted = append(extracted, extractedFile{path: f.Path, content: f.Content})
+	}
+	if scanExtracted(s, extracted) == 0 {
+		printClean()
+	}
+}
+
+// scanExtension scans a browser extension with the extension profile, which
+// adds OAuth client secret patterns and resolves source maps of bundled scripts
+func scanExtension(s *scanner.Scanner, path string, entropyOnly bool) {
+	if !entropyOnly {
+		for name, pattern := range patterns.BrowserExtensionPatterns {
+			if err := s.AddPattern(name, pattern); err != nil {
+				fmt.Fprintf(os.Stderr, "Error adding extension pattern %s: %v\n", name, err)
+			}
+		}
+	}
+
+	files, err := extension.ExtractFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	extracted := make([]extractedFile, 0, len(files))
+	for _, f := range files {
+		extracted = append(extracted, extractedFile{path: f.Path, content: f.Content})
+	}
+	if scanExtracted(s, extracted) == 0 {
+		printClean()
+	}
+}
+
+// scanAPISpec scans the examples, auth sections, and variables of an API
+// document, reporting each finding by JSON pointer
+func scanAPISpec(s *scanner.Scanner, path string) {
+	_, fields, err := apispec.ExtractFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	extracted := make([]extractedFile, 0, len(fields))
+	for _, f := range fields {
+		extracted = append(extracted, extractedFile{path: path + "#" + f.Pointer, content: f.Text()})
+	}
+	if scanExtracted(s, extracted) == 0 {
+		printClean()
+	}
+}
+
+// scanRegistry scans the values under credential-prone keys of a .reg export or offline hive
+func scanRegistry(s *scanner.Scanner, path string, secure bool) {
+	values, err := registry.ExtractFile(path)
+	e := newExtractedScan(s)
+	if errors.Is(err, registry.ErrCorrupt) {
+		e.incomplete(path, err.Error())
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	found := 0
+	for _, v := range values {
+		location := v.Key + `\` + v.Name
+		if !v.Credential {
+			e.add(extractedFile{path: location, content: v.Data})
+			continue
+		}
+
+		// Stored credentials are findings because of where they live
+		found++
+		result := scanner.Result{
+			Type:        v.Rule,
+			Value:       v.Data,
+			EndIndex:    len(v.Data),
+			LineNumber:  1,
+			Confidence:  0.9,
+			Severity:    scanner.SeverityHigh,
+			Description: v.Description,
+			Fingerprint: s.Fingerprint(v.Rule, v.Data),
+		}
+		if secure {
+			result.Value = ""
+		}
+		if len(keep([]scanner.Result{result})) == 0 {
+			continue
+		}
+		if format != "text" {
+			collect(s, location, v.Data, []scanner.Result{result})
+			continue
+		}
+		fmt.Printf("%s: stored credential\n\n", location)
+		printResults(s, []scanner.Result{result})
+	}
+
+	if found+e.finish() == 0 {
+		printClean()
+	}
+}
+
+// scanExtracted scans and prints each extracted file with the detector for its
+// kind, returning the number of findings. Files with identical content are
+// scanned once and their findings are attributed to every path.
+func scanExtracted(s *scanner.Scanner, files []extractedFile) int {
+	e := newExtractedScan(s)
+	for _, f := range files {
+		e.add(f)
+	}
+	return e.finish()
+}
+
+// extractedScan scans extracted files one at a time, each distinct content
+// once, keeping the hashes of the contents and their findings rather than the
+// contents themselves
+type extractedScan struct {
+	s       *scanner.Scanner
+	blobs   []scannedBlob
+	index   map[string]int // content hash to index in blobs
+	skipped []skippedFile
+	// files and traced are kept for -trace, which searches every file for
+	// each secret
+	files  []dirscan.File
+	traced []propagation.Finding
+}
+
+// scannedBlob is a distinct file content, every path it was found at, and
+// its findings
+type scannedBlob struct {
+	paths    []string
+	results  []scanner.Result
+	findings []report.Finding // at the first path, for the structured formats
+}
+
+func newExtractedScan(s *scanner.Scanner) *extractedScan {
+	return &extractedScan{s: s, index: make(map[string]int)}
+}
+
+// add scans f with the detector for its kind, unless its content was scanned
+// before, in which case its path is attributed the findings of that content
+func (e *extractedScan) add(f extractedFile) {
+	if size := max(f.size, int64(len(f.content))); maxSize > 0 && size > maxSize {
+		e.skipped = append(e.skipped, skippedFile{path: f.path, reason: fmt.Sprintf("%d bytes exceeds -max-size", size)})
+		return
+	}
+	if f.size > int64(len(f.content)) {
+		e.skipped = append(e.skipped, skippedFile{path: f.path, reason: fmt.Sprintf("only the first %d of %d bytes scanned", len(f.content), f.size)})
+	}
+	if traceCopies {
+		e.files = append(e.files, dirscan.File{Path: f.path, Content: f.content})
+	}
+
+	hash := dirscan.Hash(f.content)
+	if i, ok := e.index[hash]; ok {
+		e.blobs[i].paths = append(e.blobs[i].paths, f.path)
+		return
+	}
+	e.index[hash] = len(e.blobs)
+	e.blobs = append(e.blobs, scannedBlob{paths: []string{f.path}})
+	blob := &e.blobs[len(e.blobs)-1]
+
+	// Copies share the detector of the first path, as they share content
+	ctx, cancel := fileContext()
+	results, err := scanContent(ctx, e.s, f.path, f.content)
+	cancel()
+	if skip(err) {
+		e.skipped = append(e.skipped, skippedFile{path: f.path, reason: skipReason(err)})
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", f.path, err)
+		os.Exit(exitError)
+	}
+	if results = keep(results); len(results) == 0 {
+		return
+	}
+	sortResults(results)
+	for _, result := range results {
+		e.traced = append(e.traced, propagation.Finding{Path: f.path, Result: result})
+	}
+	blob.results = results
+	if format != "text" {
+		blob.findings = reportFindings(e.s, f.path, f.content, results)
+	}
+}
+
+// unreadable records a file or directory that could not be read as unscanned
+func (e *extractedScan) unreadable(path string, err error) {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err // the path is already reported
+	}
+	e.incomplete(path, fmt.Sprintf("failed to read: %v", err))
+}
+
+// incomplete records part of the input that was left wholly or partly
+// unscanned, which fails the run once the report is written
+func (e *extractedScan) incomplete(path, reason string) {
+	e.skipped = append(e.skipped, skippedFile{path: path, reason: reason})
+	incomplete = true
+}
+
+// finish prints or collects the findings of every scanned file, returning
+// their number
+func (e *extractedScan) finish() int {
+	found := 0
+	for _, blob := range e.blobs {
+		if len(blob.results) == 0 {
+			continue
+		}
+		found += len(blob.results)
+		if format != "text" {
+			for _, path := range blob.paths {
+				for _, f := range blob.findings {
+					f.Artifact = path
+					findings = append(findings, f)
+				}
+			}
+			continue
+		}
+		fmt.Printf("%s: %d potential secrets\n", blob.paths[0], len(blob.results))
+		if len(blob.paths) > 1 {
+			fmt.Printf("Same content at: %s\n", strings.Join(blob.paths[1:], ", "))
+		}
+		fmt.Println()
+		printResults(e.s, blob.results)
+	}
+	if traceCopies {
+		printPropagation(propagation.Trace(e.files, e.traced))
+	}
+	reportSkipped(e.skipped)
+	return found
+}
+
+// printPropagation prints where each secret was first found and every copy of it
+func printPropagation(graphs []propagation.Graph) {
+	if len(graphs) == 0 {
+		return
+	}
+	out := status()
+	fmt.Fprintln(out, "Propagation:")
+	for _, g := range graphs {
+		fmt.Fprintf(out, "%s %s first found at %s:%d\n", g.Type, g.Fingerprint, g.Source.Path, g.Source.LineNumber)
+		for _, c := range g.Copies {
+			if c.Encoding != "" {
+				fmt.Fprintf(out, "  -> %s:%d (%s)\n", c.Path, c.LineNumber, c.Encoding)
+			} else {
+				fmt.Fprintf(out, "  -> %s:%d\n", c.Path, c.LineNumber)
+			}
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+// sortResults orders results by position so that output is stable between runs
+func sortResults(results []scanner.Result) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].StartIndex != results[j].StartIndex {
+			return results[i].StartIndex < results[j].StartIndex
+		}
+		return results[i].Type < results[j].Type
+	})
+}
+
+func printResults(s *scanner.Scanner, results []scanner.Result) {
 	for i, result := range results {
 		fmt.Printf("%d. Type: %s\n", i+1, result.Type)
+		if result.RuleID != "" {
+			fmt.Printf("   Rule ID: %s\n", result.RuleID)
+		}
 		fmt.Printf("   Description: %s\n", result.Description)
 		fmt.Printf("   Confidence: %.2f\n", result.Confidence)
-		fmt.Printf("   Value: %s\n", scanner.MaskSecret(result.Value, 2)) // Updated to include exposeCount
+		if result.Severity != "" {
+			fmt.Printf("   Severity: %s\n", result.Severity)
+		}
+		if result.Value == "" {
+			fmt.Println("   Value: (withheld)")
+		} else {
+			fmt.Printf("   Value: %s\n", s.Mask(result.Value))
+		}
+		if strings.Contains(result.Match, "://") && strings.Contains(result.Match, result.Value) {
+			// Credentials in URLs show where they lead, with only the secret masked
+			fmt.Printf("   URL: %s\n", strings.Replace(result.Match, result.Value, s.Mask(result.Value), 1))
+		}
 		fmt.Printf("   Position: %d-%d\n", result.StartIndex, result.EndIndex)
-		fmt.Printf("   Line Number: %d\n", result.LineNumber)
+		if result.Path != "" {
+			fmt.Printf("   Path: %s\n", result.Path)
+		}
+		if result.EndLineNumber > result.LineNumber {
+			fmt.Printf("   Line Number: %d-%d\n", result.LineNumber, result.EndLineNumber)
+		} else if result.LineNumber > 0 {
+			fmt.Printf("   Line Number: %d\n", result.LineNumber)
+		}
+		fmt.Printf("   Fingerprint: %s\n", result.Fingerprint)
+		if result.Encoded {
+			fmt.Printf("   Encoded: %s\n", result.Encoding)
+		}
+		if jwt := result.JWT; jwt != nil {
+			expiry := "no expiry"
+			if jwt.ExpiresAt != nil {
+				expiry = "expires " + jwt.ExpiresAt.Format(time.RFC3339)
+				if jwt.Expired {
+					expiry = "expired " + jwt.ExpiresAt.Format(time.RFC3339)
+				}
+			}
+			fmt.Printf("   JWT: alg=%s iss=%s, %s\n", jwt.Algorithm, jwt.Issuer, expiry)
+		}
+		if result.AWSAccountID != "" {
+			fmt.Printf("   AWS Account: %s\n", result.AWSAccountID)
+		}
+		if account := result.ServiceAccount; account != nil {
+			fmt.Printf("   Service Account: %s (project %s)\n", orDash(account.ClientEmail), orDash(account.ProjectID))
+		}
+		if key := result.Key; key != nil {
+			desc := key.Type
+			if desc == "" {
+				desc = "unknown"
+			}
+			if key.Bits > 0 {
+				desc += fmt.Sprintf(" %d-bit", key.Bits)
+			}
+			if key.Encrypted {
+				desc += ", passphrase-encrypted"
+			} else {
+				desc += ", unencrypted"
+			}
+			fmt.Printf("   Key: %s\n", desc)
+		}
+		if result.Occurrences > 1 {
+			lines := make([]string, 0, len(result.Locations))
+			for _, loc := range result.Locations {
+				lines = append(lines, strconv.Itoa(loc.LineNumber))
+			}
+			fmt.Printf("   Occurrences: %d (lines %s)\n", result.Occurrences, strings.Join(lines, ", "))
+		}
+		if result.Context != "" {
+			fmt.Println("   Context:")
+			for _, line := range strings.Split(result.Context, "\n") {
+				fmt.Printf("     | %s\n", line)
+			}
+		}
 		fmt.Println()
 	}
 }
@@ -109,6 +1205,11 @@ func addPatternsWithFilters(s *scanner.Scanner, filters scanFilters) {
 				fmt.Fprintf(os.Stderr, "Error adding API pattern %s: %v\n", name, err)
 			}
 		}
+		for name, pattern := range patterns.GraphQLPatterns {
+			if err := s.AddPattern(name, pattern); err != nil {
+				fmt.Fprintf(os.Stderr, "Error adding GraphQL pattern %s: %v\n", name, err)
+			}
+		}
 	}
 
 	if filters.enablePasswords {
@@ -154,27 +1255,107 @@ func readStdin() (string, error) {
 	return builder.String(), nil
 }
 
+func printVersion() {
+	info := rules.Manifest()
+	fmt.Printf("secret-scanner %s\n", version)
+	fmt.Printf("Ruleset version: %s\n", info.Version)
+	fmt.Printf("Rules digest: %s\n", info.Digest)
+	for _, pack := range info.Packs {
+		fmt.Printf("  %-18s %3d patterns  sha256:%s\n", pack.Name, pack.Patterns, pack.SHA256)
+	}
+}
+
 func printUsage() {
 	fmt.Println(`Secret Scanner - Detect potential secrets in text
 
 Usage:
   secret-scanner [options]
+  secret-scanner image [options] <reference or tarball>
+  secret-scanner patterns list|describe|test [options]
 
 Options:
   -file string
-        File to scan for secrets
+        File to scan for secrets; zip and tar archives are opened
+  -dir string
+        Directory to scan for secrets, scanning duplicate files once
   -text string
         Text to scan for secrets
+  -app string
+        Mobile app archive (APK/IPA) to scan for secrets
+  -firmware string
+        Firmware image (gzip squashfs, cpio initramfs) to scan for secrets
+  -registry string
+        Windows registry export (.reg) or offline hive to scan for secrets
+  -extension string
+        Browser extension (CRX, XPI, or unpacked directory) to scan for secrets
+  -apispec string
+        OpenAPI/Swagger spec or Postman collection/environment to scan for secrets
   -entropy-only
         Use only entropy-based detection
   -mask
         Mask secrets in output (default: true)
+  -strings
+        Scan the printable strings of binary input and report byte offsets
+  -format string
+        Output format: text, csaf, csv, github, gitlab-sast, junit, or
+        markdown (default: text); all but text report the findings of every
+        scanned file as one document
+  -config string
+        YAML configuration file (default: .secret-scanner.yaml in the current
+        directory, if present); flags override its settings
+  -workers int
+        Number of concurrent scan workers (default: 4)
+  -fail-on string
+        Exit with status 1 only on findings of this severity or above:
+        critical, high, medium, or low (default: any finding)
+  -min-severity string
+        Drop findings below this severity: critical, high, medium, or low
+  -min-confidence float
+        Drop findings with a confidence below this, from 0 to 1, as in 0.7
+  -keyword-window int
+        Bytes on each side of a match searched for the context keywords of
+        its rule (default: ` + strconv.Itoa(scanner.DefaultKeywordWindow) + `)
+  -secure
+        Never keep raw secret values in memory or output
+  -fingerprint-salt string
+        Salt for secret fingerprints
+  -group
+        Report each distinct secret once with its occurrences
+  -context int
+        Lines of context to show around each finding
+  -decode-base64
+        Also scan the decoded content of base64 substrings
+  -decode string
+        Comma-separated decoders whose output is also scanned: base64, gzip, url, json
+  -decode-depth int
+        Layers of nested encoding to decode (default: 3)
+  -unescape
+        Also match against the text with string escapes such as \x41 and \n resolved
+  -normalize
+        Also match against the text with full-width and look-alike characters replaced and zero-width characters removed
+  -trace
+        Report every copy of each secret, raw or encoded, across the scanned files
+  -exclude pattern
+        Skip paths under -dir matching a gitignore-style pattern, as in vendor/
+        or *.min.js; repeatable, and applied after the tree's .secretignore
+  -max-size string
+        Skip files larger than this, as in 512KB or 10MB (default: no limit)
+  -timeout duration
+        Stop scanning any single file after this long, as in 30s (default: no limit)
+  -patterns-file string
+        YAML or JSON file of custom rules with regex, severity, keywords, and
+        entropy threshold, or a gitleaks .toml config, added to the built-in
+        patterns
+  -replace-patterns
+        Use only the rules of -patterns-file instead of the built-in patterns
   -passwords
         Enable password detection (default: true)
   -apikeys
         Enable API key detection (default: true)
   -privatekeys
         Enable private key detection (default: true)
+  -version
+        Show version and embedded rule packs
   -help
         Show this help message
 
@@ -182,6 +1363,36 @@ Examples:
   # Scan a file
   secret-scanner -file config.json
 
+  # Scan the printable strings of a release binary, by ELF/PE section
+  secret-scanner -file dist/app -strings
+
+  # Scan a directory tree, scanning files with identical content once
+  secret-scanner -dir ./repo
+
+  # Scan a repository, skipping vendored code and minified bundles on top of
+  # the paths in its .secretignore
+  secret-scanner -dir ./repo -exclude vendor/ -exclude '*.min.js'
+
+  # Scan a repository, skipping files over 10MB or taking over 30s
+  secret-scanner -dir ./repo -max-size 10MB -timeout 30s
+
+  # Scan the files of a release bundle, including nested archives
+  secret-scanner -file dist/release.tar.gz
+
+  # Scan the layers of a container image, pulled or saved with docker save
+  secret-scanner image ghcr.io/org/app:1.4
+  secret-scanner image app.tar
+
+  # Scan with custom rules in addition to the built-in patterns
+  secret-scanner -dir ./repo -patterns-file rules.yaml
+
+  # List the rules that run, and show the pattern and examples of one
+  secret-scanner patterns list
+  secret-scanner patterns describe aws_access_key
+
+  # Check what a custom rule matches while writing it
+  secret-scanner patterns test -rule rules.yaml -text "token: itk_a8F3kQ9zLm2Xp7Rw"
+
   # Scan without password detection
   secret-scanner -file config.json -passwords=false
 
@@ -191,11 +1402,52 @@ Examples:
   # Scan text directly without masking
   secret-scanner -text "api_key=1234567890abcdef" -mask=false
 
+  # Scan the resources and config files of a mobile app
+  secret-scanner -app app-release.apk
+
+  # Scan the filesystem of a firmware image
+  secret-scanner -firmware initramfs.cpio.gz
+
+  # Scan credential-prone keys of an offline registry hive
+  secret-scanner -registry Windows/System32/config/SOFTWARE
+
+  # Scan a browser extension, including original sources from its source maps
+  secret-scanner -extension extension.crx
+
+  # Scan example values and auth sections of a Postman collection
+  secret-scanner -apispec api.postman_collection.json
+
+  # Fail CI only on critical findings, while still printing the rest
+  secret-scanner -dir ./repo -fail-on critical
+
+  # Export findings as a CSAF VEX advisory
+  secret-scanner -file config.json -format csaf
+
+  # Export the findings of a repository as CSV for triage in a spreadsheet
+  secret-scanner -dir ./repo -format csv > findings.csv
+
+  # Annotate the lines of a pull request with findings in GitHub Actions
+  secret-scanner -dir . -format github
+
+  # Show findings in the security widget of GitLab merge requests
+  secret-scanner -dir . -format gitlab-sast > gl-sast-report.json
+
+  # Report findings as JUnit test failures for Jenkins
+  secret-scanner -dir ./repo -format junit > secrets.xml
+
+  # Summarize findings as Markdown tables for a pull request comment
+  secret-scanner -dir ./repo -format markdown > secrets.md
+
   # Scan from stdin
   cat config.json | secret-scanner
 
   # Use only entropy-based detection
   secret-scanner -entropy-only -file config.json
 
+Exit status:
+  0 when nothing is reported, 1 when a finding at or above the -fail-on
+  severity is reported (any finding by default), and 2 when the scan could not
+  run
+
 Note: Boolean flags require the '=' operator, e.g., -mask=false instead of -mask false`)
 }
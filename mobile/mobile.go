@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mobile extracts scannable text from Android (APK) and iOS (IPA) app archives
+package mobile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxEntrySize bounds how much of a single archive entry is read
+const maxEntrySize = 10 * 1024 * 1024
+
+// File is the text extracted from a single archive entry
+type File struct {
+	Path    string
+	Content string
+	// Err is why the entry could not be read, such as an unsupported
+	// compression method or a corrupt entry; such files have no Content
+	Err error
+}
+
+// textExtensions lists configuration formats that are scanned as-is
+var textExtensions = map[string]bool{
+	".json":       true,
+	".xml":        true,
+	".properties": true,
+	".yaml":       true,
+	".yml":        true,
+	".txt":        true,
+	".cfg":        true,
+	".conf":       true,
+	".ini":        true,
+	".js":         true,
+	".html":       true,
+	".plist":      true,
+	".strings":    true,
+}
+
+// ExtractFile opens the APK or IPA at path and extracts its string resources,
+// plists, and embedded configuration files. Entries that cannot be read are
+// returned with Err set rather than failing the extraction.
+func ExtractFile(path string) ([]File, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open app archive: %w", err)
+	}
+	defer r.Close()
+
+	return extract(&r.Reader)
+}
+
+// Extract reads an APK or IPA archive of the given size from r
+func Extract(r io.ReaderAt, size int64) ([]File, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open app archive: %w", err)
+	}
+	return extract(zr)
+}
+
+func extract(zr *zip.Reader) ([]File, error) {
+	var files []File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isCandidate(f.Name) {
+			continue
+		}
+
+		data, err := readEntry(f)
+		if err != nil {
+			files = append(files, File{Path: f.Name, Err: err})
+			continue
+		}
+
+		content := decode(f.Name, data)
+		if content == "" {
+			continue
+		}
+		files = append(files, File{Path: f.Name, Content: content})
+	}
+	return files, nil
+}
+
+func isCandidate(name string) bool {
+	if path.Base(name) == "resources.arsc" {
+		return true
+	}
+	return textExtensions[strings.ToLower(path.Ext(name))]
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(io.LimitReader(rc, maxEntrySize))
+}
+
+// decode converts an archive entry into text, handling the binary formats used
+// by compiled apps
+func decode(name string, data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("bplist")):
+		return strings.Join(printableStrings(data, 4), "\n")
+	case isResourceChunk(data):
+		strs, err := stringPools(data)
+		if err != nil {
+			return ""
+		}
+		return strings.Join(strs, "\n")
+	case bytes.IndexByte(data, 0) >= 0:
+		// Some other binary file with a text extension
+		return ""
+	default:
+		return string(data)
+	}
+}
+
+// Android resource chunk types
+const (
+	chunkStringPool = 0x0001
+	chunkTable      = 0x0002
+	chunkXML        = 0x0003
+	chunkPackage    = 0x0200
+
+	stringPoolUTF8 = 1 << 8
+)
+
+// maxChunkDepth bounds the nesting of resource chunks; real files nest a
+// package in a table, and no deeper
+const maxChunkDepth = 8
+
+var (
+	errTruncated = errors.New("truncated resource chunk")
+	errTooDeep   = errors.New("resource chunks nested too deeply")
+)
+
+func isResourceChunk(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+	typ := binary.LittleEndian.Uint16(data)
+	return typ == chunkTable || typ == chunkXML
+}
+
+// stringPools walks the chunks of a compiled resource table or binary XML file
+// and returns the contents of every string pool found
+func stringPools(data []byte) ([]string, error) {
+	return walkChunks(data, 0)
+}
+
+// walkChunks returns the contents of the string pools among chunks nested
+// depth levels deep. A chunk header is at least 8 bytes, so that every nested
+// walk covers fewer bytes than its parent.
+func walkChunks(data []byte, depth int) ([]string, error) {
+	if depth > maxChunkDepth {
+		return nil, errTooDeep
+	}
+	var strs []string
+	for off := 0; off+8 <= len(data); {
+		typ := binary.LittleEndian.Uint16(data[off:])
+		headerSize := int(binary.LittleEndian.Uint16(data[off+2:]))
+		size := int(binary.LittleEndian.Uint32(data[off+4:]))
+		if headerSize < 8 || headerSize > size || size > len(data)-off {
+			return strs, errTruncated
+		}
+		chunk := data[off : off+size]
+
+		switch typ {
+		case chunkStringPool:
+			pool, err := parseStringPool(chunk)
+			if err != nil {
+				return strs, err
+			}
+			strs = append(strs, pool...)
+		case chunkTable, chunkXML, chunkPackage:
+			inner, err := walkChunks(chunk[headerSize:], depth+1)
+			strs = append(strs, inner...)
+			if err != nil {
+				return strs, err
+			}
+		}
+		off += size
+	}
+	return strs, nil
+}
+
+func parseStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errTruncated
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	if headerSize+4*count > len(chunk) || stringsStart > len(chunk) {
+		return nil, errTruncated
+	}
+
+	strs := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		off := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+4*i:]))
+		if off >= len(chunk) {
+			return strs, errTruncated
+		}
+
+		var s string
+		var err error
+		if flags&stringPoolUTF8 != 0 {
+			s, err = decodeUTF8String(chunk[off:])
+		} else {
+			s, err = decodeUTF16String(chunk[off:])
+		}
+		if err != nil {
+			return strs, err
+		}
+		if s != "" {
+			strs = append(strs, s)
+		}
+	}
+	return strs, nil
+}
+
+// decodeUTF8String reads a string pool entry prefixed with its UTF-16 and UTF-8 lengths
+func decodeUTF8String(b []byte) (string, error) {
+	_, n := utf8Length(b)
+	if n == 0 {
+		return "", errTruncated
+	}
+	length, m := utf8Length(b[n:])
+	if m == 0 || n+m+length > len(b) {
+		return "", errTruncated
+	}
+	return string(b[n+m : n+m+length]), nil
+}
+
+func utf8Length(b []byte) (int, int) {
+	if len(b) < 1 {
+		return 0, 0
+	}
+	if b[0]&0x80 == 0 {
+		return int(b[0]), 1
+	}
+	if len(b) < 2 {
+		return 0, 0
+	}
+	return int(b[0]&0x7f)<<8 | int(b[1]), 2
+}
+
+// decodeUTF16String reads a string pool entry prefixed with its UTF-16 length
+func decodeUTF16String(b []byte) (string, error) {
+	if len(b) < 2 {
+		return "", errTruncated
+	}
+	length := int(binary.LittleEndian.Uint16(b))
+	n := 2
+	if length&0x8000 != 0 {
+		if len(b) < 4 {
+			return "", errTruncated
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(b[2:]))
+		n = 4
+	}
+	if n+2*length > len(b) {
+		return "", errTruncated
+	}
+
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[n+2*i:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// printableStrings returns runs of printable ASCII of at least minLen bytes
+func printableStrings(data []byte, minLen int) []string {
+	var strs []string
+	start := -1
+	for i, c := range data {
+		if c >= 0x20 && c < 0x7f {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minLen {
+			strs = append(strs, string(data[start:i]))
+		}
+		start = -1
+	}
+	if start >= 0 && len(data)-start >= minLen {
+		strs = append(strs, string(data[start:]))
+	}
+	return strs
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package mobile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+const testKey = "AIzaSyC93b6FxR4r4Q1jIxyz789example12"
+
+// stringPoolChunk builds a compiled resource string pool holding strs
+func stringPoolChunk(strs []string, utf8 bool) []byte {
+	var data bytes.Buffer
+	offsets := make([]uint32, len(strs))
+	for i, s := range strs {
+		offsets[i] = uint32(data.Len())
+		if utf8 {
+			data.WriteByte(byte(len(s)))
+			data.WriteByte(byte(len(s)))
+			data.WriteString(s)
+			data.WriteByte(0)
+			continue
+		}
+		units := utf16.Encode([]rune(s))
+		binary.Write(&data, binary.LittleEndian, uint16(len(units)))
+		binary.Write(&data, binary.LittleEndian, units)
+		binary.Write(&data, binary.LittleEndian, uint16(0))
+	}
+
+	const headerSize = 28
+	stringsStart := headerSize + 4*len(strs)
+	var flags uint32
+	if utf8 {
+		flags = stringPoolUTF8
+	}
+
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.LittleEndian, uint16(chunkStringPool))
+	binary.Write(&chunk, binary.LittleEndian, uint16(headerSize))
+	binary.Write(&chunk, binary.LittleEndian, uint32(stringsStart+data.Len()))
+	binary.Write(&chunk, binary.LittleEndian, uint32(len(strs)))
+	binary.Write(&chunk, binary.LittleEndian, uint32(0))
+	binary.Write(&chunk, binary.LittleEndian, flags)
+	binary.Write(&chunk, binary.LittleEndian, uint32(stringsStart))
+	binary.Write(&chunk, binary.LittleEndian, uint32(0))
+	binary.Write(&chunk, binary.LittleEndian, offsets)
+	chunk.Write(data.Bytes())
+	return chunk.Bytes()
+}
+
+// wrapChunk wraps inner chunks in a container chunk of the given type
+func wrapChunk(typ uint16, inner []byte) []byte {
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.LittleEndian, typ)
+	binary.Write(&chunk, binary.LittleEndian, uint16(8))
+	binary.Write(&chunk, binary.LittleEndian, uint32(8+len(inner)))
+	chunk.Write(inner)
+	return chunk.Bytes()
+}
+
+func buildArchive(t *testing.T, entries map[string][]byte) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range entries {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		if _, err := f.Write(content); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  map[string][]byte
+		wantPath string
+	}{
+		{
+			name: "APK resource table",
+			entries: map[string][]byte{
+				"resources.arsc": wrapChunk(chunkTable, stringPoolChunk([]string{"app_name", testKey}, true)),
+			},
+			wantPath: "resources.arsc",
+		},
+		{
+			name: "APK binary manifest",
+			entries: map[string][]byte{
+				"AndroidManifest.xml": wrapChunk(chunkXML, stringPoolChunk([]string{"com.google.android.geo.API_KEY", testKey}, false)),
+			},
+			wantPath: "AndroidManifest.xml",
+		},
+		{
+			name: "APK asset config",
+			entries: map[string][]byte{
+				"assets/config.json": []byte(`{"maps_key": "` + testKey + `"}`),
+			},
+			wantPath: "assets/config.json",
+		},
+		{
+			name: "IPA binary plist",
+			entries: map[string][]byte{
+				"Payload/App.app/GoogleService-Info.plist": append([]byte("bplist00\xd1\x01\x02\x5f\x10\x23"), testKey...),
+			},
+			wantPath: "Payload/App.app/GoogleService-Info.plist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Binary files without a known extension are ignored
+			tt.entries["classes.dex"] = []byte("dex\n035\x00" + testKey)
+
+			r := buildArchive(t, tt.entries)
+			files, err := Extract(r, r.Size())
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+
+			if len(files) != 1 {
+				t.Fatalf("Extract() returned %d files, want 1", len(files))
+			}
+			if files[0].Path != tt.wantPath {
+				t.Errorf("Extract() path = %s, want %s", files[0].Path, tt.wantPath)
+			}
+			if !strings.Contains(files[0].Content, testKey) {
+				t.Errorf("Extract() content %q does not contain the key", files[0].Content)
+			}
+		})
+	}
+}
+
+func TestStringPoolsTruncated(t *testing.T) {
+	chunk := wrapChunk(chunkTable, stringPoolChunk([]string{testKey}, true))
+	if _, err := stringPools(chunk[:len(chunk)-10]); err == nil {
+		t.Error("Expected error for truncated chunk, got nil")
+	}
+}
+
+func TestStringPoolsMalformed(t *testing.T) {
+	nested := stringPoolChunk([]string{testKey}, true)
+	for i := 0; i <= maxChunkDepth+1; i++ {
+		nested = wrapChunk(chunkTable, nested)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		// A header size of 0 would make the table its own first child
+		{name: "Empty header", data: []byte{0x02, 0, 0, 0, 8, 0, 0, 0}},
+		{name: "Header larger than chunk", data: []byte{0x02, 0, 16, 0, 8, 0, 0, 0}},
+		{name: "Size beyond data", data: []byte{0x02, 0, 8, 0, 0xff, 0xff, 0xff, 0xff}},
+		{name: "Nested too deeply", data: nested},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := stringPools(tt.data); err == nil {
+				t.Error("Expected error for malformed chunk, got nil")
+			}
+		})
+	}
+}
+
+func TestExtractUnreadableEntry(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"assets/broken.json", "assets/config.json"} {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		if _, err := f.Write([]byte(`{"maps_key": "` + testKey + `"}`)); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	// Corrupt the first entry so that its checksum fails
+	data := buf.Bytes()
+	data[bytes.Index(data, []byte("maps_key"))] = 'M'
+
+	files, err := Extract(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Extract() returned %d files, want 2", len(files))
+	}
+	if files[0].Path != "assets/broken.json" || !errors.Is(files[0].Err, zip.ErrChecksum) {
+		t.Errorf("Extract()[0] = %s with error %v, want assets/broken.json with a checksum error", files[0].Path, files[0].Err)
+	}
+	if files[1].Err != nil || !strings.Contains(files[1].Content, testKey) {
+		t.Errorf("Extract()[1] = %+v, want assets/config.json with the key", files[1])
+	}
+}
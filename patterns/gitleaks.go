@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package patterns
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// LoadGitleaksConfig reads a gitleaks .toml configuration and converts its
+// rules, with their keywords, entropy thresholds, and allowlist regexes and
+// stopwords, to scanner rules. The secretGroup of a rule becomes its secret
+// group, and without one the first capture group is the secret as in
+// gitleaks. The global allowlist applies to every rule. Rules with only a path
+// and allowlists of paths or commits, which do not apply to text, are skipped.
+func LoadGitleaksConfig(path string) ([]scanner.Rule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gitleaks config: %w", err)
+	}
+	return ParseGitleaksConfig(content)
+}
+
+// ParseGitleaksConfig parses the content of a gitleaks configuration; see
+// LoadGitleaksConfig
+func ParseGitleaksConfig(content []byte) ([]scanner.Rule, error) {
+	doc, err := parseTOML(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse gitleaks config: %w", err)
+	}
+
+	var global gitleaksAllowlist
+	for _, table := range tables(doc, "allowlist", "allowlists") {
+		global.add(table)
+	}
+
+	var rules []scanner.Rule
+	raw, _ := doc["rules"].([]map[string]any)
+	for i, r := range raw {
+		id := tomlString(r, "id")
+		if id == "" {
+			return nil, fmt.Errorf("gitleaks rule %d has no id", i+1)
+		}
+		pattern := tomlString(r, "regex")
+		if pattern == "" {
+			continue
+		}
+		group, _ := r["secretGroup"].(int64)
+		pattern, err := withSecretGroup(pattern, int(group))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gitleaks rule %s: %w", id, err)
+		}
+
+		allow := global
+		for _, table := range tables(r, "allowlist", "allowlists") {
+			allow.add(table)
+		}
+		rule := scanner.Rule{
+			Name:           id,
+			Pattern:        pattern,
+			Description:    tomlString(r, "description"),
+			Keywords:       tomlStrings(r, "keywords"),
+			MinEntropy:     tomlFloat(r, "entropy"),
+			Allowlist:      allow.secret,
+			MatchAllowlist: allow.match,
+			Stopwords:      allow.stopwords,
+		}
+		for _, re := range append(append([]string{}, rule.Allowlist...), rule.MatchAllowlist...) {
+			if _, err := regexp.Compile(re); err != nil {
+				return nil, fmt.Errorf("invalid gitleaks allowlist of %s: %w", id, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// gitleaksAllowlist collects the parts of gitleaks allowlists that apply to
+// text
+type gitleaksAllowlist struct {
+	secret    []string
+	match     []string
+	stopwords []string
+}
+
+// add adds an allowlist table, unless it only applies together with paths or
+// commits, which text does not have
+func (a *gitleaksAllowlist) add(table map[string]any) {
+	regexes, stopwords := tomlStrings(table, "regexes"), tomlStrings(table, "stopwords")
+	if strings.EqualFold(tomlString(table, "condition"), "AND") {
+		kinds := 0
+		for _, key := range []string{"regexes", "stopwords", "paths", "commits"} {
+			if len(tomlStrings(table, key)) > 0 {
+				kinds++
+			}
+		}
+		if kinds > 1 {
+			return
+		}
+	}
+	// Copy so that rules do not share the global allowlist's arrays
+	if target := tomlString(table, "regexTarget"); target == "match" || target == "line" {
+		a.match = append(a.match[:len(a.match):len(a.match)], regexes...)
+	} else {
+		a.secret = append(a.secret[:len(a.secret):len(a.secret)], regexes...)
+	}
+	a.stopwords = append(a.stopwords[:len(a.stopwords):len(a.stopwords)], stopwords...)
+}
+
+// withSecretGroup names capture group n of pattern, or its first if n is
+// zero, as the scanner's secret group
+func withSecretGroup(pattern string, n int) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	groups := re.MaxCap()
+	if n == 0 {
+		if groups == 0 {
+			return pattern, nil
+		}
+		n = 1
+	}
+	if n > groups {
+		return "", fmt.Errorf("secretGroup %d of a pattern with %d groups", n, groups)
+	}
+
+	// Find the opening parenthesis of the group outside escapes and classes
+	count := 0
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			// A ] right after [ or [^ is a literal
+			if strings.HasPrefix(pattern[i+1:], "^]") {
+				i += 2
+			} else if strings.HasPrefix(pattern[i+1:], "]") {
+				i++
+			}
+		case c == '(':
+			rest := pattern[i+1:]
+			named := strings.HasPrefix(rest, "?P<") || strings.HasPrefix(rest, "?<")
+			if strings.HasPrefix(rest, "?") && !named {
+				continue
+			}
+			if count++; count != n {
+				continue
+			}
+			if named {
+				end := strings.IndexByte(rest, '>')
+				return pattern[:i+1] + "?P<" + scanner.SecretGroup + ">" + rest[end+1:], nil
+			}
+			return pattern[:i+1] + "?P<" + scanner.SecretGroup + ">" + rest, nil
+		}
+	}
+	return "", fmt.Errorf("secretGroup %d not found", n)
+}
+
+// tables returns the tables at any of keys of table, whether single tables or
+// arrays of tables
+func tables(table map[string]any, keys ...string) []map[string]any {
+	var found []map[string]any
+	for _, key := range keys {
+		switch v := table[key].(type) {
+		case map[string]any:
+			found = append(found, v)
+		case []map[string]any:
+			found = append(found, v...)
+		}
+	}
+	return found
+}
+
+func tomlString(table map[string]any, key string) string {
+	s, _ := table[key].(string)
+	return s
+}
+
+func tomlStrings(table map[string]any, key string) []string {
+	values, _ := table[key].([]any)
+	var strs []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func tomlFloat(table map[string]any, key string) float64 {
+	switch v := table[key].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,142 @@
+package patterns
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+const gitleaksConfig = `title = "custom gitleaks config"
+
+[allowlist]
+description = "global allow list"
+stopwords = ["example"]
+paths = ['''vendor/''']
+
+[[rules]]
+id = "internal-token"
+description = "Internal service token"
+regex = '''(?i)\b(itk_[a-z0-9]{16})\b'''
+keywords = ["itk_"]
+entropy = 3
+
+[[rules]]
+id = "build-secret"
+regex = '''(build)[_-]?secret\s*=\s*['"]([a-f0-9]{20})['"]'''
+secretGroup = 2
+  [[rules.allowlists]]
+  regexTarget = "match"
+  regexes = ['''= "0{20}"''']
+  [[rules.allowlists]]
+  condition = "AND"
+  regexes = ['''^0+$''']
+  paths = ['''fixtures/''']
+
+[[rules]]
+id = "pkcs12-file"
+path = '''\.p12$'''
+`
+
+func TestLoadGitleaksConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gitleaks.toml")
+	if err := os.WriteFile(path, []byte(gitleaksConfig), 0o600); err != nil {
+		t.Fatalf("Failed to write gitleaks config: %v", err)
+	}
+
+	rules, err := LoadGitleaksConfig(path)
+	if err != nil {
+		t.Fatalf("LoadGitleaksConfig failed: %v", err)
+	}
+	want := []scanner.Rule{
+		{
+			Name:        "internal-token",
+			Pattern:     `(?i)\b(?P<secret>itk_[a-z0-9]{16})\b`,
+			Description: "Internal service token",
+			Keywords:    []string{"itk_"},
+			MinEntropy:  3,
+			Stopwords:   []string{"example"},
+		},
+		{
+			Name:           "build-secret",
+			Pattern:        `(build)[_-]?secret\s*=\s*['"](?P<secret>[a-f0-9]{20})['"]`,
+			MatchAllowlist: []string{`= "0{20}"`},
+			Stopwords:      []string{"example"},
+		},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("LoadGitleaksConfig() = %+v, want %+v", rules, want)
+	}
+}
+
+func TestGitleaksRulesScan(t *testing.T) {
+	rules, err := ParseGitleaksConfig([]byte(gitleaksConfig))
+	if err != nil {
+		t.Fatalf("ParseGitleaksConfig failed: %v", err)
+	}
+	s := scanner.New()
+	if err := s.ReplacePatterns(nil); err != nil {
+		t.Fatalf("Failed to clear patterns: %v", err)
+	}
+	for _, rule := range rules {
+		if err := s.AddRule(rule); err != nil {
+			t.Fatalf("AddRule failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "Secret group", text: `build_secret = "0123456789abcdef0123"`, want: "0123456789abcdef0123"},
+		{name: "Match allowlist", text: `build_secret = "00000000000000000000"`},
+		{name: "Global stopword", text: "token: itk_example9f8e7d6c5b"},
+		{name: "First group", text: "token: ITK_a8f3kq9zlm2xp7rw", want: "ITK_a8f3kq9zlm2xp7rw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := s.Scan(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			var got string
+			if len(results) > 0 {
+				got = results[0].Value
+			}
+			if len(results) > 1 || got != tt.want {
+				t.Errorf("Scan() = %+v, want %q", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSecretGroup(t *testing.T) {
+	tests := []struct {
+		pattern string
+		group   int
+		want    string
+		wantErr bool
+	}{
+		{pattern: `key=[a-z]+`, want: `key=[a-z]+`},
+		{pattern: `(?:key)=([a-z]+)`, want: `(?:key)=(?P<secret>[a-z]+)`},
+		{pattern: `[(]x\(([a-z]+)`, want: `[(]x\((?P<secret>[a-z]+)`},
+		{pattern: `(?P<name>a)(?P<value>b)`, group: 2, want: `(?P<name>a)(?P<secret>b)`},
+		{pattern: `(a)`, group: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := withSecretGroup(tt.pattern, tt.group)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("withSecretGroup(%q, %d) error = %v, wantErr %v", tt.pattern, tt.group, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("withSecretGroup(%q, %d) = %q, want %q", tt.pattern, tt.group, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package patterns
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// fileRule is a rule as written in a pattern file
+type fileRule struct {
+	Name        string   `yaml:"name"`
+	ID          string   `yaml:"id"`
+	Regex       string   `yaml:"regex"`
+	Severity    string   `yaml:"severity"`
+	Description string   `yaml:"description"`
+	Keywords    []string `yaml:"keywords"`
+	Entropy     float64  `yaml:"entropy"`
+}
+
+// LoadFile reads a pattern file and returns its patterns by name, without
+// their metadata; see LoadRules for the formats it accepts
+func LoadFile(path string) (map[string]string, error) {
+	rules, err := LoadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	patterns := make(map[string]string, len(rules))
+	for _, r := range rules {
+		patterns[r.Name] = r.Pattern
+	}
+	return patterns, nil
+}
+
+// LoadRules reads a YAML or JSON pattern file, either an object that maps
+// pattern names to regular expressions or a list of rules with metadata:
+//
+//	rules:
+//	  - name: internal_token
+//	    id: ACME-TOKEN-001
+//	    regex: 'itk_[A-Za-z0-9]{32}'
+//	    severity: high
+//	    description: Internal service token
+//	    keywords: [itk_]
+//	    entropy: 3.5
+//
+// Every pattern is compiled so that an invalid file is rejected as a whole.
+func LoadRules(path string) ([]scanner.Rule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern file: %w", err)
+	}
+	return ParseRules(content)
+}
+
+// ParseRules parses the content of a pattern file; see LoadRules
+func ParseRules(content []byte) ([]scanner.Rule, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse pattern file: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse pattern file: not an object")
+	}
+	root := doc.Content[0]
+
+	var rules []fileRule
+	if len(root.Content) == 2 && root.Content[0].Value == "rules" && root.Content[1].Kind == yaml.SequenceNode {
+		if err := root.Content[1].Decode(&rules); err != nil {
+			return nil, fmt.Errorf("failed to parse pattern file: %w", err)
+		}
+	} else {
+		var patterns map[string]string
+		if err := root.Decode(&patterns); err != nil {
+			return nil, fmt.Errorf("failed to parse pattern file: %w", err)
+		}
+		for name, pattern := range patterns {
+			rules = append(rules, fileRule{Name: name, Regex: pattern})
+		}
+	}
+
+	parsed := make([]scanner.Rule, 0, len(rules))
+	seen := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i+1)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate rule %s", r.Name)
+		}
+		seen[r.Name] = true
+		if _, err := regexp.Compile(r.Regex); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", r.Name, err)
+		}
+		rule := scanner.Rule{
+			Name:        r.Name,
+			ID:          r.ID,
+			Pattern:     r.Regex,
+			Description: r.Description,
+			Keywords:    r.Keywords,
+			MinEntropy:  r.Entropy,
+		}
+		if r.Severity != "" {
+			severity, err := scanner.ParseSeverity(r.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %s: %w", r.Name, err)
+			}
+			rule.Severity = severity
+		}
+		if r.Entropy < 0 {
+			return nil, fmt.Errorf("invalid rule %s: negative entropy", r.Name)
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
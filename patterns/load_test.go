@@ -0,0 +1,113 @@
+package patterns
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "Valid patterns",
+			content: `{"aws_access_key": "AKIA[0-9A-Z]{16}", "custom": "secret[0-9]+"}`,
+			want:    2,
+		},
+		{
+			name:    "Invalid regex",
+			content: `{"broken": "["}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid JSON",
+			content: `not json`,
+			wantErr: true,
+		},
+		{
+			name: "YAML rules",
+			content: `rules:
+  - name: internal_token
+    regex: 'itk_[A-Za-z0-9]{32}'
+    severity: high
+    keywords: [itk_]
+    entropy: 3.5
+  - name: build_secret
+    regex: 'bld-[0-9a-f]{40}'
+`,
+			want: 2,
+		},
+		{
+			name:    "Unknown severity",
+			content: "rules:\n  - name: a\n    regex: x\n    severity: urgent\n",
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate rule",
+			content: "rules:\n  - name: a\n    regex: x\n  - name: a\n    regex: y\n",
+			wantErr: true,
+		},
+		{
+			name:    "Rule without name",
+			content: "rules:\n  - regex: x\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "patterns.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write pattern file: %v", err)
+			}
+
+			got, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("LoadFile() returned %d patterns, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `rules:
+  - name: internal_token
+    id: ACME-TOKEN-001
+    regex: 'itk_[A-Za-z0-9]{32}'
+    severity: high
+    description: Internal service token
+    keywords: [itk_]
+    entropy: 3.5
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write pattern file: %v", err)
+	}
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	want := []scanner.Rule{{
+		Name:        "internal_token",
+		ID:          "ACME-TOKEN-001",
+		Pattern:     "itk_[A-Za-z0-9]{32}",
+		Severity:    scanner.SeverityHigh,
+		Description: "Internal service token",
+		Keywords:    []string{"itk_"},
+		MinEntropy:  3.5,
+	}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("LoadRules() = %+v, want %+v", rules, want)
+	}
+}
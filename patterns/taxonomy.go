@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package patterns
+
+// Credential classes of the taxonomy
+const (
+	ClassAPIKey       = "api_key"
+	ClassAccessToken  = "access_token"
+	ClassClientSecret = "client_secret"
+	ClassPassword     = "password"
+	ClassPrivateKey   = "private_key"
+	ClassCertificate  = "certificate"
+	ClassGeneric      = "generic"
+)
+
+// Persistence values of the taxonomy
+const (
+	// PersistenceLongLived credentials stay valid until revoked
+	PersistenceLongLived = "long_lived"
+	// PersistenceShortLived credentials expire on their own within hours
+	PersistenceShortLived = "short_lived"
+	PersistenceUnknown    = "unknown"
+)
+
+// MITRE ATT&CK credential access techniques
+const (
+	AttackCredentialsInFiles = "T1552.001" // Unsecured Credentials: Credentials In Files
+	AttackPrivateKeys        = "T1552.004" // Unsecured Credentials: Private Keys
+	AttackCloudAccounts      = "T1078.004" // Valid Accounts: Cloud Accounts
+	AttackAppAccessToken     = "T1550.001" // Use Alternate Authentication Material: Application Access Token
+)
+
+// Taxonomy classifies the secret a pattern detects and maps it to external
+// frameworks for downstream risk tooling
+type Taxonomy struct {
+	Class       string   `json:"class"`
+	Provider    string   `json:"provider,omitempty"`
+	Persistence string   `json:"persistence"`
+	CWE         string   `json:"cwe"`
+	CWEName     string   `json:"cwe_name"`
+	Attack      []string `json:"attack"`
+}
+
+type classification struct {
+	class, provider, persistence string
+}
+
+// taxonomies classifies each built-in pattern by name
+var taxonomies = map[string]classification{
+	"aws_access_key":                   {ClassAPIKey, "AWS", PersistenceLongLived},
+	"aws_secret":                       {ClassAPIKey, "AWS", PersistenceLongLived},
+	"github_token":                     {ClassAccessToken, "GitHub", PersistenceLongLived},
+	"google_api":                       {ClassAPIKey, "Google Cloud", PersistenceLongLived},
+	"stripe_key":                       {ClassAPIKey, "Stripe", PersistenceLongLived},
+	"slack_token":                      {ClassAccessToken, "Slack", PersistenceLongLived},
+	"twitter_bearer_token":             {ClassAccessToken, "Twitter", PersistenceLongLived},
+	"facebook_access_token":            {ClassAccessToken, "Facebook", PersistenceShortLived},
+	"azure_storage_account_key":        {ClassAPIKey, "Azure", PersistenceLongLived},
+	"digitalocean_access_token":        {ClassAccessToken, "DigitalOcean", PersistenceLongLived},
+	"heroku_api_key":                   {ClassAPIKey, "Heroku", PersistenceLongLived},
+	"generic_api_key":                  {ClassAPIKey, "", PersistenceUnknown},
+	"sendgrid_api_key":                 {ClassAPIKey, "SendGrid", PersistenceLongLived},
+	"twilio_api_key":                   {ClassAPIKey, "Twilio", PersistenceLongLived},
+	"mailgun_api_key":                  {ClassAPIKey, "Mailgun", PersistenceLongLived},
+	"paypal_bearer_token":              {ClassAccessToken, "PayPal", PersistenceShortLived},
+	"firebase_api_key":                 {ClassAPIKey, "Firebase", PersistenceLongLived},
+	"square_access_token":              {ClassAccessToken, "Square", PersistenceLongLived},
+	"shopify_access_token":             {ClassAccessToken, "Shopify", PersistenceLongLived},
+	"pinterest_access_token":           {ClassAccessToken, "Pinterest", PersistenceLongLived},
+	"asana_personal_access_token":      {ClassAccessToken, "Asana", PersistenceLongLived},
+	"gitlab_personal_access_token":     {ClassAccessToken, "GitLab", PersistenceLongLived},
+	"dropbox_access_token":             {ClassAccessToken, "Dropbox", PersistenceShortLived},
+	"microsoft_graph_access_token":     {ClassAccessToken, "Microsoft", PersistenceShortLived},
+	"bitbucket_access_token":           {ClassAccessToken, "Bitbucket", PersistenceLongLived},
+	"huggingface_token":                {ClassAccessToken, "Hugging Face", PersistenceLongLived},
+	"openai_api_key":                   {ClassAPIKey, "OpenAI", PersistenceLongLived},
+	"openai_organization_id":           {ClassGeneric, "OpenAI", PersistenceLongLived},
+	"anthropic_api_key":                {ClassAPIKey, "Anthropic", PersistenceLongLived},
+	"npm_access_token":                 {ClassAccessToken, "npm", PersistenceLongLived},
+	"npm_legacy_token":                 {ClassAccessToken, "npm", PersistenceLongLived},
+	"pypi_upload_token":                {ClassAPIKey, "PyPI", PersistenceLongLived},
+	"terraform_cloud_token":            {ClassAccessToken, "Terraform Cloud", PersistenceLongLived},
+	"hcp_client_secret":                {ClassClientSecret, "HashiCorp", PersistenceLongLived},
+	"databricks_token":                 {ClassAccessToken, "Databricks", PersistenceLongLived},
+	"azure_ad_client_secret":           {ClassClientSecret, "Azure", PersistenceLongLived},
+	"azure_sas_token":                  {ClassAccessToken, "Azure", PersistenceUnknown},
+	"gcp_service_account":              {ClassPrivateKey, "Google Cloud", PersistenceLongLived},
+	"slack_webhook_url":                {ClassAccessToken, "Slack", PersistenceLongLived},
+	"discord_webhook_url":              {ClassAccessToken, "Discord", PersistenceLongLived},
+	"discord_bot_token":                {ClassAccessToken, "Discord", PersistenceLongLived},
+	"telegram_bot_token":               {ClassAccessToken, "Telegram", PersistenceLongLived},
+	"datadog_api_key":                  {ClassAPIKey, "Datadog", PersistenceLongLived},
+	"datadog_app_key":                  {ClassAPIKey, "Datadog", PersistenceLongLived},
+	"newrelic_user_api_key":            {ClassAPIKey, "New Relic", PersistenceLongLived},
+	"newrelic_insert_key":              {ClassAPIKey, "New Relic", PersistenceLongLived},
+	"newrelic_license_key":             {ClassAPIKey, "New Relic", PersistenceLongLived},
+	"cloudflare_api_token":             {ClassAccessToken, "Cloudflare", PersistenceLongLived},
+	"cloudflare_global_api_key":        {ClassAPIKey, "Cloudflare", PersistenceLongLived},
+	"okta_api_token":                   {ClassAccessToken, "Okta", PersistenceLongLived},
+	"auth0_client_secret":              {ClassClientSecret, "Auth0", PersistenceLongLived},
+	"auth0_management_token":           {ClassAccessToken, "Auth0", PersistenceShortLived},
+	"vault_token":                      {ClassAccessToken, "HashiCorp", PersistenceShortLived},
+	"vault_legacy_token":               {ClassAccessToken, "HashiCorp", PersistenceShortLived},
+	"planetscale_service_token":        {ClassAccessToken, "PlanetScale", PersistenceLongLived},
+	"planetscale_oauth_token":          {ClassAccessToken, "PlanetScale", PersistenceShortLived},
+	"postman_api_key":                  {ClassAPIKey, "Postman", PersistenceLongLived},
+	"netlify_access_token":             {ClassAccessToken, "Netlify", PersistenceLongLived},
+	"netlify_legacy_token":             {ClassAccessToken, "Netlify", PersistenceLongLived},
+	"vercel_token":                     {ClassAccessToken, "Vercel", PersistenceLongLived},
+	"github_fine_grained_pat":          {ClassAccessToken, "GitHub", PersistenceLongLived},
+	"github_app_installation_token":    {ClassAccessToken, "GitHub", PersistenceShortLived},
+	"gitlab_deploy_token":              {ClassAccessToken, "GitLab", PersistenceLongLived},
+	"gitlab_runner_registration_token": {ClassAccessToken, "GitLab", PersistenceLongLived},
+	"gitlab_ci_job_token":              {ClassAccessToken, "GitLab", PersistenceShortLived},
+	"gitlab_pipeline_trigger_token":    {ClassAccessToken, "GitLab", PersistenceLongLived},
+	"aws_temporary_access_key":         {ClassAccessToken, "AWS", PersistenceShortLived},
+	"aws_session_token":                {ClassAccessToken, "AWS", PersistenceShortLived},
+	"stripe_restricted_key":            {ClassAPIKey, "Stripe", PersistenceLongLived},
+	"stripe_webhook_secret":            {ClassClientSecret, "Stripe", PersistenceLongLived},
+	"stripe_test_key":                  {ClassAPIKey, "Stripe", PersistenceLongLived},
+	"mongodb_connection_string":        {ClassPassword, "MongoDB", PersistenceLongLived},
+	"redis_url":                        {ClassPassword, "Redis", PersistenceLongLived},
+	"amqp_url":                         {ClassPassword, "AMQP", PersistenceLongLived},
+	"basic_auth_url":                   {ClassPassword, "", PersistenceLongLived},
+	"smtp_password":                    {ClassPassword, "SMTP", PersistenceLongLived},
+	"smtp_url":                         {ClassPassword, "SMTP", PersistenceLongLived},
+	"msmtp_password":                   {ClassPassword, "SMTP", PersistenceLongLived},
+	"sendmail_authinfo":                {ClassPassword, "SMTP", PersistenceLongLived},
+	"basic_password":                   {ClassPassword, "", PersistenceLongLived},
+	"complex_password":                 {ClassPassword, "", PersistenceLongLived},
+	"rsa_private":                      {ClassPrivateKey, "", PersistenceLongLived},
+	"ssh_private":                      {ClassPrivateKey, "", PersistenceLongLived},
+	"pgp_private":                      {ClassPrivateKey, "", PersistenceLongLived},
+	"generic_private":                  {ClassPrivateKey, "", PersistenceLongLived},
+	"dsa_private":                      {ClassPrivateKey, "", PersistenceLongLived},
+	"ec_private":                       {ClassPrivateKey, "", PersistenceLongLived},
+	"putty_private":                    {ClassPrivateKey, "", PersistenceLongLived},
+	"jwt_private":                      {ClassPrivateKey, "", PersistenceLongLived},
+	"pkcs8_private":                    {ClassPrivateKey, "", PersistenceLongLived},
+	"pem_certificate":                  {ClassCertificate, "", PersistenceLongLived},
+	"pkcs12_private":                   {ClassPrivateKey, "", PersistenceLongLived},
+	"putty_ppk_private":                {ClassPrivateKey, "", PersistenceLongLived},
+	"cosign_private":                   {ClassPrivateKey, "Sigstore", PersistenceLongLived},
+	"sigstore_private":                 {ClassPrivateKey, "Sigstore", PersistenceLongLived},
+	"google_oauth_client_secret":       {ClassClientSecret, "Google Cloud", PersistenceLongLived},
+	"oauth_client_secret":              {ClassClientSecret, "", PersistenceLongLived},
+	"apollo_api_key":                   {ClassAPIKey, "Apollo", PersistenceLongLived},
+	"graphql_auth_header":              {ClassAccessToken, "", PersistenceUnknown},
+	"graphql_api_key_header":           {ClassAPIKey, "", PersistenceUnknown},
+	"graphql_argument_secret":          {ClassGeneric, "", PersistenceUnknown},
+}
+
+// cloudProviders are providers whose credentials grant access to cloud accounts
+var cloudProviders = map[string]bool{
+	"AWS":          true,
+	"Azure":        true,
+	"DigitalOcean": true,
+	"Google Cloud": true,
+	"Heroku":       true,
+}
+
+// Classify returns the taxonomy of a pattern. Patterns that are not built in
+// are classified as generic credentials of unknown persistence.
+func Classify(name string) Taxonomy {
+	c, ok := taxonomies[name]
+	if !ok {
+		c = classification{ClassGeneric, "", PersistenceUnknown}
+	}
+
+	t := Taxonomy{
+		Class:       c.class,
+		Provider:    c.provider,
+		Persistence: c.persistence,
+		CWE:         "CWE-798",
+		CWEName:     "Use of Hard-coded Credentials",
+		Attack:      []string{AttackCredentialsInFiles},
+	}
+	switch c.class {
+	case ClassPassword:
+		t.CWE, t.CWEName = "CWE-259", "Use of Hard-coded Password"
+	case ClassPrivateKey:
+		t.CWE, t.CWEName = "CWE-321", "Use of Hard-coded Cryptographic Key"
+		t.Attack = append(t.Attack, AttackPrivateKeys)
+	case ClassAccessToken:
+		t.Attack = append(t.Attack, AttackAppAccessToken)
+	}
+	if cloudProviders[c.provider] {
+		t.Attack = append(t.Attack, AttackCloudAccounts)
+	}
+	return t
+}
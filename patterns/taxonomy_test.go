@@ -0,0 +1,81 @@
+package patterns
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		want Taxonomy
+	}{
+		{
+			name: "aws_access_key",
+			want: Taxonomy{Class: ClassAPIKey, Provider: "AWS", Persistence: PersistenceLongLived, CWE: "CWE-798", CWEName: "Use of Hard-coded Credentials",
+				Attack: []string{AttackCredentialsInFiles, AttackCloudAccounts}},
+		},
+		{
+			name: "github_token",
+			want: Taxonomy{Class: ClassAccessToken, Provider: "GitHub", Persistence: PersistenceLongLived, CWE: "CWE-798", CWEName: "Use of Hard-coded Credentials",
+				Attack: []string{AttackCredentialsInFiles, AttackAppAccessToken}},
+		},
+		{
+			name: "basic_password",
+			want: Taxonomy{Class: ClassPassword, Persistence: PersistenceLongLived, CWE: "CWE-259", CWEName: "Use of Hard-coded Password",
+				Attack: []string{AttackCredentialsInFiles}},
+		},
+		{
+			name: "ssh_private",
+			want: Taxonomy{Class: ClassPrivateKey, Persistence: PersistenceLongLived, CWE: "CWE-321", CWEName: "Use of Hard-coded Cryptographic Key",
+				Attack: []string{AttackCredentialsInFiles, AttackPrivateKeys}},
+		},
+		{
+			name: "custom_rule",
+			want: Taxonomy{Class: ClassGeneric, Persistence: PersistenceUnknown, CWE: "CWE-798", CWEName: "Use of Hard-coded Credentials",
+				Attack: []string{AttackCredentialsInFiles}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Classify(%s) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEveryPatternIsClassified(t *testing.T) {
+	all := GetAllPatterns()
+	for name := range BrowserExtensionPatterns {
+		all[name] = BrowserExtensionPatterns[name]
+	}
+	for name := range all {
+		if _, ok := taxonomies[name]; !ok {
+			t.Errorf("Pattern %s has no taxonomy", name)
+		}
+	}
+}
+
+func TestEveryPatternHasRuleID(t *testing.T) {
+	all := GetAllPatterns()
+	for name := range BrowserExtensionPatterns {
+		all[name] = BrowserExtensionPatterns[name]
+	}
+	format := regexp.MustCompile(`^SSA-[A-Z0-9]+-[0-9]{3}$`)
+	seen := make(map[string]string)
+	for name := range all {
+		id := scanner.RuleID(name)
+		if !format.MatchString(id) {
+			t.Errorf("Pattern %s has rule ID %q, want SSA-<GROUP>-<NNN>", name, id)
+		}
+		if other, ok := seen[id]; ok {
+			t.Errorf("Patterns %s and %s share rule ID %s", name, other, id)
+		}
+		seen[id] = name
+	}
+}
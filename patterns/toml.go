@@ -0,0 +1,388 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package patterns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML parses the subset of TOML used by gitleaks configurations into
+// nested maps: tables, arrays of tables, dotted and quoted keys, strings of
+// all four kinds, integers, floats, booleans, arrays, and inline tables.
+// Dates are not supported.
+func parseTOML(text string) (map[string]any, error) {
+	p := &tomlParser{text: text, line: 1}
+	root := make(map[string]any)
+	current := root
+	for {
+		p.skipSpace(true)
+		if p.done() {
+			return root, nil
+		}
+		var err error
+		if p.peek() == '[' {
+			current, err = p.header(root)
+		} else {
+			err = p.keyValue(current)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+		p.skipSpace(false)
+		if !p.done() && p.peek() != '\n' {
+			return nil, fmt.Errorf("line %d: unexpected %q", p.line, p.peek())
+		}
+	}
+}
+
+type tomlParser struct {
+	text string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) done() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.text[p.pos]
+}
+
+func (p *tomlParser) next() byte {
+	c := p.text[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipSpace skips blanks and comments, and line breaks if newlines is set
+func (p *tomlParser) skipSpace(newlines bool) {
+	for !p.done() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.next()
+		case c == '\n' && newlines:
+			p.next()
+		case c == '#':
+			for !p.done() && p.peek() != '\n' {
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// header parses a [table] or [[array of tables]] header and returns the
+// table that the following keys belong to
+func (p *tomlParser) header(root map[string]any) (map[string]any, error) {
+	p.next()
+	array := !p.done() && p.peek() == '['
+	if array {
+		p.next()
+	}
+	p.skipSpace(false)
+	keys, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace(false)
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.text[p.pos:], closing) {
+		return nil, fmt.Errorf("unterminated table header")
+	}
+	p.pos += len(closing)
+
+	table, err := descend(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	if array {
+		tables, _ := table[last].([]map[string]any)
+		if _, exists := table[last]; exists && tables == nil {
+			return nil, fmt.Errorf("%s is not an array of tables", strings.Join(keys, "."))
+		}
+		next := make(map[string]any)
+		table[last] = append(tables, next)
+		return next, nil
+	}
+	return descend(table, []string{last})
+}
+
+// descend returns the table at keys below table, creating missing tables and
+// entering the last table of arrays of tables
+func descend(table map[string]any, keys []string) (map[string]any, error) {
+	for _, k := range keys {
+		switch v := table[k].(type) {
+		case nil:
+			next := make(map[string]any)
+			table[k] = next
+			table = next
+		case map[string]any:
+			table = v
+		case []map[string]any:
+			table = v[len(v)-1]
+		default:
+			return nil, fmt.Errorf("%s is not a table", k)
+		}
+	}
+	return table, nil
+}
+
+// key parses a possibly dotted key
+func (p *tomlParser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace(false)
+		if p.done() {
+			return nil, fmt.Errorf("missing key")
+		}
+		var k string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		default:
+			start := p.pos
+			for !p.done() && isBareKey(p.peek()) {
+				p.next()
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("invalid key at %q", c)
+			}
+			k = p.text[start:p.pos]
+		}
+		keys = append(keys, k)
+		p.skipSpace(false)
+		if p.done() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.next()
+	}
+}
+
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// keyValue parses key = value into table
+func (p *tomlParser) keyValue(table map[string]any) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	if p.done() || p.peek() != '=' {
+		return fmt.Errorf("expected = after %s", strings.Join(keys, "."))
+	}
+	p.next()
+	p.skipSpace(false)
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	table, err = descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := table[last]; exists {
+		return fmt.Errorf("duplicate key %s", strings.Join(keys, "."))
+	}
+	table[last] = value
+	return nil
+}
+
+func (p *tomlParser) value() (any, error) {
+	if p.done() {
+		return nil, fmt.Errorf("missing value")
+	}
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	}
+
+	start := p.pos
+	for !p.done() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.next()
+	}
+	word := p.text[start:p.pos]
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	number := strings.ReplaceAll(word, "_", "")
+	if n, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", word)
+}
+
+func (p *tomlParser) array() ([]any, error) {
+	p.next()
+	values := []any{}
+	for {
+		p.skipSpace(true)
+		if p.done() {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.next()
+			return values, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		p.skipSpace(true)
+		if !p.done() && p.peek() == ',' {
+			p.next()
+		} else if p.done() || p.peek() != ']' {
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) inlineTable() (map[string]any, error) {
+	p.next()
+	table := make(map[string]any)
+	for {
+		p.skipSpace(false)
+		if p.done() {
+			return nil, fmt.Errorf("unterminated inline table")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return table, nil
+		}
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace(false)
+		if !p.done() && p.peek() == ',' {
+			p.next()
+		} else if p.done() || p.peek() != '}' {
+			return nil, fmt.Errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// str parses a basic or literal string, single- or multi-line
+func (p *tomlParser) str() (string, error) {
+	quote := p.text[p.pos : p.pos+1]
+	multiline := strings.HasPrefix(p.text[p.pos:], strings.Repeat(quote, 3))
+	delim := quote
+	if multiline {
+		delim = strings.Repeat(quote, 3)
+	}
+	p.pos += len(delim)
+	if multiline {
+		// A newline right after the opening delimiter is trimmed
+		if strings.HasPrefix(p.text[p.pos:], "\r\n") {
+			p.pos++
+		}
+		if !p.done() && p.peek() == '\n' {
+			p.next()
+		}
+	}
+
+	var b strings.Builder
+	for {
+		if p.done() {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.text[p.pos:], delim) {
+			p.pos += len(delim)
+			// Up to two quotes may end a multi-line string before its delimiter
+			for i := 0; multiline && i < 2 && !p.done() && p.text[p.pos:p.pos+1] == quote; i++ {
+				b.WriteString(quote)
+				p.pos++
+			}
+			return b.String(), nil
+		}
+		c := p.next()
+		switch {
+		case c == '\n' && !multiline:
+			return "", fmt.Errorf("newline in string")
+		case c == '\\' && quote == `"`:
+			if err := p.escape(&b, multiline); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// escape decodes the escape sequence after a backslash in a basic string
+func (p *tomlParser) escape(b *strings.Builder, multiline bool) error {
+	if p.done() {
+		return fmt.Errorf("unterminated escape")
+	}
+	c := p.next()
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte('\x1b')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.text) {
+			return fmt.Errorf("truncated \\%c escape", c)
+		}
+		code, err := strconv.ParseUint(p.text[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("invalid \\%c escape", c)
+		}
+		b.WriteRune(rune(code))
+		p.pos += n
+	case ' ', '\t', '\r', '\n':
+		// A backslash at the end of a line of a multi-line string trims the
+		// line break and the whitespace after it
+		if !multiline {
+			return fmt.Errorf("invalid escape \\%c", c)
+		}
+		for !p.done() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+			p.next()
+		}
+	default:
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
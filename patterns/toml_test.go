@@ -0,0 +1,84 @@
+package patterns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	doc, err := parseTOML(`title = "config" # comment
+[extend]
+useDefault = true
+
+[[rules]]
+id = "a"
+regex = '''(?i)key\s*=\s*"?([a-z0-9]{32})'''
+entropy = 3.5
+secretGroup = 1
+keywords = [
+  "key", # trailing comment
+  'api',
+]
+[rules.allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "b"
+description = "Quote \"b\"\tand é"
+regex = """
+multi\
+    line"""
+tags = []
+point = { x = 1, y = 2 }
+`)
+	if err != nil {
+		t.Fatalf("parseTOML failed: %v", err)
+	}
+
+	want := map[string]any{
+		"title":  "config",
+		"extend": map[string]any{"useDefault": true},
+		"rules": []map[string]any{
+			{
+				"id":          "a",
+				"regex":       `(?i)key\s*=\s*"?([a-z0-9]{32})`,
+				"entropy":     3.5,
+				"secretGroup": int64(1),
+				"keywords":    []any{"key", "api"},
+				"allowlist":   map[string]any{"stopwords": []any{"example"}},
+			},
+			{
+				"id":          "b",
+				"description": "Quote \"b\"\tand é",
+				"regex":       "multiline",
+				"tags":        []any{},
+				"point":       map[string]any{"x": int64(1), "y": int64(2)},
+			},
+		},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("parseTOML() = %#v, want %#v", doc, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "Unterminated string", text: `a = "b`},
+		{name: "Unterminated array", text: `a = [1, 2`},
+		{name: "Duplicate key", text: "a = 1\na = 2"},
+		{name: "Missing equals", text: `a 1`},
+		{name: "Invalid value", text: `a = nope`},
+		{name: "Trailing garbage", text: `a = 1 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseTOML(tt.text); err == nil {
+				t.Error("parseTOML() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/stackloklabs/secret-scanning-api/patterns"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// maxExampleWidth truncates examples in the patterns list table
+const maxExampleWidth = 48
+
+// runPatterns runs the patterns subcommand, which lists, describes, and tests
+// the rules the scanner would run
+func runPatterns(args []string) {
+	if len(args) == 0 {
+		printPatternsUsage()
+		os.Exit(exitError)
+	}
+
+	fs := flag.NewFlagSet("patterns "+args[0], flag.ExitOnError)
+	var rulesFile string
+	var replaceAll bool
+	fs.StringVar(&rulesFile, "patterns-file", "", "YAML or JSON file of custom rules, or a gitleaks .toml config")
+	fs.BoolVar(&replaceAll, "replace-patterns", false, "Show only the rules of -patterns-file")
+
+	switch args[0] {
+	case "list":
+		fs.Parse(args[1:])
+		listPatterns(patternRules(rulesFile, replaceAll))
+	case "describe":
+		// The rule name may come before or after the flags
+		fs.Parse(args[1:])
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: secret-scanner patterns describe [options] <rule>")
+			os.Exit(exitError)
+		}
+		name := fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+		for _, rule := range patternRules(rulesFile, replaceAll) {
+			if rule.Name == name || rule.ID == name {
+				describePattern(rule)
+				return
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Unknown rule: %s\n", name)
+		os.Exit(exitError)
+	case "test":
+		var rule, text, file string
+		fs.StringVar(&rule, "rule", "", "Rule file to test, or the name or ID of a built-in rule")
+		fs.StringVar(&text, "text", "", "Text to test the rule against")
+		fs.StringVar(&file, "file", "", "File to test the rule against (default: stdin)")
+		fs.Parse(args[1:])
+		if rule == "" {
+			rule = rulesFile
+		}
+		if rule == "" {
+			fmt.Fprintln(os.Stderr, "Usage: secret-scanner patterns test -rule <file or rule> [-text text | -file file]")
+			os.Exit(exitError)
+		}
+		var err error
+		switch {
+		case text != "":
+		case file != "":
+			text, err = readFile(file)
+		default:
+			text, err = readStdin()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+			os.Exit(exitError)
+		}
+		if !testPatterns(testedRules(rule), text) {
+			// As a failed check, unlike the usage errors that exit with exitError
+			os.Exit(1)
+		}
+	default:
+		printPatternsUsage()
+		os.Exit(exitError)
+	}
+}
+
+// testedRules returns the rules of the rule file at path, or the built-in
+// rule it names if there is no such file
+func testedRules(path string) []scanner.Rule {
+	if _, err := os.Stat(path); err == nil {
+		return patternRules(path, true)
+	}
+	for _, rule := range patternRules("", false) {
+		if rule.Name == path || rule.ID == path {
+			return []scanner.Rule{rule}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s is neither a rule file nor a built-in rule\n", path)
+	os.Exit(exitError)
+	return nil
+}
+
+// testPatterns shows how each rule handles text: its matches with their
+// capture groups and entropy, and whether the scanner reports them, with what
+// confidence, or why not. It returns whether any match was reported.
+func testPatterns(rules []scanner.Rule, text string) bool {
+	reported := false
+	for i, rule := range rules {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Rule %s", rule.Name)
+		if rule.ID != "" {
+			fmt.Printf(" (%s)", rule.ID)
+		}
+		fmt.Println()
+
+		s := scanner.New(scanner.WithMasking(scanner.MaskOptions{None: true}))
+		if err := s.ReplacePatterns(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := s.AddRule(rule); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		results, err := s.Scan(context.Background(), text)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+			os.Exit(exitError)
+		}
+		found := make(map[int]scanner.Result, len(results))
+		for _, result := range results {
+			found[result.StartIndex] = result
+		}
+
+		re := regexp.MustCompile(rule.Pattern)
+		secretGroup := re.SubexpIndex(scanner.SecretGroup)
+		matches := re.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			fmt.Println("  No match")
+			continue
+		}
+		for n, match := range matches {
+			start, end := match[0], match[1]
+			if secretGroup > 0 && match[2*secretGroup] >= 0 {
+				start, end = match[2*secretGroup], match[2*secretGroup+1]
+			}
+			value := text[start:end]
+			line := strings.Count(text[:start], "\n") + 1
+			fmt.Printf("  Match %d at line %d, offset %d-%d: %s\n", n+1, line, match[0], match[1], exampleText(text[match[0]:match[1]]))
+			for g, name := range re.SubexpNames() {
+				if g == 0 || match[2*g] < 0 {
+					continue
+				}
+				label := fmt.Sprintf("group %d", g)
+				if name != "" {
+					label += " (" + name + ")"
+				}
+				fmt.Printf("    %-20s %s\n", label+":", exampleText(text[match[2*g]:match[2*g+1]]))
+			}
+			entropy := patterns.CalculateEntropy(value)
+			if rule.MinEntropy > 0 {
+				fmt.Printf("    %-20s %.2f bits/char (minimum %.2f)\n", "Entropy:", entropy, rule.MinEntropy)
+			} else {
+				fmt.Printf("    %-20s %.2f bits/char\n", "Entropy:", entropy)
+			}
+			if result, ok := found[start]; ok {
+				reported = true
+				fmt.Printf("    %-20s yes, confidence %.2f, severity %s\n", "Reported:", result.Confidence, result.Severity)
+			} else {
+				fmt.Printf("    %-20s no, %s\n", "Reported:", dropReason(rule, text, value, match[0], match[1], entropy))
+			}
+		}
+	}
+	return reported
+}
+
+// dropReason explains why the scanner does not report a match of rule
+func dropReason(rule scanner.Rule, text, value string, start, end int, entropy float64) string {
+	match := text[start:end]
+	if len(rule.Keywords) > 0 {
+		lower := strings.ToLower(text)
+		found := false
+		for _, keyword := range rule.Keywords {
+			found = found || strings.Contains(lower, strings.ToLower(keyword))
+		}
+		if !found {
+			return "no keyword (" + strings.Join(rule.Keywords, ", ") + ") in the text"
+		}
+	}
+	if rule.MinEntropy > 0 && entropy < rule.MinEntropy {
+		return "entropy below the minimum"
+	}
+	for _, re := range rule.Allowlist {
+		if regexp.MustCompile(re).MatchString(value) {
+			return "value allowlisted by " + re
+		}
+	}
+	for _, re := range rule.MatchAllowlist {
+		if regexp.MustCompile(re).MatchString(match) {
+			return "match allowlisted by " + re
+		}
+	}
+	if !scanner.InContext(rule.Name, text, start, end, scanner.DefaultKeywordWindow) {
+		return "no context keyword of " + rule.Name + " nearby"
+	}
+	if !scanner.Valid(rule.Name, value) {
+		return "value fails the built-in check of " + rule.Name
+	}
+	if scanner.IsPlaceholder(value) {
+		return "value is a placeholder"
+	}
+	valueStart := start + strings.Index(match, value)
+	if kind, suppress := scanner.NonSecret(rule.Name, text, valueStart, valueStart+len(value)); suppress {
+		return "value is a " + kind
+	}
+	lower := strings.ToLower(value)
+	for _, stopword := range rule.Stopwords {
+		if strings.Contains(lower, strings.ToLower(stopword)) {
+			return "value contains stopword " + stopword
+		}
+	}
+	return "an overlapping match has higher confidence"
+}
+
+// patternRules returns the built-in rules and those of rulesFile, or only the
+// latter if replaceAll is set
+func patternRules(rulesFile string, replaceAll bool) []scanner.Rule {
+	if replaceAll && rulesFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -replace-patterns requires -patterns-file")
+		os.Exit(exitError)
+	}
+
+	builtin := patterns.GetAllPatterns()
+	for name, pattern := range patterns.BrowserExtensionPatterns {
+		builtin[name] = pattern
+	}
+	if replaceAll {
+		builtin = nil
+	}
+	s := scanner.New()
+	if err := s.ReplacePatterns(builtin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if rulesFile != "" {
+		rules, err := loadRules(rulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		for _, rule := range rules {
+			if err := s.AddRule(rule); err != nil {
+				fmt.Fprintf(os.Stderr, "Error adding custom rule %s: %v\n", rule.Name, err)
+				os.Exit(exitError)
+			}
+		}
+	}
+	return s.Rules()
+}
+
+func listPatterns(rules []scanner.Rule) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSEVERITY\tDESCRIPTION\tEXAMPLE")
+	for _, rule := range rules {
+		example := "-"
+		if ex := patterns.ExamplesOf(rule.Name).Positive; len(ex) > 0 && rule.ID == scanner.RuleID(rule.Name) {
+			example = exampleText(ex[0])
+			if len(example) > maxExampleWidth {
+				example = example[:maxExampleWidth-3] + "..."
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", orDash(rule.ID), rule.Name, rule.Severity, rule.Description, example)
+	}
+	w.Flush()
+}
+
+func describePattern(rule scanner.Rule) {
+	fmt.Printf("Name:        %s\n", rule.Name)
+	fmt.Printf("ID:          %s\n", orDash(rule.ID))
+	fmt.Printf("Severity:    %s\n", rule.Severity)
+	fmt.Printf("Description: %s\n", rule.Description)
+	taxonomy := patterns.Classify(rule.Name)
+	class := taxonomy.Class
+	if taxonomy.Provider != "" {
+		class += " (" + taxonomy.Provider + ")"
+	}
+	fmt.Printf("Class:       %s, %s\n", class, taxonomy.CWE)
+	fmt.Printf("Pattern:     %s\n", rule.Pattern)
+	if len(rule.Keywords) > 0 {
+		fmt.Printf("Keywords:    %s\n", strings.Join(rule.Keywords, ", "))
+	}
+	if rule.MinEntropy > 0 {
+		fmt.Printf("Min entropy: %.2f\n", rule.MinEntropy)
+	}
+	for _, re := range rule.Allowlist {
+		fmt.Printf("Allowlist:   %s\n", re)
+	}
+	for _, re := range rule.MatchAllowlist {
+		fmt.Printf("Allowlist:   %s (whole match)\n", re)
+	}
+	if len(rule.Stopwords) > 0 {
+		fmt.Printf("Stopwords:   %s\n", strings.Join(rule.Stopwords, ", "))
+	}
+
+	// Custom rules may shadow a built-in name, whose examples do not apply
+	if rule.ID != scanner.RuleID(rule.Name) {
+		return
+	}
+	examples := patterns.ExamplesOf(rule.Name)
+	if len(examples.Positive)+len(examples.Negative) > 0 {
+		fmt.Println("Examples:")
+		for _, ex := range examples.Positive {
+			fmt.Printf("  match     %s\n", exampleText(ex))
+		}
+		for _, ex := range examples.Negative {
+			fmt.Printf("  no match  %s\n", exampleText(ex))
+		}
+	}
+}
+
+// exampleText shows line breaks of an example as \n so that it fits on a line
+func exampleText(example string) string {
+	return strings.ReplaceAll(example, "\n", `\n`)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func printPatternsUsage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  secret-scanner patterns list [options]
+        List every rule with its ID, severity, description, and an example
+  secret-scanner patterns describe [options] <rule>
+        Show the pattern, metadata, and examples of a rule, by name or ID
+  secret-scanner patterns test -rule <file or rule> [-text text | -file file]
+        Show the matches of a rule file or built-in rule in the text, with
+        their groups, entropy, and confidence, and whether they are reported;
+        exits with 1 if none is
+
+Options of list and describe:
+  -patterns-file string
+        YAML or JSON file of custom rules, or a gitleaks .toml config, to
+        include
+  -replace-patterns
+        Show only the rules of -patterns-file`)
+}
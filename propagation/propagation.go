@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package propagation links each detected secret to every other place in a
+// scanned tree where its value, or an encoding of it, appears, so that
+// remediation covers every copy rather than just the first hit
+package propagation
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/dirscan"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// minValueLength is the shortest value that is traced. Shorter values match
+// too much unrelated text.
+const minValueLength = 8
+
+// Finding is a secret detected in a file
+type Finding struct {
+	Path string
+	scanner.Result
+}
+
+// Occurrence is a place the value of a secret appears
+type Occurrence struct {
+	Path       string `json:"path"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+	LineNumber int    `json:"line_number"`
+	// Encoding is how the value is encoded at this place: "" for the raw
+	// value, "base64", or "hex"
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Graph links the first detection of a secret to each of its copies
+type Graph struct {
+	Type        string       `json:"type"`
+	Fingerprint string       `json:"fingerprint"`
+	Source      Occurrence   `json:"source"`
+	Copies      []Occurrence `json:"copies"`
+}
+
+// encoding is a form the value of a secret may be copied in
+type encoding struct {
+	name   string
+	encode func(string) string
+}
+
+var encodings = []encoding{
+	{name: "", encode: func(v string) string { return v }},
+	{name: "base64", encode: func(v string) string { return base64.StdEncoding.EncodeToString([]byte(v)) }},
+	{name: "base64", encode: func(v string) string { return base64.RawStdEncoding.EncodeToString([]byte(v)) }},
+	{name: "base64", encode: func(v string) string { return base64.URLEncoding.EncodeToString([]byte(v)) }},
+	{name: "hex", encode: func(v string) string { return hex.EncodeToString([]byte(v)) }},
+	{name: "hex", encode: func(v string) string { return strings.ToUpper(hex.EncodeToString([]byte(v))) }},
+}
+
+// Trace searches files for the value of each finding, raw and base64- or
+// hex-encoded, and returns a graph for every secret that appears in more than
+// one place. The source of a graph is its first finding by path and
+// position. Findings without a value, as from a scanner in secure mode,
+// cannot be traced.
+func Trace(files []dirscan.File, findings []Finding) []Graph {
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].StartIndex < sorted[j].StartIndex
+	})
+
+	var graphs []Graph
+	traced := make(map[string]bool)
+	for _, f := range sorted {
+		if len(f.Value) < minValueLength || traced[f.Value] {
+			continue
+		}
+		traced[f.Value] = true
+
+		source := Occurrence{Path: f.Path, StartIndex: f.StartIndex, EndIndex: f.EndIndex, LineNumber: f.LineNumber}
+		graph := Graph{Type: f.Type, Fingerprint: f.Fingerprint, Source: source}
+		for _, occ := range occurrences(files, f.Value) {
+			if occ.Path == source.Path && occ.StartIndex <= source.StartIndex && source.StartIndex < occ.EndIndex {
+				continue
+			}
+			graph.Copies = append(graph.Copies, occ)
+		}
+		if len(graph.Copies) > 0 {
+			graphs = append(graphs, graph)
+		}
+	}
+	return graphs
+}
+
+// occurrences returns every place value appears in files in any encoding
+func occurrences(files []dirscan.File, value string) []Occurrence {
+	var found []Occurrence
+	for _, file := range files {
+		seen := make(map[int]bool)
+		for _, enc := range encodings {
+			needle := enc.encode(value)
+			for base := 0; ; {
+				i := strings.Index(file.Content[base:], needle)
+				if i < 0 {
+					break
+				}
+				start := base + i
+				base = start + len(needle)
+				// Unpadded and padded base64 overlap; report each place once
+				if seen[start] {
+					continue
+				}
+				seen[start] = true
+				found = append(found, Occurrence{
+					Path:       file.Path,
+					StartIndex: start,
+					EndIndex:   start + len(needle),
+					LineNumber: strings.Count(file.Content[:start], "\n") + 1,
+					Encoding:   enc.name,
+				})
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Path != found[j].Path {
+			return found[i].Path < found[j].Path
+		}
+		return found[i].StartIndex < found[j].StartIndex
+	})
+	return found
+}
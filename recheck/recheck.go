@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package recheck periodically re-verifies stored findings and resolves the
+// ones that are no longer reproducible, such as a secret removed from its file
+// or a token that has been revoked, keeping stored findings fresh without
+// manual re-scans
+package recheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Finding is a stored finding to re-verify
+type Finding struct {
+	ID          string
+	Path        string
+	Type        string
+	Fingerprint string
+}
+
+// Store holds the findings of a long-running service
+type Store interface {
+	// Open returns the findings that have not been resolved
+	Open(ctx context.Context) ([]Finding, error)
+	// Resolve marks a finding as resolved with a reason
+	Resolve(ctx context.Context, id, reason string) error
+}
+
+// Check reports whether a finding can still be reproduced. It returns a reason
+// along with false, and an error if it could not tell.
+type Check func(ctx context.Context, f Finding) (reproducible bool, reason string, err error)
+
+// FileCheck re-scans the file of a finding with s and reports the finding as
+// reproducible while the file still contains a secret with its fingerprint.
+// s must use the fingerprint salt the finding was stored with.
+func FileCheck(s *scanner.Scanner) Check {
+	return func(ctx context.Context, f Finding) (bool, string, error) {
+		data, err := os.ReadFile(f.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, "file no longer exists", nil
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+
+		results, err := s.Scan(ctx, string(data))
+		if err != nil {
+			return false, "", fmt.Errorf("failed to scan %s: %w", f.Path, err)
+		}
+		for _, result := range results {
+			if result.Fingerprint == f.Fingerprint {
+				return true, "", nil
+			}
+		}
+		return false, "secret no longer present in file", nil
+	}
+}
+
+// Scheduler re-verifies the open findings of a store with a set of checks
+type Scheduler struct {
+	store  Store
+	checks []Check
+}
+
+// New creates a Scheduler. A finding is resolved as soon as one of the checks
+// reports it is no longer reproducible.
+func New(store Store, checks ...Check) *Scheduler {
+	return &Scheduler{store: store, checks: checks}
+}
+
+// RunOnce re-verifies every open finding and returns the number resolved.
+// Findings whose checks fail are left open and their errors are joined.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	findings, err := s.store.Open(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list open findings: %w", err)
+	}
+
+	resolved := 0
+	var errs []error
+	for _, f := range findings {
+		if err := ctx.Err(); err != nil {
+			return resolved, err
+		}
+		for _, check := range s.checks {
+			reproducible, reason, err := check(ctx, f)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("finding %s: %w", f.ID, err))
+				break
+			}
+			if reproducible {
+				continue
+			}
+			if err := s.store.Resolve(ctx, f.ID, reason); err != nil {
+				errs = append(errs, fmt.Errorf("failed to resolve finding %s: %w", f.ID, err))
+			} else {
+				resolved++
+			}
+			break
+		}
+	}
+	return resolved, errors.Join(errs...)
+}
+
+// Run calls RunOnce every interval until ctx is cancelled. Errors are reported
+// on the returned channel, without blocking if nobody is listening, and the
+// channel is closed when ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) <-chan error {
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(errChan)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				select {
+				case errChan <- err:
+				default:
+				}
+			}
+		}
+	}()
+
+	return errChan
+}
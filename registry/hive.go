@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	hiveBaseBlockSize = 4096
+	keyCompressedName = 0x0020
+	valueCompressed   = 0x0001
+	dataInline        = 0x80000000
+	maxKeyDepth       = 512
+	maxValueSize      = 16344 // larger values use big data records
+)
+
+var (
+	hiveMagic = []byte("regf")
+
+	errHiveTruncated = errors.New("truncated registry hive")
+)
+
+// ErrCorrupt is returned along with the values that were read when corrupt
+// key or value records of a hive were skipped
+var ErrCorrupt = errors.New("corrupt registry hive records skipped")
+
+// hive is a read-only view of an offline registry hive (regf)
+type hive struct {
+	data    []byte
+	visited map[uint32]bool
+	values  []Value
+	// skipped counts the corrupt records left out of the walk, the first of
+	// which failed with firstErr
+	skipped  int
+	firstErr error
+}
+
+// ParseHive walks an offline registry hive and returns the values stored under
+// credential-prone keys. Corrupt records are skipped with the keys below them,
+// and reported with ErrCorrupt once the rest of the hive is read.
+func ParseHive(data []byte) ([]Value, error) {
+	if len(data) < hiveBaseBlockSize {
+		return nil, errHiveTruncated
+	}
+	if string(data[:4]) != string(hiveMagic) {
+		return nil, errors.New("not a registry hive")
+	}
+
+	h := &hive{data: data, visited: make(map[uint32]bool)}
+	root := binary.LittleEndian.Uint32(data[0x24:])
+	h.walk(root, "", 0)
+	if h.skipped > 0 {
+		return h.values, fmt.Errorf("%w: %d, the first: %v", ErrCorrupt, h.skipped, h.firstErr)
+	}
+	return h.values, nil
+}
+
+// skip records a corrupt record that is left out of the walk
+func (h *hive) skip(err error) {
+	if h.skipped == 0 {
+		h.firstErr = err
+	}
+	h.skipped++
+}
+
+// cell returns the data of the cell at offset, relative to the first hive bin
+func (h *hive) cell(offset uint32) ([]byte, error) {
+	pos := hiveBaseBlockSize + int(offset)
+	if pos+4 > len(h.data) || pos < hiveBaseBlockSize {
+		return nil, errHiveTruncated
+	}
+	size := int(int32(binary.LittleEndian.Uint32(h.data[pos:])))
+	if size < 0 {
+		size = -size // allocated cells have a negative size
+	}
+	if size < 4 || pos+size > len(h.data) {
+		return nil, errHiveTruncated
+	}
+	return h.data[pos+4 : pos+size], nil
+}
+
+// walk visits the key node at offset and its subkeys, skipping those that
+// are corrupt
+func (h *hive) walk(offset uint32, parent string, depth int) {
+	if depth > maxKeyDepth {
+		h.skip(errors.New("registry hive key tree too deep"))
+		return
+	}
+	if h.visited[offset] {
+		return
+	}
+	h.visited[offset] = true
+
+	nk, err := h.cell(offset)
+	if err != nil {
+		h.skip(err)
+		return
+	}
+	if len(nk) < 0x4c || string(nk[:2]) != "nk" {
+		h.skip(fmt.Errorf("invalid key node at offset %#x", offset))
+		return
+	}
+
+	le := binary.LittleEndian
+	nameLen := int(le.Uint16(nk[0x48:]))
+	if 0x4c+nameLen > len(nk) {
+		h.skip(errHiveTruncated)
+		return
+	}
+	name := decodeName(nk[0x4c:0x4c+nameLen], le.Uint16(nk[0x02:])&keyCompressedName != 0)
+
+	path := name
+	if parent != "" {
+		path = parent + `\` + name
+	}
+
+	if count := le.Uint32(nk[0x24:]); count > 0 {
+		h.readValues(path, le.Uint32(nk[0x28:]), count)
+	}
+
+	if le.Uint32(nk[0x14:]) == 0 {
+		return
+	}
+	for _, sub := range h.subkeys(le.Uint32(nk[0x1c:]), 0) {
+		h.walk(sub, path, depth+1)
+	}
+}
+
+// subkeys resolves a subkey list (lf, lh, li, or ri) to key node offsets,
+// skipping the lists that are corrupt
+func (h *hive) subkeys(offset uint32, depth int) []uint32 {
+	offsets, err := h.subkeyList(offset, depth)
+	if err != nil {
+		h.skip(err)
+	}
+	return offsets
+}
+
+// subkeyList resolves one subkey list. The lists an ri list indexes are
+// resolved with subkeys, so that a corrupt one drops only its own keys.
+func (h *hive) subkeyList(offset uint32, depth int) ([]uint32, error) {
+	if depth > 2 {
+		return nil, errors.New("registry hive subkey index too deep")
+	}
+	list, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) < 4 {
+		return nil, errHiveTruncated
+	}
+
+	le := binary.LittleEndian
+	count := int(le.Uint16(list[2:]))
+	stride := 4
+	switch string(list[:2]) {
+	case "lf", "lh":
+		stride = 8 // offset followed by a name hash
+	case "li", "ri":
+	default:
+		return nil, fmt.Errorf("invalid subkey list at offset %#x", offset)
+	}
+	if 4+count*stride > len(list) {
+		return nil, errHiveTruncated
+	}
+
+	offsets := make([]uint32, 0, count)
+	for i := 0; i < count; i++ {
+		entry := le.Uint32(list[4+i*stride:])
+		if string(list[:2]) != "ri" {
+			offsets = append(offsets, entry)
+			continue
+		}
+		offsets = append(offsets, h.subkeys(entry, depth+1)...)
+	}
+	return offsets, nil
+}
+
+// readValues collects the selected values of the key at path, skipping
+// those that are corrupt
+func (h *hive) readValues(path string, listOffset, count uint32) {
+	list, err := h.cell(listOffset)
+	if err != nil {
+		h.skip(err)
+		return
+	}
+	if int(count)*4 > len(list) {
+		h.skip(errHiveTruncated)
+		return
+	}
+
+	le := binary.LittleEndian
+	for i := 0; i < int(count); i++ {
+		vk, err := h.cell(le.Uint32(list[4*i:]))
+		if err != nil {
+			h.skip(err)
+			continue
+		}
+		if len(vk) < 0x14 || string(vk[:2]) != "vk" {
+			continue
+		}
+
+		nameLen := int(le.Uint16(vk[0x02:]))
+		if 0x14+nameLen > len(vk) {
+			h.skip(errHiveTruncated)
+			continue
+		}
+		name := decodeName(vk[0x14:0x14+nameLen], le.Uint16(vk[0x10:])&valueCompressed != 0)
+		if _, ok := match(path, name); !ok {
+			continue
+		}
+
+		data, err := h.valueData(vk)
+		if err != nil {
+			h.skip(err)
+			continue
+		}
+		if v, ok := newValue(path, name, decodeData(le.Uint32(vk[0x0c:]), data)); ok {
+			h.values = append(h.values, v)
+		}
+	}
+}
+
+// valueData returns the raw data of a value key record
+func (h *hive) valueData(vk []byte) ([]byte, error) {
+	le := binary.LittleEndian
+	size := le.Uint32(vk[0x04:])
+	if size&dataInline != 0 {
+		size &^= dataInline
+		if size > 4 {
+			size = 4
+		}
+		return vk[0x08 : 0x08+size], nil
+	}
+	if size > maxValueSize {
+		return nil, nil
+	}
+
+	data, err := h.cell(le.Uint32(vk[0x08:]))
+	if err != nil {
+		return nil, err
+	}
+	if int(size) > len(data) {
+		return nil, errHiveTruncated
+	}
+	return data[:size], nil
+}
+
+// decodeName converts a key or value name stored as ASCII or UTF-16LE
+func decodeName(b []byte, compressed bool) string {
+	if compressed {
+		return string(b)
+	}
+	return decodeUTF16(b)
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// registry value types
+const (
+	regSZ       = 1
+	regExpandSZ = 2
+	regDWORD    = 4
+	regMultiSZ  = 7
+)
+
+var errNotRegFile = errors.New("not a registry export file")
+
+// ParseReg parses a .reg export (REGEDIT4 or version 5.00) and returns the
+// values stored under credential-prone keys
+func ParseReg(data []byte) ([]Value, error) {
+	text := decodeText(data)
+	if !strings.HasPrefix(text, "Windows Registry Editor") && !strings.HasPrefix(text, "REGEDIT4") {
+		return nil, errNotRegFile
+	}
+
+	var values []Value
+	key := ""
+	for lineNumber, line := range joinContinuations(text) {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[-"):
+			key = ""
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			key = line[1 : len(line)-1]
+		case key != "" && (strings.HasPrefix(line, `"`) || strings.HasPrefix(line, "@=")):
+			name, raw, err := splitValueLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber+1, err)
+			}
+			if v, ok := newValue(key, name, decodeRegData(raw)); ok {
+				values = append(values, v)
+			}
+		}
+	}
+	return values, nil
+}
+
+// decodeText converts a UTF-16LE (with BOM) or UTF-8 export to a string
+func decodeText(data []byte) string {
+	if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+		return decodeUTF16(data[2:])
+	}
+	return strings.TrimPrefix(string(data), "\ufeff")
+}
+
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// joinContinuations merges hex value lines that end with a backslash
+func joinContinuations(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	joined := make([]string, 0, len(lines))
+	var current strings.Builder
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if current.Len() > 0 {
+			trimmed = strings.TrimLeft(trimmed, " \t")
+		}
+		if strings.HasSuffix(trimmed, `\`) && !strings.HasSuffix(trimmed, `"`) {
+			current.WriteString(strings.TrimSuffix(trimmed, `\`))
+			continue
+		}
+		current.WriteString(trimmed)
+		joined = append(joined, current.String())
+		current.Reset()
+	}
+	return joined
+}
+
+// splitValueLine splits `"name"=data` or `@=data` into the value name and raw data
+func splitValueLine(line string) (string, string, error) {
+	if strings.HasPrefix(line, "@=") {
+		return "", line[2:], nil
+	}
+
+	name, rest, err := unquote(line)
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.HasPrefix(rest, "=") {
+		return "", "", errors.New("missing '=' after value name")
+	}
+	return name, rest[1:], nil
+}
+
+// unquote reads a leading quoted string with backslash escapes and returns it
+// together with the remainder of s
+func unquote(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// decodeRegData converts the textual data of a .reg value to a string; binary
+// and deleted values yield an empty string
+func decodeRegData(raw string) string {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		s, _, err := unquote(raw)
+		if err != nil {
+			return ""
+		}
+		return s
+	case strings.HasPrefix(raw, "dword:"):
+		v, err := strconv.ParseUint(raw[len("dword:"):], 16, 32)
+		if err != nil {
+			return ""
+		}
+		return strconv.FormatUint(v, 10)
+	case strings.HasPrefix(raw, "hex("):
+		end := strings.Index(raw, "):")
+		if end < 0 {
+			return ""
+		}
+		typ, err := strconv.ParseUint(raw[len("hex("):end], 16, 32)
+		if err != nil {
+			return ""
+		}
+		return decodeData(uint32(typ), parseHexList(raw[end+2:]))
+	default:
+		// hex: is REG_BINARY, - deletes the value
+		return ""
+	}
+}
+
+func parseHexList(s string) []byte {
+	var out []byte
+	for _, part := range strings.Split(s, ",") {
+		b, err := hex.DecodeString(strings.TrimSpace(part))
+		if err != nil || len(b) != 1 {
+			continue
+		}
+		out = append(out, b[0])
+	}
+	return out
+}
+
+// decodeData converts raw value data of the given type to a string
+func decodeData(typ uint32, data []byte) string {
+	switch typ {
+	case regSZ, regExpandSZ:
+		return strings.TrimRight(decodeUTF16(data), "\x00")
+	case regMultiSZ:
+		parts := strings.Split(strings.TrimRight(decodeUTF16(data), "\x00"), "\x00")
+		return strings.Join(parts, "\n")
+	case regDWORD:
+		if len(data) < 4 {
+			return ""
+		}
+		return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(data)), 10)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package registry extracts values stored under credential-prone keys from
+// Windows registry exports (.reg) and offline registry hives
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Value is a registry value found under a credential-prone key
+type Value struct {
+	Key  string
+	Name string
+	Data string
+	// Rule names the credential-prone location that selected the value
+	Rule string
+	// Description explains why the location is credential-prone
+	Description string
+	// Credential is set when the value is a stored credential by itself, such
+	// as the Winlogon DefaultPassword, rather than text that should be scanned
+	Credential bool
+}
+
+// rule selects values by key path suffix and value name
+type rule struct {
+	name        string
+	key         *regexp.Regexp
+	value       string // value name, empty for every value under the key
+	credential  bool
+	description string
+}
+
+var rules = []rule{
+	{
+		name:        "winlogon_default_password",
+		key:         regexp.MustCompile(`(?i)\\Microsoft\\Windows NT\\CurrentVersion\\Winlogon$`),
+		value:       "DefaultPassword",
+		credential:  true,
+		description: "Winlogon AutoAdminLogon password stored in plaintext",
+	},
+	{
+		name:        "winlogon_default_password",
+		key:         regexp.MustCompile(`(?i)\\Microsoft\\Windows NT\\CurrentVersion\\Winlogon$`),
+		value:       "AltDefaultPassword",
+		credential:  true,
+		description: "Winlogon AutoAdminLogon password stored in plaintext",
+	},
+	{
+		name:        "putty_proxy_password",
+		key:         regexp.MustCompile(`(?i)\\SimonTatham\\PuTTY\\Sessions\\[^\\]+$`),
+		value:       "ProxyPassword",
+		credential:  true,
+		description: "PuTTY session proxy password stored in plaintext",
+	},
+	{
+		name:        "service_image_path",
+		key:         regexp.MustCompile(`(?i)\\(CurrentControlSet|ControlSet\d{3})\\Services\\[^\\]+$`),
+		value:       "ImagePath",
+		description: "Service command line",
+	},
+	{
+		name:        "service_parameters",
+		key:         regexp.MustCompile(`(?i)\\(CurrentControlSet|ControlSet\d{3})\\Services\\[^\\]+\\Parameters$`),
+		value:       "AppParameters",
+		description: "Service wrapper command line arguments",
+	},
+	{
+		name:        "run_key_command",
+		key:         regexp.MustCompile(`(?i)\\Microsoft\\Windows\\CurrentVersion\\Run(Once)?$`),
+		description: "Autostart command line",
+	},
+}
+
+// match returns the rule selecting the value name under key, if any
+func match(key, name string) (rule, bool) {
+	for _, r := range rules {
+		if r.value != "" && !strings.EqualFold(r.value, name) {
+			continue
+		}
+		if r.key.MatchString(key) {
+			return r, true
+		}
+	}
+	return rule{}, false
+}
+
+// newValue returns the Value for key\name if it is under a credential-prone key
+func newValue(key, name, data string) (Value, bool) {
+	if data == "" {
+		return Value{}, false
+	}
+	r, ok := match(key, name)
+	if !ok {
+		return Value{}, false
+	}
+	return Value{
+		Key:         key,
+		Name:        name,
+		Data:        data,
+		Rule:        r.name,
+		Description: r.description,
+		Credential:  r.credential,
+	}, true
+}
+
+// ExtractFile reads a .reg export or an offline hive and returns the values
+// stored under credential-prone keys
+func ExtractFile(path string) ([]Value, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry file: %w", err)
+	}
+	return Extract(data)
+}
+
+// Extract detects whether data is an offline hive or a .reg export and returns
+// the values stored under credential-prone keys
+func Extract(data []byte) ([]Value, error) {
+	if bytes.HasPrefix(data, hiveMagic) {
+		return ParseHive(data)
+	}
+	return ParseReg(data)
+}
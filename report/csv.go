@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvHeader names the columns of CSV reports
+var csvHeader = []string{"file", "line", "column", "rule_id", "severity", "confidence", "value", "fingerprint"}
+
+// WriteCSV writes findings as CSV with a header row, one finding per row
+func WriteCSV(w io.Writer, findings []Finding) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, f := range findings {
+		row := []string{
+			cell(f.Artifact),
+			strconv.Itoa(f.Result.LineNumber),
+			strconv.Itoa(f.Column),
+			cell(rule(f)),
+			string(f.Result.Severity),
+			strconv.FormatFloat(f.Result.Confidence, 'f', 2, 64),
+			cell(f.Value),
+			f.Result.Fingerprint,
+		}
+		if err := out.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// cell guards a text cell against being run as a formula by the spreadsheet
+// it is opened in, as a value starting with = or + could be
+func cell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
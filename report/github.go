@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+var (
+	// githubData escapes the message of a workflow command
+	githubData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	// githubProperty escapes the properties of a workflow command, such as
+	// its file name
+	githubProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// WriteGitHub writes findings as GitHub Actions workflow commands, which show
+// as annotations on the lines of pull requests when run in a workflow.
+// Critical and high findings are errors, medium ones warnings, and low ones
+// notices. Skipped artifacts are warnings on the file.
+func WriteGitHub(w io.Writer, findings []Finding, skipped ...Skipped) error {
+	for _, f := range findings {
+		r := f.Result
+		message := fmt.Sprintf("%s (%s, confidence %.2f", r.Description, r.Type, r.Confidence)
+		if f.Value != "" {
+			message += ", value " + f.Value
+		}
+		message += ", fingerprint " + r.Fingerprint + ")"
+		_, err := fmt.Fprintf(w, "::%s file=%s,line=%d,endLine=%d,col=%d,title=%s::%s\n",
+			githubLevel(r.Severity), githubProperty.Replace(f.Artifact), r.LineNumber, max(r.EndLineNumber, r.LineNumber),
+			f.Column, githubProperty.Replace("Secret: "+rule(f)), githubData.Replace(message))
+		if err != nil {
+			return fmt.Errorf("failed to write GitHub annotation: %w", err)
+		}
+	}
+	for _, s := range skipped {
+		_, err := fmt.Fprintf(w, "::warning file=%s,title=Secret scan skipped::%s\n",
+			githubProperty.Replace(s.Artifact), githubData.Replace(s.Reason))
+		if err != nil {
+			return fmt.Errorf("failed to write GitHub annotation: %w", err)
+		}
+	}
+	return nil
+}
+
+// githubLevel returns the workflow command that annotates findings of a
+// severity
+func githubLevel(severity scanner.Severity) string {
+	switch severity {
+	case scanner.SeverityMedium:
+		return "warning"
+	case scanner.SeverityLow:
+		return "notice"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestWriteGitHub(t *testing.T) {
+	findings := []Finding{
+		{Artifact: "config.json", Column: 13, Value: "gh************************************uK", Result: scanner.Result{
+			Type: "github_token", RuleID: "SSA-GITHUB-001", LineNumber: 2, Confidence: 0.9,
+			Severity: scanner.SeverityHigh, Description: "Possible GitHub token detected", Fingerprint: "2cc84f53",
+		}},
+		{Artifact: "a,b:c.env", Column: 1, Result: scanner.Result{
+			Type: "basic_password", LineNumber: 4, EndLineNumber: 5, Confidence: 0.5,
+			Severity: scanner.SeverityMedium, Description: "100% a\npassword",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGitHub(&buf, findings, Skipped{Artifact: "big.log", Reason: "timed out after 30s"}); err != nil {
+		t.Fatalf("WriteGitHub failed: %v", err)
+	}
+	want := "::error file=config.json,line=2,endLine=2,col=13,title=Secret%3A SSA-GITHUB-001::" +
+		"Possible GitHub token detected (github_token, confidence 0.90, value gh************************************uK, fingerprint 2cc84f53)\n" +
+		"::warning file=a%2Cb%3Ac.env,line=4,endLine=5,col=1,title=Secret%3A basic_password::" +
+		"100%25 a%0Apassword (basic_password, confidence 0.50, fingerprint )\n" +
+		"::warning file=big.log,title=Secret scan skipped::timed out after 30s\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteGitHub() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitLabSchemaVersion is the version of the GitLab security report schema
+// that reports follow
+const gitLabSchemaVersion = "15.0.7"
+
+// gitLabTime is the time format of GitLab security reports, in UTC without a
+// zone
+const gitLabTime = "2006-01-02T15:04:05"
+
+// GitLabScan describes the scan a GitLab security report comes from
+type GitLabScan struct {
+	// Version is the version of the scanner
+	Version    string
+	Start, End time.Time
+	// Skipped lists the artifacts left unscanned, reported as warnings
+	Skipped []Skipped
+}
+
+type gitLabReport struct {
+	Version         string                `json:"version"`
+	Vulnerabilities []gitLabVulnerability `json:"vulnerabilities"`
+	Scan            gitLabScanInfo        `json:"scan"`
+}
+
+type gitLabVulnerability struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Severity    string             `json:"severity"`
+	Solution    string             `json:"solution"`
+	Identifiers []gitLabIdentifier `json:"identifiers"`
+	Location    gitLabLocation     `json:"location"`
+}
+
+type gitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type gitLabLocation struct {
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+type gitLabScanInfo struct {
+	Analyzer  gitLabTool      `json:"analyzer"`
+	Scanner   gitLabTool      `json:"scanner"`
+	Type      string          `json:"type"`
+	StartTime string          `json:"start_time"`
+	EndTime   string          `json:"end_time"`
+	Status    string          `json:"status"`
+	Messages  []gitLabMessage `json:"messages,omitempty"`
+}
+
+type gitLabMessage struct {
+	Level string `json:"level"`
+	Value string `json:"value"`
+}
+
+type gitLabTool struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Version string       `json:"version"`
+	Vendor  gitLabVendor `json:"vendor"`
+}
+
+type gitLabVendor struct {
+	Name string `json:"name"`
+}
+
+// WriteGitLabSAST writes findings as a GitLab SAST security report, which
+// GitLab shows in the security widget of merge requests. Reports name the
+// rule and location of each finding but never include the secret itself.
+func WriteGitLabSAST(w io.Writer, findings []Finding, scan GitLabScan) error {
+	tool := gitLabTool{ID: "secret-scanner", Name: "Secret Scanner", Version: scan.Version, Vendor: gitLabVendor{Name: "Stacklok"}}
+	report := gitLabReport{
+		Version:         gitLabSchemaVersion,
+		Vulnerabilities: make([]gitLabVulnerability, 0, len(findings)),
+		Scan: gitLabScanInfo{
+			Analyzer:  tool,
+			Scanner:   tool,
+			Type:      "sast",
+			StartTime: scan.Start.UTC().Format(gitLabTime),
+			EndTime:   scan.End.UTC().Format(gitLabTime),
+			Status:    "success",
+		},
+	}
+	for _, s := range scan.Skipped {
+		report.Scan.Messages = append(report.Scan.Messages, gitLabMessage{Level: "warn", Value: fmt.Sprintf("Skipped %s: %s", s.Artifact, s.Reason)})
+	}
+	for _, f := range findings {
+		r := f.Result
+		endLine := max(r.EndLineNumber, r.LineNumber)
+		report.Vulnerabilities = append(report.Vulnerabilities, gitLabVulnerability{
+			ID:          gitLabID(f),
+			Name:        r.Description,
+			Description: fmt.Sprintf("%s (%s) at line %d, column %d, with confidence %.2f.", r.Description, r.Type, r.LineNumber, f.Column, r.Confidence),
+			Severity:    gitLabSeverity(string(r.Severity)),
+			Solution:    "Revoke or rotate the secret, then remove it from the file and its history.",
+			Identifiers: []gitLabIdentifier{{Type: "secret_scanner_rule_id", Name: "Rule " + rule(f), Value: rule(f)}},
+			Location:    gitLabLocation{File: f.Artifact, StartLine: r.LineNumber, EndLine: endLine},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write GitLab report: %w", err)
+	}
+	return nil
+}
+
+// gitLabID derives a stable UUID for a finding from its rule, location, and
+// fingerprint, so that GitLab tracks it across pipelines
+func gitLabID(f Finding) string {
+	sum := sha256.Sum256([]byte(f.Artifact + "\x00" + rule(f) + "\x00" + strconv.Itoa(f.Result.LineNumber) + "\x00" + f.Result.Fingerprint))
+	h := fmt.Sprintf("%x", sum[:16])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// gitLabSeverity returns the GitLab name of a severity, such as High
+func gitLabSeverity(severity string) string {
+	if severity == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(severity[:1]) + severity[1:]
+}
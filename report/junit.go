@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// junitSuites is the root of a JUnit XML report
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr,omitempty"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite holds the test cases of one artifact
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr,omitempty"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+// junitCase is a rule checked against an artifact, failed by its findings,
+// or the skipped scan of an artifact
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes findings as a JUnit XML report with a test suite per
+// artifact and a failed test case per rule that found secrets in it, so that
+// CI systems show them in their test result views. Skipped artifacts have a
+// skipped test case. A report without findings has a single passing test
+// case, as some CI systems reject empty reports.
+func WriteJUnit(w io.Writer, findings []Finding, skipped ...Skipped) error {
+	report := junitSuites{Name: "secret-scanner"}
+	suites := make(map[string]int)
+	suiteOf := func(artifact string) *junitSuite {
+		s, ok := suites[artifact]
+		if !ok {
+			s = len(report.Suites)
+			suites[artifact] = s
+			report.Suites = append(report.Suites, junitSuite{Name: artifact})
+		}
+		return &report.Suites[s]
+	}
+	cases := make(map[[2]string]int)
+	counts := make(map[[2]string]int)
+	for _, f := range findings {
+		suite := suiteOf(f.Artifact)
+
+		key := [2]string{f.Artifact, rule(f)}
+		c, ok := cases[key]
+		if !ok {
+			c = len(suite.Cases)
+			cases[key] = c
+			suite.Cases = append(suite.Cases, junitCase{
+				Name:      rule(f),
+				ClassName: f.Artifact,
+				Failure:   &junitFailure{Type: string(f.Result.Severity)},
+			})
+			suite.Tests++
+			suite.Failures++
+		}
+		counts[key]++
+		failure := suite.Cases[c].Failure
+		failure.Text += fmt.Sprintf("%s:%d:%d: %s (%s, confidence %.2f", f.Artifact, f.Result.LineNumber, f.Column,
+			f.Result.Description, f.Result.Type, f.Result.Confidence)
+		if f.Value != "" {
+			failure.Text += ", value " + f.Value
+		}
+		failure.Text += ", fingerprint " + f.Result.Fingerprint + ")\n"
+	}
+	for _, s := range skipped {
+		suite := suiteOf(s.Artifact)
+		suite.Cases = append(suite.Cases, junitCase{Name: "scan", ClassName: s.Artifact, Skipped: &junitSkipped{Message: s.Reason}})
+		suite.Tests++
+		suite.Skipped++
+	}
+	for i := range report.Suites {
+		for _, c := range report.Suites[i].Cases {
+			if c.Failure != nil {
+				c.Failure.Message = fmt.Sprintf("%d potential secret(s) detected", counts[[2]string{c.ClassName, c.Name}])
+			}
+		}
+		report.Tests += report.Suites[i].Tests
+		report.Failures += report.Suites[i].Failures
+		report.Skipped += report.Suites[i].Skipped
+	}
+	if len(report.Suites) == 0 {
+		report.Tests = 1
+		report.Suites = []junitSuite{{Name: "secret-scanner", Tests: 1, Cases: []junitCase{{Name: "no secrets detected", ClassName: "secret-scanner"}}}}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	token := scanner.Result{Type: "github_token", RuleID: "SSA-GITHUB-001", LineNumber: 2, Severity: scanner.SeverityHigh, Description: "Possible GitHub token detected"}
+	findings := []Finding{
+		{Artifact: "a.yml", Column: 8, Value: "gh**uK", Result: token},
+		{Artifact: "a.yml", Column: 8, Value: "gh**uK", Result: token},
+		{Artifact: "b.env", Column: 5, Result: scanner.Result{Type: "aws_access_key", RuleID: "SSA-AWS-001", LineNumber: 1, Severity: scanner.SeverityCritical}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, findings); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	var got junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJUnit wrote invalid XML: %v\n%s", err, buf.String())
+	}
+	if got.Tests != 2 || got.Failures != 2 || len(got.Suites) != 2 {
+		t.Fatalf("WriteJUnit() = %d tests, %d failures, %d suites, want 2, 2, 2", got.Tests, got.Failures, len(got.Suites))
+	}
+	c := got.Suites[0].Cases[0]
+	if c.Name != "SSA-GITHUB-001" || c.ClassName != "a.yml" || c.Failure == nil || c.Failure.Message != "2 potential secret(s) detected" || c.Failure.Type != "high" {
+		t.Errorf("WriteJUnit() test case = %+v, want SSA-GITHUB-001 in a.yml failed by 2 high findings", c)
+	}
+
+	buf.Reset()
+	if err := WriteJUnit(&buf, findings[2:], Skipped{Artifact: "big.log", Reason: "20971520 bytes exceeds -max-size"}); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	got = junitSuites{}
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJUnit wrote invalid XML: %v\n%s", err, buf.String())
+	}
+	if got.Tests != 2 || got.Failures != 1 || got.Skipped != 1 {
+		t.Fatalf("WriteJUnit() = %d tests, %d failures, %d skipped, want 2, 1, 1", got.Tests, got.Failures, got.Skipped)
+	}
+	if c := got.Suites[1].Cases[0]; c.ClassName != "big.log" || c.Skipped == nil || c.Skipped.Message != "20971520 bytes exceeds -max-size" {
+		t.Errorf("WriteJUnit() skipped test case = %+v, want big.log skipped with the reason", c)
+	}
+
+	buf.Reset()
+	if err := WriteJUnit(&buf, nil); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil || got.Tests != 1 || got.Failures != 0 {
+		t.Errorf("WriteJUnit(nil) = %s, want one passing test", buf.String())
+	}
+}
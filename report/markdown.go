@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// markdownSeverities orders the rows of the summary table of Markdown reports
+var markdownSeverities = []scanner.Severity{scanner.SeverityCritical, scanner.SeverityHigh, scanner.SeverityMedium, scanner.SeverityLow}
+
+// markdownCell escapes text for a cell of a Markdown table
+var markdownCell = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ", "`", "'")
+
+// WriteMarkdown writes findings as a Markdown report, with a table counting
+// them by severity, a table listing them, and a table of skipped artifacts,
+// for pull request comments, issues, and wikis
+func WriteMarkdown(w io.Writer, findings []Finding, skipped ...Skipped) error {
+	var b strings.Builder
+	b.WriteString("# Secret scan report\n\n")
+	if len(findings) == 0 {
+		b.WriteString("No secrets detected.\n")
+		writeMarkdownSkipped(&b, skipped)
+		return write(w, b.String())
+	}
+
+	counts := make(map[scanner.Severity]int)
+	for _, f := range findings {
+		counts[f.Result.Severity]++
+	}
+	fmt.Fprintf(&b, "%d potential secret(s) detected.\n\n| Severity | Findings |\n| --- | ---: |\n", len(findings))
+	for _, severity := range markdownSeverities {
+		if counts[severity] > 0 {
+			fmt.Fprintf(&b, "| %s | %d |\n", severity, counts[severity])
+		}
+	}
+	if counts[""] > 0 {
+		fmt.Fprintf(&b, "| unknown | %d |\n", counts[""])
+	}
+
+	b.WriteString("\n## Findings\n\n| File | Line | Rule | Severity | Confidence | Description | Value |\n| --- | ---: | --- | --- | ---: | --- | --- |\n")
+	for _, f := range findings {
+		value := ""
+		if f.Value != "" {
+			value = "`" + markdownCell.Replace(f.Value) + "`"
+		}
+		fmt.Fprintf(&b, "| %s | %d | %s | %s | %.2f | %s | %s |\n", markdownCell.Replace(f.Artifact), f.Result.LineNumber,
+			markdownCell.Replace(rule(f)), f.Result.Severity, f.Result.Confidence, markdownCell.Replace(f.Result.Description), value)
+	}
+	writeMarkdownSkipped(&b, skipped)
+	return write(w, b.String())
+}
+
+// writeMarkdownSkipped writes the table of skipped artifacts, if any
+func writeMarkdownSkipped(b *strings.Builder, skipped []Skipped) {
+	if len(skipped) == 0 {
+		return
+	}
+	b.WriteString("\n## Skipped files\n\n| File | Reason |\n| --- | --- |\n")
+	for _, s := range skipped {
+		fmt.Fprintf(b, "| %s | %s |\n", markdownCell.Replace(s.Artifact), markdownCell.Replace(s.Reason))
+	}
+}
+
+// write writes a whole report to w
+func write(w io.Writer, report string) error {
+	if _, err := io.WriteString(w, report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
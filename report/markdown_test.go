@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []Finding
+		skipped  []Skipped
+		want     string
+	}{
+		{
+			name: "no findings",
+			want: "# Secret scan report\n\nNo secrets detected.\n",
+		},
+		{
+			name:    "skipped files",
+			skipped: []Skipped{{Artifact: "big|log", Reason: "20971520 bytes exceeds -max-size"}},
+			want: "# Secret scan report\n\nNo secrets detected.\n\n## Skipped files\n\n| File | Reason |\n| --- | --- |\n" +
+				"| big\\|log | 20971520 bytes exceeds -max-size |\n",
+		},
+		{
+			name: "findings",
+			findings: []Finding{
+				{Artifact: "config.json", Value: "gh************************************uK", Result: scanner.Result{
+					Type: "github_token", RuleID: "SSA-GITHUB-001", LineNumber: 2, Confidence: 0.9,
+					Severity: scanner.SeverityHigh, Description: "Possible GitHub token detected",
+				}},
+				{Artifact: "a|b.env", Value: "p`w", Result: scanner.Result{
+					Type: "basic_password", LineNumber: 4, Confidence: 0.5,
+					Severity: scanner.SeverityMedium, Description: "Possible\npassword",
+				}},
+			},
+			want: "# Secret scan report\n\n2 potential secret(s) detected.\n\n" +
+				"| Severity | Findings |\n| --- | ---: |\n| high | 1 |\n| medium | 1 |\n\n## Findings\n\n" +
+				"| File | Line | Rule | Severity | Confidence | Description | Value |\n| --- | ---: | --- | --- | ---: | --- | --- |\n" +
+				"| config.json | 2 | SSA-GITHUB-001 | high | 0.90 | Possible GitHub token detected | `gh************************************uK` |\n" +
+				"| a\\|b.env | 4 | basic_password | medium | 0.50 | Possible password | `p'w` |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteMarkdown(&buf, tt.findings, tt.skipped...); err != nil {
+				t.Fatalf("WriteMarkdown failed: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package report writes findings in the formats of the tools they are
+// triaged in, such as spreadsheets and CI systems
+package report
+
+import (
+	"strings"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// Finding is a detected secret at a location in an artifact
+type Finding struct {
+	// Artifact is the file or input the secret was found in
+	Artifact string
+	// Column is the 1-based byte column of the secret on its line
+	Column int
+	// Value is the secret as it may be shown, masked unless masking is off,
+	// or empty if withheld
+	Value  string
+	Result scanner.Result
+}
+
+// Skipped is an artifact that was not scanned, or scanned only in part, such
+// as a file over a size limit
+type Skipped struct {
+	Artifact string
+	Reason   string
+}
+
+// NewFinding returns the finding of result in content, the text of artifact,
+// with value as the secret to show
+func NewFinding(artifact, content, value string, result scanner.Result) Finding {
+	start := min(max(result.StartIndex, 0), len(content))
+	return Finding{
+		Artifact: artifact,
+		Column:   start - strings.LastIndexByte(content[:start], '\n'),
+		Value:    value,
+		Result:   result,
+	}
+}
+
+// rule returns the rule ID of a finding, or its type for rules without one
+func rule(f Finding) string {
+	if f.Result.RuleID != "" {
+		return f.Result.RuleID
+	}
+	return f.Result.Type
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rules embeds the detection rule packs shipped with the scanner and
+// exposes their integrity hashes, so that embedders can assert exactly which
+// detection content their binary ships and report it in their own version
+// endpoints.
+package rules
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+//go:embed packs/*.json
+var packFS embed.FS
+
+// Pack describes an embedded rule pack
+type Pack struct {
+	Name string `json:"name"`
+	// SHA256 is the hex-encoded hash of the pack file
+	SHA256   string `json:"sha256"`
+	Patterns int    `json:"patterns"`
+}
+
+// Info lists the embedded rule packs
+type Info struct {
+	// Version is the ruleset version, scanner.RulesetVersion
+	Version string `json:"version"`
+	// Digest is the hex-encoded SHA-256 of the sha256sum-style listing of every
+	// pack, identifying the detection content as a whole
+	Digest string `json:"digest"`
+	Packs  []Pack `json:"packs"`
+}
+
+var (
+	manifestOnce sync.Once
+	manifest     Info
+)
+
+// Manifest returns the names and hashes of the embedded rule packs, sorted by
+// name
+func Manifest() Info {
+	manifestOnce.Do(func() {
+		manifest.Version = scanner.RulesetVersion
+		names, err := Names()
+		if err != nil {
+			panic(err) // the packs are embedded, so this is a build defect
+		}
+
+		var listing strings.Builder
+		for _, name := range names {
+			content, _ := packFS.ReadFile(file(name))
+			patterns, err := Load(name)
+			if err != nil {
+				panic(err)
+			}
+			sum := sha256.Sum256(content)
+			pack := Pack{Name: name, SHA256: hex.EncodeToString(sum[:]), Patterns: len(patterns)}
+			manifest.Packs = append(manifest.Packs, pack)
+			fmt.Fprintf(&listing, "%s  %s\n", pack.SHA256, name)
+		}
+		digest := sha256.Sum256([]byte(listing.String()))
+		manifest.Digest = hex.EncodeToString(digest[:])
+	})
+
+	info := manifest
+	info.Packs = append([]Pack(nil), manifest.Packs...)
+	return info
+}
+
+// Names returns the names of the embedded rule packs, sorted
+func Names() ([]string, error) {
+	entries, err := packFS.ReadDir("packs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule packs: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns the patterns of an embedded rule pack, mapping pattern names to
+// regular expressions
+func Load(name string) (map[string]string, error) {
+	content, err := packFS.ReadFile(file(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule pack %s: %w", name, err)
+	}
+	var patterns map[string]string
+	if err := json.Unmarshal(content, &patterns); err != nil {
+		return nil, fmt.Errorf("failed to parse rule pack %s: %w", name, err)
+	}
+	return patterns, nil
+}
+
+// LoadAll returns the patterns of every embedded rule pack
+func LoadAll() (map[string]string, error) {
+	names, err := Names()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]string)
+	for _, name := range names {
+		patterns, err := Load(name)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range patterns {
+			all[k] = v
+		}
+	}
+	return all, nil
+}
+
+func file(name string) string {
+	return path.Join("packs", name+".json")
+}
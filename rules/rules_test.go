@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/patterns"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+var update = flag.Bool("update", false, "regenerate the rule packs from the patterns package")
+
+// sources maps each rule pack to the pattern group it is generated from
+var sources = map[string]map[string]string{
+	"api_keys":          patterns.CommonAPIPatterns,
+	"passwords":         patterns.PasswordPatterns,
+	"private_keys":      patterns.PrivateKeyPatterns,
+	"browser_extension": patterns.BrowserExtensionPatterns,
+	"graphql":           patterns.GraphQLPatterns,
+}
+
+func encode(t *testing.T, patterns map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(patterns); err != nil {
+		t.Fatalf("Failed to encode patterns: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPacksMatchPatterns(t *testing.T) {
+	for name, source := range sources {
+		want := encode(t, source)
+		path := filepath.Join("packs", name+".json")
+		if *update {
+			if err := os.WriteFile(path, want, 0o644); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+			continue
+		}
+		got, err := packFS.ReadFile(file(name))
+		if err != nil {
+			t.Fatalf("Failed to read pack %s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Pack %s is out of date; run go test ./rules -update", name)
+		}
+	}
+
+	names, err := Names()
+	if err != nil {
+		t.Fatalf("Names failed: %v", err)
+	}
+	if len(names) != len(sources) {
+		t.Errorf("Names() = %v, want one pack per pattern group", names)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	info := Manifest()
+	if info.Version != scanner.RulesetVersion {
+		t.Errorf("Version = %s, want %s", info.Version, scanner.RulesetVersion)
+	}
+	if len(info.Packs) != len(sources) {
+		t.Fatalf("Manifest has %d packs, want %d", len(info.Packs), len(sources))
+	}
+
+	var listing bytes.Buffer
+	for i, pack := range info.Packs {
+		if i > 0 && info.Packs[i-1].Name >= pack.Name {
+			t.Errorf("Packs are not sorted at %s", pack.Name)
+		}
+		content, err := packFS.ReadFile(file(pack.Name))
+		if err != nil {
+			t.Fatalf("Failed to read pack %s: %v", pack.Name, err)
+		}
+		sum := sha256.Sum256(content)
+		if pack.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("Pack %s hash = %s, want %x", pack.Name, pack.SHA256, sum)
+		}
+		if pack.Patterns != len(sources[pack.Name]) {
+			t.Errorf("Pack %s has %d patterns, want %d", pack.Name, pack.Patterns, len(sources[pack.Name]))
+		}
+		listing.WriteString(pack.SHA256 + "  " + pack.Name + "\n")
+	}
+	digest := sha256.Sum256(listing.Bytes())
+	if info.Digest != hex.EncodeToString(digest[:]) {
+		t.Errorf("Digest = %s, want %x", info.Digest, digest)
+	}
+
+	// Callers must not be able to alter the manifest
+	info.Packs[0].SHA256 = "tampered"
+	if Manifest().Packs[0].SHA256 == "tampered" {
+		t.Error("Manifest returned a shared slice")
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	all, err := LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	want := patterns.GetAllPatterns()
+	for k, v := range patterns.BrowserExtensionPatterns {
+		want[k] = v
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("LoadAll() returned %d patterns, want %d", len(all), len(want))
+	}
+
+	if _, err := Load("missing"); err == nil {
+		t.Error("Load succeeded for a missing pack")
+	}
+}
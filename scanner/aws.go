@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+)
+
+// awsKeyID matches an AWS access key ID: a 4-character prefix such as AKIA
+// (long-term) or ASIA (temporary) followed by 16 base32 characters
+var awsKeyID = regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|AIDA|AROA|AIPA|ANPA|ANVA|APKA|AGPA)([A-Z2-7]{16})\b`)
+
+// decodeAWSAccountID returns the AWS account ID encoded in the first access key
+// ID in value, or "" if there is none. Key IDs issued before 2019, whose fifth
+// character is A through P, do not encode the account.
+func decodeAWSAccountID(value string) string {
+	match := awsKeyID.FindStringSubmatch(value)
+	if match == nil || (match[1][0] >= 'A' && match[1][0] < 'Q') {
+		return ""
+	}
+
+	// The account ID is stored in bits 7 to 46 of the first 6 decoded bytes
+	decoded, err := base32.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return ""
+	}
+	var buf [8]byte
+	copy(buf[2:], decoded[:6])
+	account := (binary.BigEndian.Uint64(buf[:]) & 0x7fffffffff80) >> 7
+	return fmt.Sprintf("%012d", account)
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithBase64Decoding makes Scan decode base64-looking substrings, such as the
+// data of a Kubernetes Secret, and scan the decoded content too. It is
+// shorthand for WithDecoders(Base64Decoder{}).
+func WithBase64Decoding(enabled bool) ScannerOption {
+	return func(s *Scanner) {
+		if enabled {
+			s.decoders = append(s.decoders, Base64Decoder{})
+		}
+	}
+}
+
+// Base64Decoder decodes standard and URL-safe base64 substrings of printable
+// text or of gzip-compressed data, which GzipDecoder can then inflate
+type Base64Decoder struct{}
+
+// base64Blob matches candidate standard or URL-safe base64 substrings. Shorter
+// runs are mostly identifiers and rarely hide a whole secret.
+var base64Blob = regexp.MustCompile(`[A-Za-z0-9+/_-]{16,}={0,2}`)
+
+// base64Encodings are tried in order on each candidate
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// Name implements Decoder
+func (Base64Decoder) Name() string { return "base64" }
+
+// Decode implements Decoder
+func (Base64Decoder) Decode(text string) []Decoded {
+	var decoded []Decoded
+	for _, loc := range base64Blob.FindAllStringIndex(text, -1) {
+		if plain, ok := decodeBase64(text[loc[0]:loc[1]]); ok {
+			decoded = append(decoded, Decoded{Start: loc[0], End: loc[1], Text: plain})
+		}
+	}
+	return decoded
+}
+
+// decodeBase64 decodes blob if it is base64 of printable text or of gzip data.
+// Other binary payloads are skipped since patterns match text.
+func decodeBase64(blob string) (string, bool) {
+	for _, enc := range base64Encodings {
+		decoded, err := enc.DecodeString(blob)
+		if err != nil {
+			continue
+		}
+		return string(decoded), isPrintable(decoded) || strings.HasPrefix(string(decoded), gzipMagic)
+	}
+	return "", false
+}
+
+func isPrintable(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import "sync"
+
+// DefaultCacheSize is the number of bytes of scanned text whose results are
+// cached unless WithCacheSize is given
+const DefaultCacheSize = 64 * 1024 * 1024
+
+// WithCacheSize bounds the cache of scan results to n bytes of scanned text.
+// The cache is emptied when a scan would overflow it, and texts larger than n
+// are not cached. A size of 0 disables the cache, which suits long-running
+// services such as HTTP or gRPC filters whose inputs rarely repeat.
+func WithCacheSize(n int) ScannerOption {
+	return func(s *Scanner) {
+		if n >= 0 {
+			s.cacheSize = n
+		}
+	}
+}
+
+// resultCache maps scanned texts to their results, holding at most limit
+// bytes of text
+type resultCache struct {
+	mu      sync.RWMutex
+	entries map[string][]Result
+	size    int
+	limit   int
+}
+
+func newResultCache(limit int) *resultCache {
+	return &resultCache{entries: make(map[string][]Result), limit: limit}
+}
+
+func (c *resultCache) load(text string) ([]Result, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	results, ok := c.entries[text]
+	return results, ok
+}
+
+func (c *resultCache) store(text string, results []Result) {
+	if len(text) > c.limit {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[text]; ok {
+		return
+	}
+	if c.size+len(text) > c.limit {
+		clear(c.entries)
+		c.size = 0
+	}
+	c.entries[text] = results
+	c.size += len(text)
+}
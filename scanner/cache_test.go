@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithCacheSize(t *testing.T) {
+	texts := []string{"token secret123", "token secret456", "token secret789"}
+
+	tests := []struct {
+		name   string
+		size   int
+		cached []bool // whether each text is cached after all are scanned
+	}{
+		{name: "Default size", size: DefaultCacheSize, cached: []bool{true, true, true}},
+		{name: "Emptied when full", size: 2 * len(texts[0]), cached: []bool{false, false, true}},
+		{name: "Disabled", size: 0, cached: []bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(WithCacheSize(tt.size))
+			if err := s.AddPattern("test", `secret[0-9]+`); err != nil {
+				t.Fatalf("Failed to add pattern: %v", err)
+			}
+			for _, text := range texts {
+				if _, err := s.Scan(context.Background(), text); err != nil {
+					t.Fatalf("Scan failed: %v", err)
+				}
+			}
+			for i, text := range texts {
+				if _, ok := s.cache.load(text); ok != tt.cached[i] {
+					t.Errorf("Text %d cached = %v, want %v", i, ok, tt.cached[i])
+				}
+			}
+			if s.cache.size > tt.size {
+				t.Errorf("Cache holds %d bytes, want at most %d", s.cache.size, tt.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset is the character encoding of scanned text
+type Charset string
+
+// Charsets that Scan detects and transcodes to UTF-8 before matching
+const (
+	CharsetUTF8    Charset = "utf-8"
+	CharsetUTF16LE Charset = "utf-16le"
+	CharsetUTF16BE Charset = "utf-16be"
+	CharsetLatin1  Charset = "latin-1"
+)
+
+// charsetSample is how much of the text DetectCharset looks at for the NUL
+// bytes of BOM-less UTF-16
+const charsetSample = 4096
+
+// DetectCharset returns the encoding of text: UTF-16 with a byte order mark,
+// or without one when nearly every other byte is NUL as in ASCII text saved as
+// UTF-16 by Windows tools and PowerShell; Latin-1 for text that is not valid
+// UTF-8 and has no control characters; and UTF-8 otherwise.
+func DetectCharset(text string) Charset {
+	switch {
+	case strings.HasPrefix(text, "\xff\xfe"):
+		return CharsetUTF16LE
+	case strings.HasPrefix(text, "\xfe\xff"):
+		return CharsetUTF16BE
+	}
+
+	sample := text[:min(len(text), charsetSample)]
+	if strings.IndexByte(sample, 0) >= 0 {
+		var nuls [2]int
+		for i := 0; i < len(sample); i++ {
+			if sample[i] == 0 {
+				nuls[i%2]++
+			}
+		}
+		pairs := len(sample) / 2
+		switch {
+		case pairs == 0:
+		case nuls[0] == 0 && nuls[1]*10 >= pairs*9:
+			return CharsetUTF16LE
+		case nuls[1] == 0 && nuls[0]*10 >= pairs*9:
+			return CharsetUTF16BE
+		}
+		return CharsetUTF8
+	}
+
+	if !utf8.ValidString(text) && !hasControl(text) {
+		return CharsetLatin1
+	}
+	return CharsetUTF8
+}
+
+// IsBinary reports whether data is binary rather than text: empty, or with
+// NUL bytes that are not part of UTF-16 text
+func IsBinary(data []byte) bool {
+	if len(data) == 0 {
+		return true
+	}
+	if strings.IndexByte(string(data), 0) < 0 {
+		return false
+	}
+	charset := DetectCharset(string(data))
+	return charset != CharsetUTF16LE && charset != CharsetUTF16BE
+}
+
+// hasControl reports whether text has control characters other than
+// whitespace
+func hasControl(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if c := text[i]; c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// transcode converts text in charset to UTF-8, dropping any byte order mark.
+// offsets[i] is the index in text of the character that produced byte i of
+// the result, and offsets[len(result)] is len(text).
+func transcode(text string, charset Charset) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	emit := func(start int, r rune) {
+		n := b.Len()
+		b.WriteRune(r)
+		for j := n; j < b.Len(); j++ {
+			offsets = append(offsets, start)
+		}
+	}
+
+	switch charset {
+	case CharsetUTF16LE, CharsetUTF16BE:
+		unit := func(i int) rune {
+			if charset == CharsetUTF16LE {
+				return rune(text[i]) | rune(text[i+1])<<8
+			}
+			return rune(text[i])<<8 | rune(text[i+1])
+		}
+		i := 0
+		if strings.HasPrefix(text, "\xff\xfe") || strings.HasPrefix(text, "\xfe\xff") {
+			i = 2
+		}
+		for i+1 < len(text) {
+			r, n := unit(i), 2
+			if utf16.IsSurrogate(r) && i+3 < len(text) {
+				if pair := utf16.DecodeRune(r, unit(i+2)); pair != utf8.RuneError {
+					r, n = pair, 4
+				}
+			}
+			emit(i, r)
+			i += n
+		}
+	case CharsetLatin1:
+		for i := 0; i < len(text); i++ {
+			emit(i, rune(text[i]))
+		}
+	default:
+		return text, nil
+	}
+	offsets = append(offsets, len(text))
+	return b.String(), offsets
+}
+
+// untranscode moves results found in transcoded text to their position in
+// the original text, whose lines are the same
+func untranscode(results []Result, offsets []int) []Result {
+	moved := make([]Result, len(results))
+	for i, result := range results {
+		result.StartIndex, result.EndIndex = offsets[result.StartIndex], offsets[result.EndIndex]
+		moved[i] = result
+	}
+	return moved
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithContextLines captures n lines before and after each match, along with
+// the lines of the match itself, in Result.Context
+func WithContextLines(n int) ScannerOption {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.contextLines = n
+		}
+	}
+}
+
+// WithContextChars captures n characters before and after each match in
+// Result.Context. WithContextLines takes precedence if both are set.
+func WithContextChars(n int) ScannerOption {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.contextChars = n
+		}
+	}
+}
+
+// addContext sets the context of each result from the chunk it was found in.
+// Every span in masked, given by its offsets in the scanned text, is masked, so
+// that a snippet never shows the secret it belongs to or any other match
+// nearby, including ones that are not reported.
+func (s *Scanner) addContext(chunk string, offset int, results []Result, masked [][2]int) {
+	spans := make([][2]int, 0, len(masked)+len(results))
+	for _, span := range masked {
+		spans = append(spans, [2]int{span[0] - offset, span[1] - offset})
+	}
+	for _, result := range results {
+		spans = append(spans, [2]int{result.StartIndex - offset, result.EndIndex - offset})
+	}
+	spans = mergeSpans(spans)
+
+	for i := range results {
+		start, end := results[i].StartIndex-offset, results[i].EndIndex-offset
+		if s.contextLines > 0 {
+			start, end = lineWindow(chunk, start, end, s.contextLines)
+		} else {
+			start, end = charWindow(chunk, start, end, s.contextChars)
+		}
+		results[i].Context = s.maskSpans(chunk, start, end, spans)
+	}
+}
+
+// lineWindow widens [start, end) to whole lines plus n lines on each side
+func lineWindow(text string, start, end, n int) (int, int) {
+	start = strings.LastIndexByte(text[:start], '\n') + 1
+	for i := 0; i < n && start > 0; i++ {
+		start = strings.LastIndexByte(text[:start-1], '\n') + 1
+	}
+
+	if next := strings.IndexByte(text[end:], '\n'); next >= 0 {
+		end += next
+	} else {
+		end = len(text)
+	}
+	for i := 0; i < n && end < len(text); i++ {
+		if next := strings.IndexByte(text[end+1:], '\n'); next >= 0 {
+			end += next + 1
+		} else {
+			end = len(text)
+		}
+	}
+	return start, end
+}
+
+// charWindow widens [start, end) by n bytes on each side, kept on rune boundaries
+func charWindow(text string, start, end, n int) (int, int) {
+	start, end = max(start-n, 0), min(end+n, len(text))
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	return start, end
+}
+
+// maskSpans returns text[start:end] with the parts covered by spans masked
+func (s *Scanner) maskSpans(text string, start, end int, spans [][2]int) string {
+	var b strings.Builder
+	pos := start
+	for _, span := range spans {
+		from, to := max(span[0], start), min(span[1], end)
+		if from >= to {
+			continue
+		}
+		b.WriteString(text[pos:from])
+		b.WriteString(s.maskContext(text[from:to]))
+		pos = to
+	}
+	b.WriteString(text[pos:end])
+	return b.String()
+}
+
+// maskContext masks a secret within a context snippet. Secure mode hides it
+// completely, whatever the masking options.
+func (s *Scanner) maskContext(secret string) string {
+	if s.secure || s.masking.None || s.masking.Hash {
+		return MaskOptions{Rune: s.masking.Rune}.Mask(secret)
+	}
+	return s.masking.Mask(secret)
+}
+
+// mergeSpans sorts spans and joins the ones that overlap
+func mergeSpans(spans [][2]int) [][2]int {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var merged [][2]int
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], span[1])
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Decoder finds encoded substrings of text and decodes them, so that secrets
+// hidden behind an encoding are scanned too. See WithDecoders.
+type Decoder interface {
+	// Name identifies the encoding in Result.Encoding
+	Name() string
+	// Decode returns the decoded content of each encoded substring of text
+	Decode(text string) []Decoded
+}
+
+// Decoded is the decoded content of text[Start:End]
+type Decoded struct {
+	Start, End int
+	Text       string
+}
+
+// DefaultDecodeDepth is how many layers of encoding are peeled unless
+// WithDecodeDepth is given
+const DefaultDecodeDepth = 3
+
+// maxDecodedSize bounds the output of a single decoding, so that a
+// decompression bomb cannot exhaust memory
+const maxDecodedSize = 10 * 1024 * 1024
+
+// WithDecoders makes Scan apply each decoder to the scanned text and scan the
+// decoded content too, recursively up to the decode depth, so that nested
+// encodings such as base64 inside a JSON string are still caught. Findings in
+// decoded content have Encoded and Encoding set and are reported at the
+// position and line of the outermost encoded substring.
+func WithDecoders(decoders ...Decoder) ScannerOption {
+	return func(s *Scanner) {
+		s.decoders = append(s.decoders, decoders...)
+	}
+}
+
+// WithDecodeDepth sets how many layers of encoding decoders peel
+func WithDecodeDepth(n int) ScannerOption {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.decodeDepth = n
+		}
+	}
+}
+
+// LookupDecoder returns the built-in decoder with the given name: base64,
+// gzip, url, or json
+func LookupDecoder(name string) (Decoder, error) {
+	for _, d := range []Decoder{Base64Decoder{}, GzipDecoder{}, URLDecoder{}, JSONStringDecoder{}} {
+		if d.Name() == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown decoder %q", name)
+}
+
+// scanEncoded returns the findings in the unescaped, normalized, and decoded
+// forms of text that the scan of the raw text, raw, missed
+func (s *Scanner) scanEncoded(ctx context.Context, text string, raw []Result) ([]Result, error) {
+	var encoded []Result
+	if s.unescape {
+		unescaped, err := s.scanUnescaped(ctx, text, raw)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, unescaped...)
+	}
+	if s.normalize {
+		normalized, err := s.scanNormalized(ctx, text, raw)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, normalized...)
+	}
+	if len(s.decoders) > 0 {
+		decoded, err := s.scanDecoded(ctx, text, s.decodeDepth)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, dropVisible(decoded, raw)...)
+	}
+	if len(encoded) > 0 && (s.contextLines > 0 || s.contextChars > 0) {
+		spans := make([][2]int, 0, len(raw))
+		for _, result := range raw {
+			spans = append(spans, [2]int{result.StartIndex, result.EndIndex})
+		}
+		s.addContext(text, 0, encoded, spans)
+	}
+	return encoded, nil
+}
+
+// scanDecoded scans the decoded content of the encoded substrings of text,
+// peeling up to depth layers of encoding
+func (s *Scanner) scanDecoded(ctx context.Context, text string, depth int) ([]Result, error) {
+	var encoded []Result
+	for _, d := range s.decoders {
+		for _, dec := range d.Decode(text) {
+			results, err := s.scan(ctx, dec.Text)
+			if err != nil {
+				return nil, err
+			}
+			if depth > 1 {
+				nested, err := s.scanDecoded(ctx, dec.Text, depth-1)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results[:len(results):len(results)], nested...)
+			}
+
+			line := strings.Count(text[:dec.Start], "\n") + 1
+			for _, result := range results {
+				result.Encoded = true
+				if result.Encoding == "" {
+					result.Encoding = d.Name()
+				} else {
+					result.Encoding = d.Name() + "+" + result.Encoding
+				}
+				result.StartIndex, result.EndIndex = dec.Start, dec.End
+				result.LineNumber, result.EndLineNumber = line, 0
+				result.Context = ""
+				encoded = append(encoded, result)
+			}
+		}
+	}
+	return dedupeDecoded(encoded), nil
+}
+
+// dedupeDecoded drops findings of the same secret at the same place reached
+// through different decoders, keeping the first
+func dedupeDecoded(results []Result) []Result {
+	type key struct {
+		start       int
+		fingerprint string
+	}
+	seen := make(map[key]bool)
+	deduped := results[:0]
+	for _, result := range results {
+		k := key{result.StartIndex, result.Fingerprint}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		deduped = append(deduped, result)
+	}
+	return deduped
+}
+
+// dropVisible drops decoded findings of secrets that are also found in the
+// raw text within the encoded substring, as a key in a URL-encoded query
+// string is
+func dropVisible(encoded, raw []Result) []Result {
+	var kept []Result
+	for _, enc := range encoded {
+		visible := false
+		for _, r := range raw {
+			if r.Fingerprint == enc.Fingerprint && enc.StartIndex <= r.StartIndex && r.StartIndex < enc.EndIndex {
+				visible = true
+				break
+			}
+		}
+		if !visible {
+			kept = append(kept, enc)
+		}
+	}
+	return kept
+}
+
+// GzipDecoder inflates gzip streams embedded in text, as produced by
+// Base64Decoder from base64-encoded gzip data
+type GzipDecoder struct{}
+
+// gzipMagic starts every gzip stream: the ID bytes and the deflate method
+const gzipMagic = "\x1f\x8b\x08"
+
+// Name implements Decoder
+func (GzipDecoder) Name() string { return "gzip" }
+
+// Decode implements Decoder
+func (GzipDecoder) Decode(text string) []Decoded {
+	var decoded []Decoded
+	for base := 0; ; {
+		i := strings.Index(text[base:], gzipMagic)
+		if i < 0 {
+			return decoded
+		}
+		start := base + i
+		base = start + len(gzipMagic)
+
+		r := bytes.NewReader([]byte(text[start:]))
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			continue
+		}
+		zr.Multistream(false)
+		plain, err := io.ReadAll(io.LimitReader(zr, maxDecodedSize))
+		if err != nil || !isPrintable(plain) {
+			continue
+		}
+		end := len(text) - r.Len()
+		decoded = append(decoded, Decoded{Start: start, End: end, Text: string(plain)})
+		base = end
+	}
+}
+
+// URLDecoder decodes percent-encoded substrings, such as query strings and
+// form bodies
+type URLDecoder struct{}
+
+// urlEncoded matches runs of URL characters with at least one percent escape
+var urlEncoded = regexp.MustCompile(`[^\s"'<>` + "`" + `]*%[0-9A-Fa-f]{2}[^\s"'<>` + "`" + `]*`)
+
+// Name implements Decoder
+func (URLDecoder) Name() string { return "url" }
+
+// Decode implements Decoder
+func (URLDecoder) Decode(text string) []Decoded {
+	var decoded []Decoded
+	for _, loc := range urlEncoded.FindAllStringIndex(text, -1) {
+		plain, err := url.QueryUnescape(text[loc[0]:loc[1]])
+		if err != nil || plain == text[loc[0]:loc[1]] {
+			continue
+		}
+		decoded = append(decoded, Decoded{Start: loc[0], End: loc[1], Text: plain})
+	}
+	return decoded
+}
+
+// JSONStringDecoder unescapes JSON string literals with backslash escapes,
+// such as a PEM key with \n line breaks or a document serialized into a
+// string
+type JSONStringDecoder struct{}
+
+// jsonString matches a double-quoted string literal containing an escape
+var jsonString = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*\\(?:[^"\\\n]|\\.)*"`)
+
+// Name implements Decoder
+func (JSONStringDecoder) Name() string { return "json" }
+
+// Decode implements Decoder
+func (JSONStringDecoder) Decode(text string) []Decoded {
+	var decoded []Decoded
+	for _, loc := range jsonString.FindAllStringIndex(text, -1) {
+		var plain string
+		if err := json.Unmarshal([]byte(text[loc[0]:loc[1]]), &plain); err != nil {
+			continue
+		}
+		decoded = append(decoded, Decoded{Start: loc[0], End: loc[1], Text: plain})
+	}
+	return decoded
+}
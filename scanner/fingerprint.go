@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Fingerprint returns the salted SHA-256 of a rule name and secret value, as
+// set on Result.Fingerprint. It is stable across scans with the same salt, for
+// baselines, deduplication, and suppression lists.
+func (s *Scanner) Fingerprint(rule, value string) string {
+	return computeFingerprint(s.salt, rule, value)
+}
+
+func computeFingerprint(salt []byte, rule, value string) string {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte{0})
+	h.Write([]byte(rule))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil))
+}
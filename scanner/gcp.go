@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ServiceAccount identifies the Google Cloud service account a leaked key file
+// belongs to
+type ServiceAccount struct {
+	ProjectID   string `json:"project_id,omitempty"`
+	ClientEmail string `json:"client_email,omitempty"`
+}
+
+var (
+	serviceAccountType  = regexp.MustCompile(`"type"\s*:\s*"service_account"`)
+	serviceAccountField = regexp.MustCompile(`"(project_id|client_email)"\s*:\s*"([^"]*)"`)
+)
+
+// parseServiceAccount returns the project and client email of the service
+// account key file in match, or nil if match is not one
+func parseServiceAccount(match string) *ServiceAccount {
+	if !serviceAccountType.MatchString(match) {
+		return nil
+	}
+	account := &ServiceAccount{}
+	for _, field := range serviceAccountField.FindAllStringSubmatch(match, -1) {
+		switch field[1] {
+		case "project_id":
+			account.ProjectID = field[2]
+		case "client_email":
+			account.ClientEmail = field[2]
+		}
+	}
+	// Fall back on the project in the email, as in sa@project.iam.gserviceaccount.com
+	if account.ProjectID == "" {
+		if _, domain, ok := strings.Cut(account.ClientEmail, "@"); ok {
+			account.ProjectID, _, _ = strings.Cut(domain, ".")
+		}
+	}
+	if *account == (ServiceAccount{}) {
+		return nil
+	}
+	return account
+}
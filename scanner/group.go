@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+// Location is where one occurrence of a grouped secret was found
+type Location struct {
+	StartIndex int `json:"start_index"`
+	EndIndex   int `json:"end_index"`
+	LineNumber int `json:"line_number"`
+}
+
+// WithGrouping makes Scan return one result per distinct secret, with an
+// occurrence count and the location of every occurrence, instead of one
+// result per occurrence
+func WithGrouping(enabled bool) ScannerOption {
+	return func(s *Scanner) {
+		s.group = enabled
+	}
+}
+
+// GroupResults aggregates results with the same fingerprint into the first
+// occurrence, setting Occurrences and Locations. Groups are ordered by their
+// first occurrence. Results without a fingerprint or value are not grouped.
+func GroupResults(results []Result) []Result {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sortByPosition(sorted)
+
+	var grouped []Result
+	index := make(map[string]int)
+	for _, result := range sorted {
+		loc := Location{StartIndex: result.StartIndex, EndIndex: result.EndIndex, LineNumber: result.LineNumber}
+		key := fingerprint(result)
+		if i, ok := index[key]; ok && key != "" {
+			grouped[i].Occurrences++
+			grouped[i].Locations = append(grouped[i].Locations, loc)
+			continue
+		}
+		if key != "" {
+			index[key] = len(grouped)
+		}
+		result.Occurrences = 1
+		result.Locations = []Location{loc}
+		grouped = append(grouped, result)
+	}
+	return grouped
+}
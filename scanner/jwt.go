@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// JWT is metadata decoded from a JSON Web Token. The signature is not verified,
+// so the claims only help triage, such as deprioritizing expired tokens.
+type JWT struct {
+	Algorithm string `json:"algorithm,omitempty"`
+	Issuer    string `json:"issuer,omitempty"`
+	// ExpiresAt is nil when the token has no exp claim
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Expired reports whether ExpiresAt had passed at scan time
+	Expired bool `json:"expired"`
+}
+
+// jwtToken matches the header and claims segments of a JWT, which both encode
+// a JSON object and therefore start with eyJ
+var jwtToken = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+={0,2}\.eyJ[A-Za-z0-9_-]+={0,2}`)
+
+// decodeJWT returns the metadata of the first JWT in value, or nil if value
+// holds no decodable token
+func decodeJWT(value string, now time.Time) *JWT {
+	token := jwtToken.FindString(value)
+	if token == "" {
+		return nil
+	}
+	segments := strings.SplitN(token, ".", 2)
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var claims struct {
+		Iss string      `json:"iss"`
+		Exp json.Number `json:"exp"`
+	}
+	if !decodeSegment(segments[0], &header) || !decodeSegment(segments[1], &claims) {
+		return nil
+	}
+
+	info := &JWT{Algorithm: header.Alg, Issuer: claims.Iss}
+	if exp, err := claims.Exp.Float64(); err == nil && claims.Exp != "" {
+		expiresAt := time.Unix(int64(exp), 0).UTC()
+		info.ExpiresAt = &expiresAt
+		info.Expired = !now.Before(expiresAt)
+	}
+	return info
+}
+
+func decodeSegment(segment string, v interface{}) bool {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
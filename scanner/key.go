@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"strings"
+)
+
+// KeyInfo is metadata parsed from a PEM private key block
+type KeyInfo struct {
+	// Type is RSA, EC, Ed25519, X25519, or DSA. It is empty for an encrypted
+	// PKCS#8 key, whose algorithm is only known after decryption.
+	Type string `json:"type,omitempty"`
+	// Bits is the key size, such as 2048 for RSA or 256 for P-256
+	Bits int `json:"bits,omitempty"`
+	// Encrypted reports whether the key is protected by a passphrase
+	Encrypted bool `json:"encrypted"`
+}
+
+// parseKey returns the metadata of the first complete PEM private key block in
+// value, or nil if there is none or it cannot be parsed
+func parseKey(value string) *KeyInfo {
+	start := strings.Index(value, "-----BEGIN ")
+	if start < 0 {
+		return nil
+	}
+	block, _ := pem.Decode([]byte(value[start:]))
+	if block == nil {
+		return nil
+	}
+
+	label := strings.TrimSuffix(block.Type, " PRIVATE KEY")
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return &KeyInfo{Encrypted: true}
+	}
+	if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		// Legacy encrypted PEM: the label names the algorithm, the size is encrypted
+		return &KeyInfo{Type: label, Encrypted: true}
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			return &KeyInfo{Type: "RSA", Bits: key.N.BitLen()}
+		}
+	case "EC PRIVATE KEY":
+		if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+			return &KeyInfo{Type: "EC", Bits: key.Curve.Params().BitSize}
+		}
+	case "DSA PRIVATE KEY":
+		var key struct {
+			Version       int
+			P, Q, G, Y, X *big.Int
+		}
+		if _, err := asn1.Unmarshal(block.Bytes, &key); err == nil {
+			return &KeyInfo{Type: "DSA", Bits: key.P.BitLen()}
+		}
+	case "PRIVATE KEY":
+		if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			return pkcs8Info(key)
+		}
+	case "OPENSSH PRIVATE KEY":
+		return parseOpenSSHKey(block.Bytes)
+	}
+	return nil
+}
+
+func pkcs8Info(key interface{}) *KeyInfo {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &KeyInfo{Type: "RSA", Bits: k.N.BitLen()}
+	case *ecdsa.PrivateKey:
+		return &KeyInfo{Type: "EC", Bits: k.Curve.Params().BitSize}
+	case ed25519.PrivateKey:
+		return &KeyInfo{Type: "Ed25519", Bits: 256}
+	case *ecdh.PrivateKey:
+		return &KeyInfo{Type: "X25519", Bits: 256}
+	}
+	return nil
+}
+
+// parseOpenSSHKey reads the cipher and public key of an openssh-key-v1 blob,
+// which are stored in the clear even when the private key is encrypted
+func parseOpenSSHKey(data []byte) *KeyInfo {
+	const magic = "openssh-key-v1\x00"
+	if !bytes.HasPrefix(data, []byte(magic)) {
+		return nil
+	}
+	r := sshReader{data: data[len(magic):]}
+	cipher := r.string()
+	r.string() // kdf name
+	r.string() // kdf options
+	if n := r.uint32(); n != 1 {
+		return nil
+	}
+	pub := sshReader{data: []byte(r.string())}
+	if r.err {
+		return nil
+	}
+
+	info := &KeyInfo{Encrypted: cipher != "none"}
+	switch keyType := pub.string(); {
+	case keyType == "ssh-rsa":
+		pub.string() // public exponent
+		info.Type, info.Bits = "RSA", new(big.Int).SetBytes([]byte(pub.string())).BitLen()
+	case keyType == "ssh-ed25519":
+		info.Type, info.Bits = "Ed25519", 256
+	case keyType == "ssh-dss":
+		info.Type, info.Bits = "DSA", new(big.Int).SetBytes([]byte(pub.string())).BitLen()
+	case strings.HasPrefix(keyType, "ecdsa-sha2-nistp"):
+		info.Type = "EC"
+		switch strings.TrimPrefix(keyType, "ecdsa-sha2-nistp") {
+		case "256":
+			info.Bits = 256
+		case "384":
+			info.Bits = 384
+		case "521":
+			info.Bits = 521
+		}
+	default:
+		return nil
+	}
+	if pub.err {
+		return nil
+	}
+	return info
+}
+
+// sshReader reads the length-prefixed fields of the SSH wire format
+type sshReader struct {
+	data []byte
+	err  bool
+}
+
+func (r *sshReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.err = true
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *sshReader) string() string {
+	n := r.uint32()
+	if r.err || uint64(n) > uint64(len(r.data)) {
+		r.err = true
+		return ""
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"math"
+	"regexp"
+)
+
+// DefaultKeywordWindow is how many bytes on each side of a match are searched
+// for the context keywords of its pattern unless WithKeywordWindow is given
+const DefaultKeywordWindow = 256
+
+// contextBoost is added to the confidence of matches with a context keyword
+// nearby
+const contextBoost = 0.05
+
+// contextKeywords are provider names and hostnames whose presence near a
+// match, such as a workspace URL on the line above a token, makes it more
+// likely to be a credential of that provider
+var contextKeywords = map[string]*regexp.Regexp{
+	"databricks_token":          regexp.MustCompile(`(?i)databricks`),
+	"azure_ad_client_secret":    regexp.MustCompile(`(?i)azure|client[_-]?secret|tenant`),
+	"discord_bot_token":         regexp.MustCompile(`(?i)discord|bot`),
+	"telegram_bot_token":        regexp.MustCompile(`(?i)telegram|bot`),
+	"datadog_api_key":           regexp.MustCompile(`(?i)datadog|\bdd_(?:api|app|application)_?key|\bddog|datadoghq`),
+	"datadog_app_key":           regexp.MustCompile(`(?i)datadog|\bdd_(?:api|app|application)_?key|\bddog|datadoghq`),
+	"cloudflare_api_token":      regexp.MustCompile(`(?i)cloudflare|\bcf_(?:api_)?(?:token|key)|x-auth-key`),
+	"cloudflare_global_api_key": regexp.MustCompile(`(?i)cloudflare|\bcf_(?:api_)?(?:token|key)|x-auth-key`),
+	"okta_api_token":            regexp.MustCompile(`(?i)\bssws\b|okta`),
+	"vault_legacy_token":        regexp.MustCompile(`(?i)vault`),
+	"netlify_legacy_token":      regexp.MustCompile(`(?i)netlify`),
+	"vercel_token":              regexp.MustCompile(`(?i)vercel|\bnow_token`),
+	"mongodb_connection_string": regexp.MustCompile(`(?i)\.mongodb\.net`),
+	"msmtp_password":            regexp.MustCompile(`(?im)^[ \t]*(?:host[ \t]+smtp|auth[ \t]+on|account[ \t])|msmtp`),
+	"pinterest_access_token":    regexp.MustCompile(`(?i)pinterest|api\.pinimg\.com`),
+	"bitbucket_access_token":    regexp.MustCompile(`(?i)bitbucket`),
+	"digitalocean_access_token": regexp.MustCompile(`(?i)digitalocean|\bdo_(?:api_|access_)?token|\bdoctl`),
+	"azure_storage_account_key": regexp.MustCompile(`(?i)azure|account_?key|\.core\.windows\.net`),
+}
+
+// requiredContext lists the patterns whose matches are dropped unless one of
+// their context keywords is nearby, because the shape alone, such as a bare
+// number and colon, is too common to report
+var requiredContext = map[string]bool{
+	"telegram_bot_token":        true,
+	"datadog_api_key":           true,
+	"datadog_app_key":           true,
+	"cloudflare_api_token":      true,
+	"cloudflare_global_api_key": true,
+	"okta_api_token":            true,
+	"vault_legacy_token":        true,
+	"netlify_legacy_token":      true,
+	"vercel_token":              true,
+	"msmtp_password":            true,
+	"pinterest_access_token":    true,
+	"bitbucket_access_token":    true,
+	"digitalocean_access_token": true,
+	"azure_storage_account_key": true,
+}
+
+// WithKeywordWindow searches n bytes on each side of a match for the context
+// keywords of its pattern, instead of DefaultKeywordWindow
+func WithKeywordWindow(n int) ScannerOption {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.keywordWindow = n
+		}
+	}
+}
+
+// RequiresContext reports whether matches of pattern are dropped unless one of
+// its context keywords is nearby
+func RequiresContext(pattern string) bool {
+	return requiredContext[pattern]
+}
+
+// InContext reports whether a match of pattern at text[start:end] has one of
+// the pattern's context keywords within window bytes, if the pattern requires
+// one
+func InContext(pattern, text string, start, end, window int) bool {
+	if !requiredContext[pattern] {
+		return true
+	}
+	keywords, ok := contextKeywords[pattern]
+	return ok && keywords.MatchString(nearby(text, start, end, window))
+}
+
+// withContext returns the confidence of a match of pattern at chunk[start:end],
+// raised if one of the pattern's context keywords is within window bytes
+func withContext(pattern, chunk string, start, end, window int, confidence float64) float64 {
+	keywords, ok := contextKeywords[pattern]
+	if !ok || !keywords.MatchString(nearby(chunk, start, end, window)) {
+		return confidence
+	}
+	return math.Round(math.Min(confidence+contextBoost, 1)*100) / 100
+}
+
+// nearby returns the text of chunk within window bytes of chunk[start:end],
+// including the match itself
+func nearby(chunk string, start, end, window int) string {
+	return chunk[max(start-window, 0):min(end+window, len(chunk))]
+}
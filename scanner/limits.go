@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTooLarge is returned by Scan for text over the size set with
+	// WithMaxSize
+	ErrTooLarge = errors.New("text exceeds maximum scan size")
+	// ErrTimeout is returned by Scan when a scan runs longer than the time
+	// set with WithTimeout
+	ErrTimeout = errors.New("scan timed out")
+)
+
+// WithMaxSize makes Scan refuse text longer than n bytes with ErrTooLarge,
+// so that callers can skip huge inputs such as multi-gigabyte logs. Zero, the
+// default, means no limit.
+func WithMaxSize(n int) ScannerOption {
+	return func(s *Scanner) {
+		s.maxSize = n
+	}
+}
+
+// WithTimeout bounds the time of each call to Scan, which then fails with
+// ErrTimeout. Zero, the default, means no limit.
+func WithTimeout(d time.Duration) ScannerOption {
+	return func(s *Scanner) {
+		s.timeout = d
+	}
+}
+
+// checkSize returns ErrTooLarge if text is over the maximum size
+func (s *Scanner) checkSize(text string) error {
+	if s.maxSize > 0 && len(text) > s.maxSize {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrTooLarge, len(text), s.maxSize)
+	}
+	return nil
+}
+
+// withTimeout returns ctx bounded by the scan timeout
+func (s *Scanner) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// timeoutError returns ErrTimeout for a scan error caused by the scan
+// timeout, rather than by the caller's context, and err otherwise
+func (s *Scanner) timeoutError(parent context.Context, err error) error {
+	if s.timeout > 0 && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		return fmt.Errorf("%w after %s", ErrTimeout, s.timeout)
+	}
+	return err
+}
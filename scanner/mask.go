@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// MaskOptions controls how secret values are masked for display
+type MaskOptions struct {
+	// Rune replaces hidden characters (default '*')
+	Rune rune
+	// Prefix and Suffix are the number of characters exposed at each end.
+	// Nothing is exposed if they would reveal the whole value.
+	Prefix int
+	Suffix int
+	// Length, if positive, fixes the number of mask characters so that the
+	// output does not reveal the length of the secret
+	Length int
+	// Hash replaces the whole value with its SHA-256 digest
+	Hash bool
+	// None disables masking and returns values unchanged
+	None bool
+}
+
+// DefaultMaskOptions exposes two characters at each end of a secret
+var DefaultMaskOptions = MaskOptions{Rune: '*', Prefix: 2, Suffix: 2}
+
+// WithMasking sets how Mask renders secret values
+func WithMasking(opts MaskOptions) ScannerOption {
+	return func(s *Scanner) {
+		s.masking = opts
+	}
+}
+
+// Mask renders a secret value using the scanner's masking options
+func (s *Scanner) Mask(secret string) string {
+	return s.masking.Mask(secret)
+}
+
+// Mask renders a secret value according to the options
+func (o MaskOptions) Mask(secret string) string {
+	switch {
+	case o.None:
+		return secret
+	case o.Hash:
+		sum := sha256.Sum256([]byte(secret))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	mask := o.Rune
+	if mask == 0 {
+		mask = '*'
+	}
+	runes := []rune(secret)
+	prefix, suffix := max(o.Prefix, 0), max(o.Suffix, 0)
+	if prefix+suffix >= len(runes) {
+		prefix, suffix = 0, 0
+	}
+	hidden := len(runes) - prefix - suffix
+	if o.Length > 0 {
+		hidden = o.Length
+	}
+	return string(runes[:prefix]) + strings.Repeat(string(mask), hidden) + string(runes[len(runes)-suffix:])
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+// MergeResults combines the results of scans of the same project from several
+// sources, such as git history, the filesystem, and a container image, so that
+// a secret found by more than one source is reported once.
+//
+// Sets are given in order of precedence. Results with the same fingerprint
+// are taken from a single set: the one that reported them with the highest
+// confidence, or the earliest such set on a tie. Every occurrence within that
+// set is kept, since they are distinct locations. Results with neither a
+// fingerprint nor a value cannot be matched and are always kept.
+func MergeResults(sets ...[]Result) []Result {
+	type winner struct {
+		set        int
+		confidence float64
+	}
+	winners := make(map[string]winner)
+	for i, set := range sets {
+		for _, result := range set {
+			key := fingerprint(result)
+			if key == "" {
+				continue
+			}
+			if w, ok := winners[key]; !ok || result.Confidence > w.confidence {
+				winners[key] = winner{set: i, confidence: result.Confidence}
+			}
+		}
+	}
+
+	var merged []Result
+	for i, set := range sets {
+		for _, result := range set {
+			if key := fingerprint(result); key == "" || winners[key].set == i {
+				merged = append(merged, result)
+			}
+		}
+	}
+	return merged
+}
+
+// fingerprint identifies a secret independently of where it was found,
+// falling back to an unsalted fingerprint for results built without a scanner
+func fingerprint(result Result) string {
+	switch {
+	case result.Fingerprint != "":
+		return result.Fingerprint
+	case result.Value == "":
+		return ""
+	}
+	return computeFingerprint(nil, result.Type, result.Value)
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// genericRules are the rules that match values by length and character set
+// alone, and so also match hashes, IDs, and public keys that are not secret
+var genericRules = map[string]bool{
+	"aws_secret":                true,
+	"azure_storage_account_key": true,
+	"digitalocean_access_token": true,
+	"pinterest_access_token":    true,
+	"bitbucket_access_token":    true,
+	"datadog_api_key":           true,
+	"datadog_app_key":           true,
+	"cloudflare_global_api_key": true,
+	"vault_legacy_token":        true,
+	"netlify_legacy_token":      true,
+	"vercel_token":              true,
+	"basic_password":            true,
+	"complex_password":          true,
+	"graphql_argument_secret":   true,
+}
+
+// nonSecretPenalty multiplies the confidence of generic matches that look
+// like, but are not certainly, a git commit SHA or a UUID
+const nonSecretPenalty = 0.5
+
+var (
+	// hashPrefix ends the text before content hashes such as sha256:… image
+	// digests, sha512-… Subresource Integrity values, and h1:… go.sum hashes
+	hashPrefix = regexp.MustCompile(`(?i)(?:\b(?:sha(?:1|224|256|384|512)|md5|blake2b|blake3)[:-]|\bh1:)$`)
+	// integrityKey precedes the hashes of lockfiles, as in package-lock.json
+	// "integrity" and yarn.lock integrity entries
+	integrityKey = regexp.MustCompile(`(?i)\bintegrity\b`)
+	// sshPublicKey precedes the key of an OpenSSH public key or
+	// authorized_keys line
+	sshPublicKey = regexp.MustCompile(`(?:ssh-(?:rsa|dss|ed25519)|ecdsa-sha2-nistp\d+|sk-(?:ssh-ed25519|ecdsa-sha2-nistp256)@openssh\.com)\s+\S*$`)
+	// publicBlock is the BEGIN line of a PEM block that holds no secret
+	publicBlock = regexp.MustCompile(`-----BEGIN (?:RSA |EC |SSH2 )?(?:PUBLIC KEY|CERTIFICATE)-----`)
+	// gitKeyword names a git object on the line before a commit SHA
+	gitKeyword = regexp.MustCompile(`(?i)\b(?:commit|sha|rev(?:ision)?|checkout|cherry-pick|merge|parent|tree)\b`)
+	gitSHA     = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	uuid       = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// NonSecret recognizes a match of a generic pattern at text[start:end] whose
+// value is a well-known kind of non-secret string, and returns that kind, such
+// as "content hash", or "" if it is none. Suppress reports whether the kind is
+// certain enough to drop the match rather than lower its confidence.
+func NonSecret(pattern, text string, start, end int) (kind string, suppress bool) {
+	if !genericRules[pattern] {
+		return "", false
+	}
+	value := text[start:end]
+	before := text[strings.LastIndexByte(text[:start], '\n')+1 : start]
+
+	switch {
+	case integrityKey.MatchString(before):
+		return "lockfile integrity hash", true
+	case hashPrefix.MatchString(before):
+		return "content hash", true
+	case sshPublicKey.MatchString(before) || inPublicBlock(text, start):
+		return "public key", true
+	case gitSHA.MatchString(value) && gitKeyword.MatchString(before):
+		return "git commit SHA", true
+	case gitSHA.MatchString(value):
+		// Rules such as datadog_app_key match 40 hex digits natively; their
+		// provider keyword nearby outweighs the shape of a commit SHA
+		if requiredContext[pattern] && InContext(pattern, text, start, end, DefaultKeywordWindow) {
+			return "", false
+		}
+		return "git commit SHA", false
+	case uuid.MatchString(surrounding(text, start, end, "0123456789abcdefABCDEF-")):
+		return "UUID", false
+	}
+	return "", false
+}
+
+// withNonSecret returns the confidence of a match of pattern at chunk[start:end]
+// and whether to keep it, lowered or dropped if its value is a known kind of
+// non-secret string
+func withNonSecret(pattern, chunk string, start, end int, confidence float64) (float64, bool) {
+	kind, suppress := NonSecret(pattern, chunk, start, end)
+	switch {
+	case kind == "":
+		return confidence, true
+	case suppress:
+		return 0, false
+	}
+	return math.Round(confidence*nonSecretPenalty*100) / 100, true
+}
+
+// inPublicBlock reports whether offset falls inside a PEM public key or
+// certificate block
+func inPublicBlock(text string, offset int) bool {
+	begin := strings.LastIndex(text[:offset], "-----BEGIN ")
+	if begin < 0 || strings.Contains(text[begin:offset], "-----END ") {
+		return false
+	}
+	return publicBlock.MatchString(text[begin:offset])
+}
+
+// surrounding returns text[start:end] extended on both sides over the
+// characters of set
+func surrounding(text string, start, end int, set string) string {
+	for start > 0 && strings.IndexByte(set, text[start-1]) >= 0 {
+		start--
+	}
+	for end < len(text) && strings.IndexByte(set, text[end]) >= 0 {
+		end++
+	}
+	return text[start:end]
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithNormalization makes Scan also match against the text with look-alike
+// characters replaced by their ASCII counterparts and invisible characters
+// removed, so that keys obfuscated with full-width letters such as ＡＫＩＡ,
+// Cyrillic or Greek homoglyphs, or zero-width spaces are found. Findings are
+// reported at their exact position in the original text, with Encoded set and
+// Encoding "unicode".
+func WithNormalization(enabled bool) ScannerOption {
+	return func(s *Scanner) {
+		s.normalize = enabled
+	}
+}
+
+// invisible are the format characters removed by normalize: the soft hyphen,
+// zero-width spaces and joiners, the word joiner and invisible operators, and
+// the byte order mark
+var invisible = map[rune]bool{
+	'\u00ad': true, '\u200b': true, '\u200c': true, '\u200d': true,
+	'\u2060': true, '\u2061': true, '\u2062': true, '\u2063': true,
+	'\u2064': true, '\ufeff': true,
+}
+
+// homoglyphs maps Cyrillic and Greek letters to the ASCII letters they are
+// indistinguishable from in most fonts
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O',
+	'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X', 'Ѕ': 'S', 'І': 'I', 'Ј': 'J',
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x',
+	'ѕ': 's', 'і': 'i', 'ј': 'j',
+	// Greek
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K',
+	'Μ': 'M', 'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+	'ο': 'o',
+}
+
+// normalizeRune returns the ASCII character r stands for, and false if r is
+// removed
+func normalizeRune(r rune) (rune, bool) {
+	switch {
+	case invisible[r]:
+		return 0, false
+	case r >= '！' && r <= '～':
+		// Full-width forms of ASCII ! to ~
+		return r - '！' + '!', true
+	case r == '\u3000':
+		return ' ', true
+	}
+	if ascii, ok := homoglyphs[r]; ok {
+		return ascii, true
+	}
+	return r, true
+}
+
+// normalize replaces the look-alike characters of text by ASCII and removes
+// invisible ones. offsets[i] is the index in text of the character that
+// produced byte i of the result, and offsets[len(result)] is len(text).
+func normalize(text string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	for i := 0; i < len(text); {
+		r, n := utf8.DecodeRuneInString(text[i:])
+		if r < utf8.RuneSelf {
+			b.WriteByte(text[i])
+			offsets = append(offsets, i)
+		} else if normal, ok := normalizeRune(r); ok {
+			start := b.Len()
+			if normal == r {
+				b.WriteString(text[i : i+n])
+			} else {
+				b.WriteRune(normal)
+			}
+			for j := start; j < b.Len(); j++ {
+				offsets = append(offsets, i)
+			}
+		}
+		i += n
+	}
+	offsets = append(offsets, len(text))
+	return b.String(), offsets
+}
+
+// hasConfusable reports whether text has any character that normalize
+// changes
+func hasConfusable(text string) bool {
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		if normal, ok := normalizeRune(r); !ok || normal != r {
+			return true
+		}
+	}
+	return false
+}
+
+// scanNormalized scans the normalized form of text and returns the findings
+// that the scan of the raw text, raw, missed, at their position in text
+func (s *Scanner) scanNormalized(ctx context.Context, text string, raw []Result) ([]Result, error) {
+	if !hasConfusable(text) {
+		return nil, nil
+	}
+	plain, offsets := normalize(text)
+	results, err := s.scan(ctx, plain)
+	if err != nil {
+		return nil, err
+	}
+	return remap(text, results, offsets, raw, "unicode"), nil
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// PatternLoader reads a pattern set from a configuration file
+type PatternLoader func(path string) (map[string]string, error)
+
+// RuleLoader reads rules with their metadata from a configuration file
+type RuleLoader func(path string) ([]Rule, error)
+
+// WatchPatterns polls the pattern configuration file at path and swaps the
+// scanner's pattern set whenever the file changes. A file that fails to load or
+// compile is reported on the returned channel and the previous pattern set is
+// kept. Watching stops and the channel is closed when ctx is cancelled.
+// Patterns get the default metadata of their names; use WatchRules to keep
+// the severities, keywords, and entropy thresholds of a rule file.
+func (s *Scanner) WatchPatterns(ctx context.Context, path string, interval time.Duration, load PatternLoader) <-chan error {
+	return s.watch(ctx, path, interval, func() error {
+		patterns, err := load(path)
+		if err != nil {
+			return err
+		}
+		return s.ReplacePatterns(patterns)
+	})
+}
+
+// WatchRules is WatchPatterns for a rule file, swapping in its rules along
+// with their metadata
+func (s *Scanner) WatchRules(ctx context.Context, path string, interval time.Duration, load RuleLoader) <-chan error {
+	return s.watch(ctx, path, interval, func() error {
+		rules, err := load(path)
+		if err != nil {
+			return err
+		}
+		return s.ReplaceRules(rules)
+	})
+}
+
+// watch polls the file at path and calls reload whenever it changes
+func (s *Scanner) watch(ctx context.Context, path string, interval time.Duration, reload func() error) <-chan error {
+	errChan := make(chan error, 1)
+
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	go func() {
+		defer close(errChan)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				reportError(ctx, errChan, fmt.Errorf("failed to stat pattern file: %w", err))
+				continue
+			}
+			if !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			if err := reload(); err != nil {
+				reportError(ctx, errChan, fmt.Errorf("failed to reload patterns: %w", err))
+			}
+		}
+	}()
+
+	return errChan
+}
+
+// reportError sends err without blocking the watcher if nobody is listening
+func reportError(ctx context.Context, errChan chan<- error, err error) {
+	select {
+	case errChan <- err:
+	case <-ctx.Done():
+	default:
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func loadJSON(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var patterns map[string]string
+	err = json.Unmarshal(content, &patterns)
+	return patterns, err
+}
+
+func TestWatchPatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.json")
+	if err := os.WriteFile(path, []byte(`{"old": "secret[0-9]+"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write pattern file: %v", err)
+	}
+
+	s := New()
+	if err := s.AddPattern("old", `secret[0-9]+`); err != nil {
+		t.Fatalf("Failed to add pattern: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := s.WatchPatterns(ctx, path, 10*time.Millisecond, loadJSON)
+
+	mod := time.Now()
+	touch := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write pattern file: %v", err)
+		}
+		// Make sure the modification time moves forward on coarse filesystems
+		mod = mod.Add(time.Second)
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatalf("Failed to update pattern file time: %v", err)
+		}
+	}
+
+	// A broken file is reported and rolled back
+	touch(`{"new": "["}`)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("Expected reload error, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for reload error")
+	}
+	if results, _ := s.Scan(context.Background(), "secret123"); len(results) != 1 {
+		t.Errorf("Expected old pattern set to remain active, got %v", results)
+	}
+
+	// A valid file replaces the pattern set
+	touch(`{"new": "token[0-9]+"}`)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		results, _ := s.Scan(context.Background(), "token456")
+		if len(results) == 1 && results[0].Type == "new" {
+			cancel()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Timed out waiting for patterns to reload")
+}
+
+func TestWatchRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`[]`), 0o600); err != nil {
+		t.Fatalf("Failed to write rule file: %v", err)
+	}
+	load := func(path string) ([]Rule, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var rules []Rule
+		err = json.Unmarshal(content, &rules)
+		return rules, err
+	}
+
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := s.WatchRules(ctx, path, 10*time.Millisecond, load)
+
+	content := `[{"Name": "internal_token", "Pattern": "itk_[a-z0-9]{8}", "ID": "ACME-TOKEN-001", "Severity": "low", "Keywords": ["itk_"]}]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write rule file: %v", err)
+	}
+	mod := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatalf("Failed to update rule file time: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-errs:
+			t.Fatalf("Reload failed: %v", err)
+		default:
+		}
+		results, _ := s.Scan(context.Background(), "token itk_a1b2c3d4")
+		if len(results) == 1 {
+			if got := results[0]; got.RuleID != "ACME-TOKEN-001" || got.Severity != SeverityLow {
+				t.Errorf("Reloaded rule = ID %s severity %s, want ACME-TOKEN-001 low", got.RuleID, got.Severity)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Timed out waiting for rules to reload")
+}
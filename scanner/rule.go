@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is a pattern with the metadata of a user-defined rule
+type Rule struct {
+	Name    string
+	Pattern string
+	// ID overrides the stable identifier of the rule's name; see RuleID
+	ID string
+	// Severity overrides the default severity of the rule's name
+	Severity Severity
+	// Description overrides the default description of the rule's name
+	Description string
+	// Keywords, if any, are matched case-insensitively against the text before
+	// the pattern is, which is skipped unless one of them appears
+	Keywords []string
+	// MinEntropy drops matches whose value has a lower Shannon entropy, in
+	// bits per character, such as placeholders like xxxxxxxx
+	MinEntropy float64
+	// Allowlist are regular expressions that drop the matches whose value
+	// they match, and MatchAllowlist those whose whole match they match
+	Allowlist      []string
+	MatchAllowlist []string
+	// Stopwords drop the matches whose value contains one of them, ignoring
+	// case
+	Stopwords []string
+}
+
+// ruleMeta is the metadata of a rule added with AddRule
+type ruleMeta struct {
+	id             string
+	severity       Severity
+	description    string
+	keywords       []string
+	minEntropy     float64
+	allowlist      []*regexp.Regexp
+	matchAllowlist []*regexp.Regexp
+	stopwords      []string
+}
+
+// AddRule adds a pattern with its severity, description, keywords, and
+// entropy threshold, replacing any pattern of the same name
+func (s *Scanner) AddRule(r Rule) error {
+	compiled, meta, err := compileRule(r)
+	if err != nil {
+		return err
+	}
+
+	s.patternMutex.Lock()
+	defer s.patternMutex.Unlock()
+	s.patterns[r.Name] = compiled
+	if s.rules == nil {
+		s.rules = make(map[string]ruleMeta)
+	}
+	s.rules[r.Name] = meta
+	s.cache = newResultCache(s.cacheSize) // cached results were found without the rule
+	return nil
+}
+
+// ReplaceRules atomically swaps the scanner's pattern set for rules, with
+// their metadata. Every rule is compiled before the swap, so if any of them
+// fails to compile the error is returned and the previous set stays active.
+func (s *Scanner) ReplaceRules(rules []Rule) error {
+	compiled := make(map[string]*regexp.Regexp, len(rules))
+	metas := make(map[string]ruleMeta, len(rules))
+	for _, r := range rules {
+		re, meta, err := compileRule(r)
+		if err != nil {
+			return err
+		}
+		compiled[r.Name], metas[r.Name] = re, meta
+	}
+
+	s.patternMutex.Lock()
+	defer s.patternMutex.Unlock()
+	s.patterns = compiled
+	s.rules = metas
+	s.cache = newResultCache(s.cacheSize) // cached results belong to the old pattern set
+	return nil
+}
+
+// compileRule compiles the pattern and metadata of r
+func compileRule(r Rule) (*regexp.Regexp, ruleMeta, error) {
+	compiled, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return nil, ruleMeta{}, fmt.Errorf("rule %s: %w", r.Name, err)
+	}
+	if r.Severity != "" {
+		if _, err := ParseSeverity(string(r.Severity)); err != nil {
+			return nil, ruleMeta{}, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+	}
+	meta := ruleMeta{id: r.ID, severity: r.Severity, description: r.Description, minEntropy: r.MinEntropy}
+	for _, keyword := range r.Keywords {
+		meta.keywords = append(meta.keywords, strings.ToLower(keyword))
+	}
+	for _, stopword := range r.Stopwords {
+		meta.stopwords = append(meta.stopwords, strings.ToLower(stopword))
+	}
+	for _, list := range []struct {
+		patterns []string
+		compiled *[]*regexp.Regexp
+	}{{r.Allowlist, &meta.allowlist}, {r.MatchAllowlist, &meta.matchAllowlist}} {
+		for _, pattern := range list.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, ruleMeta{}, fmt.Errorf("rule %s allowlist: %w", r.Name, err)
+			}
+			*list.compiled = append(*list.compiled, re)
+		}
+	}
+	return compiled, meta, nil
+}
+
+// Rules returns the scanner's patterns, sorted by name, with their metadata
+// as set with AddRule or by default for their names
+func (s *Scanner) Rules() []Rule {
+	s.patternMutex.RLock()
+	defer s.patternMutex.RUnlock()
+	rules := make([]Rule, 0, len(s.patterns))
+	for name, re := range s.patterns {
+		meta := s.rules[name]
+		rule := Rule{
+			Name:        name,
+			Pattern:     re.String(),
+			ID:          meta.idOf(name),
+			Severity:    meta.severityOf(name),
+			Description: meta.descriptionOf(name),
+			Keywords:    meta.keywords,
+			MinEntropy:  meta.minEntropy,
+			Stopwords:   meta.stopwords,
+		}
+		for _, re := range meta.allowlist {
+			rule.Allowlist = append(rule.Allowlist, re.String())
+		}
+		for _, re := range meta.matchAllowlist {
+			rule.MatchAllowlist = append(rule.MatchAllowlist, re.String())
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// skips reports whether the rule's keywords rule out a match in text, whose
+// lowercase form lower computes once per chunk
+func (m ruleMeta) skips(lower func() string) bool {
+	if len(m.keywords) == 0 {
+		return false
+	}
+	text := lower()
+	for _, keyword := range m.keywords {
+		if strings.Contains(text, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// drops reports whether the rule's entropy threshold, allowlists, or
+// stopwords drop a match of value within match
+func (m ruleMeta) drops(value, match string) bool {
+	if m.minEntropy > 0 && entropy(value) < m.minEntropy {
+		return true
+	}
+	for _, re := range m.allowlist {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	for _, re := range m.matchAllowlist {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	if len(m.stopwords) > 0 {
+		lower := strings.ToLower(value)
+		for _, stopword := range m.stopwords {
+			if strings.Contains(lower, stopword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// severityOf returns the severity of rule, as set with AddRule or by default
+// for its name
+func (m ruleMeta) severityOf(rule string) Severity {
+	if m.severity != "" {
+		return m.severity
+	}
+	return getSeverity(rule)
+}
+
+// idOf returns the stable identifier of rule, as set with AddRule or by
+// default for its name
+func (m ruleMeta) idOf(rule string) string {
+	if m.id != "" {
+		return m.id
+	}
+	return RuleID(rule)
+}
+
+// descriptionOf returns the description of rule, as set with AddRule or by
+// default for its name
+func (m ruleMeta) descriptionOf(rule string) string {
+	if m.description != "" {
+		return m.description
+	}
+	return getDescription(rule)
+}
+
+// entropy returns the Shannon entropy of text in bits per character
+func entropy(text string) float64 {
+	if text == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range text {
+		counts[r]++
+		n++
+	}
+	var bits float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
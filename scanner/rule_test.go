@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAddRule(t *testing.T) {
+	rule := Rule{
+		Name:        "internal_token",
+		ID:          "ACME-TOKEN-001",
+		Pattern:     `itk_[A-Za-z0-9]{16}`,
+		Severity:    SeverityCritical,
+		Description: "Internal service token",
+		Keywords:    []string{"ITK_"},
+		MinEntropy:  3,
+	}
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "Match", text: "token: itk_a8F3kQ9zLm2Xp7Rw", want: 1},
+		{name: "Low entropy placeholder", text: "token: itk_xxxxxxxxxxxxxxxx", want: 0},
+		{name: "No keyword", text: "token: ITK-a8F3kQ9zLm2Xp7Rw", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			if err := s.ReplacePatterns(nil); err != nil {
+				t.Fatalf("Failed to clear patterns: %v", err)
+			}
+			if err := s.AddRule(rule); err != nil {
+				t.Fatalf("AddRule failed: %v", err)
+			}
+			results, err := s.Scan(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			if len(results) != tt.want {
+				t.Fatalf("Scan found %d results, want %d", len(results), tt.want)
+			}
+			if tt.want > 0 && (results[0].Severity != SeverityCritical || results[0].Description != "Internal service token" || results[0].RuleID != "ACME-TOKEN-001") {
+				t.Errorf("Scan() = severity %q description %q rule ID %q, want the rule's", results[0].Severity, results[0].Description, results[0].RuleID)
+			}
+		})
+	}
+}
+
+func TestAddRuleInvalid(t *testing.T) {
+	s := New()
+	if err := s.AddRule(Rule{Name: "broken", Pattern: "("}); err == nil {
+		t.Error("AddRule() error = nil, want an error for an invalid pattern")
+	}
+	if err := s.AddRule(Rule{Name: "bad_severity", Pattern: "x", Severity: "urgent"}); err == nil {
+		t.Error("AddRule() error = nil, want an error for an unknown severity")
+	}
+}
+
+func TestRules(t *testing.T) {
+	s := New()
+	if err := s.ReplacePatterns(map[string]string{"aws_access_key": `AKIA[0-9A-Z]{16}`}); err != nil {
+		t.Fatalf("Failed to replace patterns: %v", err)
+	}
+	custom := Rule{
+		Name:        "internal_token",
+		ID:          "ACME-TOKEN-001",
+		Pattern:     `itk_[a-z0-9]{16}`,
+		Severity:    SeverityCritical,
+		Description: "Internal service token",
+		Keywords:    []string{"itk_"},
+		MinEntropy:  3,
+		Allowlist:   []string{`^itk_0+$`},
+	}
+	if err := s.AddRule(custom); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	want := []Rule{
+		{
+			Name:        "aws_access_key",
+			Pattern:     `AKIA[0-9A-Z]{16}`,
+			ID:          "SSA-AWS-001",
+			Severity:    SeverityCritical,
+			Description: getDescription("aws_access_key"),
+		},
+		custom,
+	}
+	if got := s.Rules(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Rules() = %+v, want %+v", got, want)
+	}
+}
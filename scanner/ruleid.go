@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+// RulesetVersion identifies the built-in detection rules. It changes whenever
+// a pattern is added, removed, or changes what it matches, so that reports can
+// state exactly which rules ran.
+const RulesetVersion = "2023.1.0"
+
+// ruleIDs are the stable identifiers of the built-in rules. Suppressions and
+// dashboards should key on these rather than on rule names, which may change.
+// An ID is never reused, even once its rule is removed.
+var ruleIDs = map[string]string{
+	"aws_access_key":                   "SSA-AWS-001",
+	"aws_secret":                       "SSA-AWS-002",
+	"github_token":                     "SSA-GITHUB-001",
+	"google_api":                       "SSA-GCP-001",
+	"stripe_key":                       "SSA-STRIPE-001",
+	"slack_token":                      "SSA-SLACK-001",
+	"twitter_bearer_token":             "SSA-TWITTER-001",
+	"facebook_access_token":            "SSA-FACEBOOK-001",
+	"azure_storage_account_key":        "SSA-AZURE-001",
+	"digitalocean_access_token":        "SSA-DO-001",
+	"heroku_api_key":                   "SSA-HEROKU-001",
+	"generic_api_key":                  "SSA-GEN-001",
+	"sendgrid_api_key":                 "SSA-SENDGRID-001",
+	"twilio_api_key":                   "SSA-TWILIO-001",
+	"mailgun_api_key":                  "SSA-MAILGUN-001",
+	"paypal_bearer_token":              "SSA-PAYPAL-001",
+	"firebase_api_key":                 "SSA-FIREBASE-001",
+	"square_access_token":              "SSA-SQUARE-001",
+	"shopify_access_token":             "SSA-SHOPIFY-001",
+	"pinterest_access_token":           "SSA-PINTEREST-001",
+	"asana_personal_access_token":      "SSA-ASANA-001",
+	"gitlab_personal_access_token":     "SSA-GITLAB-001",
+	"dropbox_access_token":             "SSA-DROPBOX-001",
+	"microsoft_graph_access_token":     "SSA-MSGRAPH-001",
+	"bitbucket_access_token":           "SSA-BITBUCKET-001",
+	"huggingface_token":                "SSA-HF-001",
+	"openai_api_key":                   "SSA-OPENAI-001",
+	"openai_organization_id":           "SSA-OPENAI-002",
+	"anthropic_api_key":                "SSA-ANTHROPIC-001",
+	"npm_access_token":                 "SSA-NPM-001",
+	"npm_legacy_token":                 "SSA-NPM-002",
+	"pypi_upload_token":                "SSA-PYPI-001",
+	"terraform_cloud_token":            "SSA-TFC-001",
+	"hcp_client_secret":                "SSA-HCP-001",
+	"databricks_token":                 "SSA-DATABRICKS-001",
+	"azure_ad_client_secret":           "SSA-AZURE-002",
+	"azure_sas_token":                  "SSA-AZURE-003",
+	"gcp_service_account":              "SSA-GCP-003",
+	"slack_webhook_url":                "SSA-SLACK-002",
+	"discord_webhook_url":              "SSA-DISCORD-001",
+	"discord_bot_token":                "SSA-DISCORD-002",
+	"telegram_bot_token":               "SSA-TELEGRAM-001",
+	"datadog_api_key":                  "SSA-DATADOG-001",
+	"datadog_app_key":                  "SSA-DATADOG-002",
+	"newrelic_user_api_key":            "SSA-NEWRELIC-001",
+	"newrelic_insert_key":              "SSA-NEWRELIC-002",
+	"newrelic_license_key":             "SSA-NEWRELIC-003",
+	"cloudflare_api_token":             "SSA-CLOUDFLARE-001",
+	"cloudflare_global_api_key":        "SSA-CLOUDFLARE-002",
+	"okta_api_token":                   "SSA-OKTA-001",
+	"auth0_client_secret":              "SSA-AUTH0-001",
+	"auth0_management_token":           "SSA-AUTH0-002",
+	"vault_token":                      "SSA-VAULT-001",
+	"vault_legacy_token":               "SSA-VAULT-002",
+	"planetscale_service_token":        "SSA-PLANETSCALE-001",
+	"planetscale_oauth_token":          "SSA-PLANETSCALE-002",
+	"postman_api_key":                  "SSA-POSTMAN-001",
+	"netlify_access_token":             "SSA-NETLIFY-001",
+	"netlify_legacy_token":             "SSA-NETLIFY-002",
+	"vercel_token":                     "SSA-VERCEL-001",
+	"github_fine_grained_pat":          "SSA-GITHUB-002",
+	"github_app_installation_token":    "SSA-GITHUB-003",
+	"gitlab_deploy_token":              "SSA-GITLAB-002",
+	"gitlab_runner_registration_token": "SSA-GITLAB-003",
+	"gitlab_ci_job_token":              "SSA-GITLAB-004",
+	"gitlab_pipeline_trigger_token":    "SSA-GITLAB-005",
+	"aws_temporary_access_key":         "SSA-AWS-003",
+	"aws_session_token":                "SSA-AWS-004",
+	"stripe_restricted_key":            "SSA-STRIPE-002",
+	"stripe_webhook_secret":            "SSA-STRIPE-003",
+	"stripe_test_key":                  "SSA-STRIPE-004",
+	"mongodb_connection_string":        "SSA-MONGODB-001",
+	"redis_url":                        "SSA-REDIS-001",
+	"amqp_url":                         "SSA-AMQP-001",
+	"basic_auth_url":                   "SSA-URL-001",
+	"smtp_password":                    "SSA-SMTP-001",
+	"smtp_url":                         "SSA-SMTP-002",
+	"msmtp_password":                   "SSA-SMTP-003",
+	"sendmail_authinfo":                "SSA-SMTP-004",
+	"basic_password":                   "SSA-PWD-001",
+	"complex_password":                 "SSA-PWD-002",
+	"rsa_private":                      "SSA-KEY-001",
+	"ssh_private":                      "SSA-KEY-002",
+	"pgp_private":                      "SSA-KEY-003",
+	"generic_private":                  "SSA-KEY-004",
+	"dsa_private":                      "SSA-KEY-005",
+	"ec_private":                       "SSA-KEY-006",
+	"putty_private":                    "SSA-KEY-007",
+	"jwt_private":                      "SSA-KEY-008",
+	"pkcs8_private":                    "SSA-KEY-009",
+	"pem_certificate":                  "SSA-KEY-010",
+	"pkcs12_private":                   "SSA-KEY-011",
+	"putty_ppk_private":                "SSA-KEY-012",
+	"cosign_private":                   "SSA-SIGSTORE-001",
+	"sigstore_private":                 "SSA-SIGSTORE-002",
+	"google_oauth_client_secret":       "SSA-GCP-002",
+	"oauth_client_secret":              "SSA-OAUTH-001",
+	"apollo_api_key":                   "SSA-APOLLO-001",
+	"graphql_auth_header":              "SSA-GRAPHQL-001",
+	"graphql_api_key_header":           "SSA-GRAPHQL-002",
+	"graphql_argument_secret":          "SSA-GRAPHQL-003",
+	"dotenv_secret":                    "SSA-DOTENV-001",
+	"docker_build_arg_secret":          "SSA-DOCKER-001",
+	"docker_env_secret":                "SSA-DOCKER-002",
+	"kubernetes_secret":                "SSA-K8S-001",
+	"terraform_secret":                 "SSA-TF-001",
+	"helm_secret_value":                "SSA-HELM-001",
+	"netrc_password":                   "SSA-NETRC-001",
+	"pgpass_password":                  "SSA-PGPASS-001",
+	"npmrc_auth":                       "SSA-NPMRC-001",
+	"git_credentials_password":         "SSA-GITCRED-001",
+}
+
+// RuleID returns the stable identifier of a built-in rule, or "" for rules
+// that are not built in
+func RuleID(rule string) string {
+	return ruleIDs[rule]
+}
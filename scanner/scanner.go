@@ -8,28 +8,102 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Result represents a detected secret in the text
 type Result struct {
-	Type        string  `json:"type"`
-	Value       string  `json:"value"`
-	StartIndex  int     `json:"start_index"`
-	EndIndex    int     `json:"end_index"`
-	LineNumber  int     `json:"line_number"`
-	Confidence  float64 `json:"confidence"`
-	Description string  `json:"description"`
+	Type string `json:"type"`
+	// RuleID is the stable identifier of the rule, which survives renames of
+	// Type; it is empty for custom rules without one
+	RuleID string `json:"rule_id,omitempty"`
+	// Value is the secret: the text of the pattern's SecretGroup if it has one,
+	// otherwise the whole match. It is empty when the scanner runs in secure mode.
+	Value string `json:"value,omitempty"`
+	// Match is the whole match when it differs from Value, such as
+	// password='hunter22' for the value hunter22. StartIndex and EndIndex
+	// always span Value. It is empty when the scanner runs in secure mode.
+	Match      string `json:"match,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+	LineNumber int    `json:"line_number"`
+	// Path locates the secret within a structured document, such as the key
+	// path spec.containers[0].env[3].value of a YAML manifest
+	Path string `json:"path,omitempty"`
+	// EndLineNumber is the line the secret ends on when it spans several
+	// lines, such as a PEM private key block
+	EndLineNumber int     `json:"end_line_number,omitempty"`
+	Confidence    float64 `json:"confidence"`
+	// Severity is the pattern's severity, lowered for encrypted private keys
+	Severity    Severity `json:"severity,omitempty"`
+	Description string   `json:"description"`
+	// Fingerprint identifies the secret across scans without storing it; see
+	// Scanner.Fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Context is the text around the match with every pattern match in it
+	// masked, reported or not; see WithContextLines and WithContextChars
+	Context string `json:"context,omitempty"`
+	// JWT holds the decoded header and claims of a JSON Web Token match
+	JWT *JWT `json:"jwt,omitempty"`
+	// Key holds the type, size, and encryption of a PEM private key match
+	Key *KeyInfo `json:"key,omitempty"`
+	// AWSAccountID is the account an AWS access key ID belongs to, decoded
+	// from the key ID itself
+	AWSAccountID string `json:"aws_account_id,omitempty"`
+	// ServiceAccount holds the project and client email of a Google Cloud
+	// service account key file match
+	ServiceAccount *ServiceAccount `json:"service_account,omitempty"`
+	// Encoded is set when the secret was found in the decoded content of an
+	// encoded substring; StartIndex and EndIndex then span the encoded text.
+	// Encoding names the decoders that revealed it, outermost first, as in
+	// json+base64. See WithDecoders.
+	Encoded  bool   `json:"encoded,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	// Occurrences and Locations are set on grouped results; see GroupResults
+	Occurrences int        `json:"occurrences,omitempty"`
+	Locations   []Location `json:"locations,omitempty"`
 }
 
+// SecretGroup is the name of the capture group that designates the secret
+// value within a pattern, as in password=(?P<secret>\S+). Patterns without it
+// report the whole match as the value.
+const SecretGroup = "secret"
+
 // Scanner represents the main secret scanning interface
 type Scanner struct {
-	patterns     map[string]*regexp.Regexp
-	patternMutex sync.RWMutex
-	cache        *sync.Map
-	workers      int
+	patterns      map[string]*regexp.Regexp
+	rules         map[string]ruleMeta
+	patternMutex  sync.RWMutex
+	cache         *resultCache
+	cacheSize     int
+	workers       int
+	placeholder   string
+	masking       MaskOptions
+	deterministic bool
+	secure        bool
+	salt          []byte
+	group         bool
+	contextLines  int
+	contextChars  int
+	decoders      []Decoder
+	decodeDepth   int
+	keywordWindow int
+	minConfidence float64
+	unescape      bool
+	normalize     bool
+	maxSize       int
+	timeout       time.Duration
+	now           func() time.Time
 }
 
 // ScannerOption represents a function that modifies Scanner configuration
@@ -44,17 +118,72 @@ func WithWorkers(n int) ScannerOption {
 	}
 }
 
+// WithDeterministic makes scans reproducible for debugging and golden tests:
+// chunks are processed one at a time in order and results are sorted by
+// position. Scans are parallel by default.
+func WithDeterministic(enabled bool) ScannerOption {
+	return func(s *Scanner) {
+		s.deterministic = enabled
+	}
+}
+
+// WithSecureMode keeps raw secret values out of results: Result.Value is left
+// empty at detection time and scanned text is not cached, so neither result
+// slices, the cache, nor anything serialized from them holds the plaintext.
+// Result.Fingerprint still identifies each secret.
+func WithSecureMode(enabled bool) ScannerOption {
+	return func(s *Scanner) {
+		s.secure = enabled
+	}
+}
+
+// WithFingerprintSalt sets the salt mixed into result fingerprints. Use the
+// same secret salt across scans whose fingerprints are compared, so that
+// fingerprints of low-entropy secrets cannot be reversed by guessing.
+func WithFingerprintSalt(salt []byte) ScannerOption {
+	return func(s *Scanner) {
+		s.salt = salt
+	}
+}
+
+// WithMinConfidence drops results with a confidence below min from scans, so
+// that weak matches such as bare hashes or guessable passwords are not
+// reported
+func WithMinConfidence(min float64) ScannerOption {
+	return func(s *Scanner) {
+		s.minConfidence = min
+	}
+}
+
+// DefaultPlaceholder is the redaction placeholder used unless WithPlaceholder
+// is given. {type} is replaced with the pattern name of each finding.
+const DefaultPlaceholder = "[REDACTED:{type}]"
+
+// WithPlaceholder sets the text that Redact substitutes for each detected
+// secret. Occurrences of {type} are replaced with the pattern name.
+func WithPlaceholder(placeholder string) ScannerOption {
+	return func(s *Scanner) {
+		s.placeholder = placeholder
+	}
+}
+
 // New creates a new Scanner instance with default patterns
 func New(opts ...ScannerOption) *Scanner {
 	s := &Scanner{
-		patterns: make(map[string]*regexp.Regexp),
-		cache:    &sync.Map{},
-		workers:  4, // default number of workers
+		patterns:      make(map[string]*regexp.Regexp),
+		workers:       4, // default number of workers
+		placeholder:   DefaultPlaceholder,
+		masking:       DefaultMaskOptions,
+		decodeDepth:   DefaultDecodeDepth,
+		keywordWindow: DefaultKeywordWindow,
+		cacheSize:     DefaultCacheSize,
+		now:           time.Now,
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
+	s.cache = newResultCache(s.cacheSize)
 
 	return s
 }
@@ -69,50 +198,113 @@ func (s *Scanner) AddPattern(name string, pattern string) error {
 	s.patternMutex.Lock()
 	defer s.patternMutex.Unlock()
 	s.patterns[name] = compiled
+	delete(s.rules, name)
+	return nil
+}
+
+// ReplacePatterns atomically swaps the scanner's pattern set. Every pattern is
+// compiled before the swap, so if any of them fails to compile the error is
+// returned and the previous set stays active.
+func (s *Scanner) ReplacePatterns(patterns map[string]string) error {
+	compiled := make(map[string]*regexp.Regexp, len(patterns))
+	for name, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %s: %w", name, err)
+		}
+		compiled[name] = re
+	}
+
+	s.patternMutex.Lock()
+	defer s.patternMutex.Unlock()
+	s.patterns = compiled
+	s.rules = nil
+	s.cache = newResultCache(s.cacheSize) // cached results belong to the old pattern set
 	return nil
 }
 
 // scanChunk performs pattern matching on a chunk of text
 func (s *Scanner) scanChunk(ctx context.Context, chunk string, offset int) ([]Result, error) {
 	var results []Result
+	// spans holds every match, reported or not, to mask in context snippets
+	var spans [][2]int
 	s.patternMutex.RLock()
 	defer s.patternMutex.RUnlock()
 
+	var lower string
+	lowerChunk := func() string {
+		if lower == "" {
+			lower = strings.ToLower(chunk)
+		}
+		return lower
+	}
+
 	for patternName, pattern := range s.patterns {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
+		meta := s.rules[patternName]
+		if meta.skips(lowerChunk) {
+			continue
+		}
 
-		matches := pattern.FindAllStringIndex(chunk, -1)
+		group := pattern.SubexpIndex(SecretGroup)
+		matches := pattern.FindAllStringSubmatchIndex(chunk, -1)
 		for _, match := range matches {
-			lineNumber := strings.Count(chunk[:match[0]], "\n") + 1
+			start, end := match[0], match[1]
+			if group > 0 && match[2*group] >= 0 {
+				start, end = match[2*group], match[2*group+1]
+			}
+			spans = append(spans, [2]int{offset + start, offset + end})
+			if meta.drops(chunk[start:end], chunk[match[0]:match[1]]) || !Valid(patternName, chunk[start:end]) ||
+				!InContext(patternName, chunk, match[0], match[1], s.keywordWindow) || IsPlaceholder(chunk[start:end]) {
+				continue
+			}
+			confidence := withStrength(patternName, chunk[start:end], withContext(patternName, chunk, match[0], match[1], s.keywordWindow, calculateConfidence(patternName, chunk[match[0]:match[1]])))
+			confidence, keep := withNonSecret(patternName, chunk, start, end, confidence)
+			if !keep {
+				continue
+			}
+			lineNumber := strings.Count(chunk[:start], "\n") + 1
 			result := Result{
 				Type:        patternName,
-				Value:       chunk[match[0]:match[1]],
-				StartIndex:  offset + match[0],
-				EndIndex:    offset + match[1],
+				RuleID:      meta.idOf(patternName),
+				Value:       chunk[start:end],
+				StartIndex:  offset + start,
+				EndIndex:    offset + end,
 				LineNumber:  lineNumber,
-				Confidence:  calculateConfidence(chunk[match[0]:match[1]]),
-				Description: getDescription(patternName),
+				Confidence:  confidence,
+				Severity:    meta.severityOf(patternName),
+				Description: meta.descriptionOf(patternName),
+			}
+			if start != match[0] || end != match[1] {
+				result.Match = chunk[match[0]:match[1]]
+			}
+			if lines := strings.Count(result.Value, "\n"); lines > 0 {
+				result.EndLineNumber = lineNumber + lines
+			}
+			result.JWT = decodeJWT(result.Value, s.now())
+			result.AWSAccountID = decodeAWSAccountID(result.Value)
+			result.ServiceAccount = parseServiceAccount(chunk[match[0]:match[1]])
+			if result.EndLineNumber > 0 {
+				if result.Key = parseKey(result.Value); result.Key != nil && result.Key.Encrypted {
+					result.Severity = result.Severity.Lower()
+				}
+			}
+			result.Fingerprint = s.Fingerprint(patternName, result.Value)
+			if s.secure {
+				result.Value = ""
+				result.Match = ""
 			}
 			results = append(results, result)
 		}
 	}
 
-	// Group results by line number and select the highest confidence result
-	lineResults := make(map[int]Result)
-	for _, result := range results {
-		if existing, found := lineResults[result.LineNumber]; !found || result.Confidence > existing.Confidence {
-			lineResults[result.LineNumber] = result
-		}
-	}
-
-	// Convert the map back to a slice
-	finalResults := make([]Result, 0, len(lineResults))
-	for _, result := range lineResults {
-		finalResults = append(finalResults, result)
+	finalResults := bestResults(dropNested(results))
+	if s.contextLines > 0 || s.contextChars > 0 {
+		s.addContext(chunk, offset, finalResults, spans)
 	}
 
 	return finalResults, nil
@@ -120,43 +312,137 @@ func (s *Scanner) scanChunk(ctx context.Context, chunk string, offset int) ([]Re
 
 // Scan performs the secret scanning on the provided text
 func (s *Scanner) Scan(ctx context.Context, text string) ([]Result, error) {
+	ctx, span := tracer.Start(ctx, "Scanner.Scan", trace.WithAttributes(
+		attribute.Int("scanner.text_length", len(text)),
+		attribute.Int("scanner.workers", s.workers),
+	))
+	defer span.End()
+
+	if err := s.checkSize(text); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	parent := ctx
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// UTF-16 and Latin-1 text is matched as UTF-8, with results reported at
+	// their byte offsets in the original text
+	charset := DetectCharset(text)
+	span.SetAttributes(attribute.String("scanner.charset", string(charset)))
+	text, offsets := transcode(text, charset)
+
+	results, err := s.scan(ctx, text)
+	if err == nil && (s.unescape || s.normalize || len(s.decoders) > 0) {
+		var encoded []Result
+		if encoded, err = s.scanEncoded(ctx, text, results); err == nil && len(encoded) > 0 {
+			// Copy rather than append into the cached slice
+			results = append(results[:len(results):len(results)], encoded...)
+			if s.deterministic {
+				sortByPosition(results)
+			}
+		}
+	}
+	if err != nil {
+		err = s.timeoutError(parent, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if offsets != nil {
+		results = untranscode(results, offsets)
+	}
+	if s.minConfidence > 0 {
+		results = confident(results, s.minConfidence)
+	}
+	if s.group {
+		results = GroupResults(results)
+	}
+	span.SetAttributes(attribute.Int("scanner.results", len(results)))
+	return results, nil
+}
+
+// confident returns the results with a confidence of at least min, in a new
+// slice so that cached results are left intact
+func confident(results []Result, min float64) []Result {
+	kept := make([]Result, 0, len(results))
+	for _, result := range results {
+		if result.Confidence >= min {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+func (s *Scanner) scan(ctx context.Context, text string) ([]Result, error) {
+	span := trace.SpanFromContext(ctx)
+
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
 	}
 
+	// Scans that race with ReplacePatterns write into the cache they started with
+	s.patternMutex.RLock()
+	cache := s.cache
+	span.SetAttributes(attribute.Int("scanner.patterns", len(s.patterns)))
+	s.patternMutex.RUnlock()
+	if s.secure {
+		// The cache is keyed by the scanned text, so secure scans do not
+		// cache at all
+		cache = newResultCache(0)
+	}
+
 	// Check cache first
-	if cached, ok := s.cache.Load(text); ok {
-		return cached.([]Result), nil
+	cached, ok := cache.load(text)
+	span.SetAttributes(attribute.Bool("scanner.cache_hit", ok))
+	if ok {
+		return cached, nil
 	}
 
 	// For small texts, process directly
-	if len(text) < 10000 { // threshold for small texts
+	if len(text) < chunkSize {
+		span.SetAttributes(attribute.Int("scanner.chunks", 1))
 		results, err := s.scanChunk(ctx, text, 0)
 		if err != nil {
 			return nil, err
 		}
-		s.cache.Store(text, results)
+		if s.deterministic {
+			sortByPosition(results)
+		}
+		cache.store(text, results)
 		return results, nil
 	}
 
 	// For larger texts, process in parallel chunks
 	chunks := s.splitIntoChunks(text)
+	span.SetAttributes(attribute.Int("scanner.chunks", len(chunks)))
+	if s.deterministic {
+		results, err := s.scanSequential(ctx, chunks)
+		if err != nil {
+			return nil, err
+		}
+		// Lines of a multi-line secret also appear in the next chunk
+		results = dropNested(results)
+		cache.store(text, results)
+		return results, nil
+	}
 	resultsChan := make(chan []Result, len(chunks))
 	errChan := make(chan error, 1)
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, s.workers) // semaphore for worker pool
 
 	// Start workers
-	for _, chunk := range chunks {
+	for _, c := range chunks {
 		wg.Add(1)
-		go func(chunkText string, offset int) {
+		go func(c chunk) {
 			defer wg.Done()
 			sem <- struct{}{}        // acquire semaphore
 			defer func() { <-sem }() // release semaphore
 
-			results, err := s.scanChunk(ctx, chunkText, offset)
+			results, err := s.scanChunkTraced(ctx, c)
 			if err != nil {
 				select {
 				case errChan <- err:
@@ -173,7 +459,7 @@ func (s *Scanner) Scan(ctx context.Context, text string) ([]Result, error) {
 				default:
 				}
 			}
-		}(chunk.text, chunk.offset)
+		}(c)
 	}
 
 	// Wait for all workers in a separate goroutine
@@ -195,8 +481,10 @@ func (s *Scanner) Scan(ctx context.Context, text string) ([]Result, error) {
 			}
 		case results, ok := <-resultsChan:
 			if !ok {
-				// Channel closed, all workers completed
-				s.cache.Store(text, allResults)
+				// Channel closed, all workers completed. Lines of a
+				// multi-line secret also appear in the next chunk.
+				allResults = dropNested(allResults)
+				cache.store(text, allResults)
 				return allResults, nil
 			}
 			allResults = append(allResults, results...)
@@ -241,81 +529,355 @@ func (s *Scanner) StreamScan(ctx context.Context, reader *strings.Reader) (<-cha
 	return resultsChan, nil
 }
 
+// scanSequential scans chunks one at a time in order
+func (s *Scanner) scanSequential(ctx context.Context, chunks []chunk) ([]Result, error) {
+	var allResults []Result
+	for _, c := range chunks {
+		results, err := s.scanChunkTraced(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		sortByPosition(results)
+		allResults = append(allResults, results...)
+	}
+	return allResults, nil
+}
+
+// bestResults keeps one result per secret: of the results whose values
+// overlap, the one with the highest confidence, breaking ties in favor of the
+// pattern with the more distinctive format, then by pattern name so that the
+// choice does not depend on map order. Secrets that share a line without
+// overlapping, as in minified JSON, are all kept.
+func bestResults(results []Result) []Result {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Type != b.Type {
+			return preferred(a.Type, b.Type)
+		}
+		return a.StartIndex < b.StartIndex
+	})
+
+	kept := make([]Result, 0, len(results))
+	for _, result := range results {
+		overlaps := false
+		for _, best := range kept {
+			if result.StartIndex < best.EndIndex && best.StartIndex < result.EndIndex {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// dropNested removes results that lie within a multi-line secret, such as
+// base64 lines of a PEM private key body matched by generic key patterns
+func dropNested(results []Result) []Result {
+	var blocks []Result
+	for _, result := range results {
+		if result.EndLineNumber > 0 {
+			blocks = append(blocks, result)
+		}
+	}
+	if len(blocks) == 0 {
+		return results
+	}
+
+	kept := make([]Result, 0, len(results))
+	for _, result := range results {
+		nested := false
+		for _, block := range blocks {
+			if block.StartIndex <= result.StartIndex && result.EndIndex <= block.EndIndex &&
+				(block.StartIndex != result.StartIndex || block.EndIndex != result.EndIndex) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// sortByPosition orders results by start index, then by pattern name
+func sortByPosition(results []Result) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].StartIndex != results[j].StartIndex {
+			return results[i].StartIndex < results[j].StartIndex
+		}
+		return results[i].Type < results[j].Type
+	})
+}
+
+const (
+	// chunkSize is the length of the text each worker scans; shorter texts
+	// are scanned in one piece
+	chunkSize = 10000
+	// chunkOverlap is how far a chunk reaches into the next one, so that a
+	// match starting near its end, up to the PEM block of an 8192-bit RSA
+	// key, is found whole
+	chunkOverlap = 8192
+)
+
+// chunk is a part of a larger text. Its text overlaps the neighbouring chunks,
+// but only matches whose value starts between from and to are reported from
+// it, so that each is reported once.
 type chunk struct {
-	text   string
-	offset int
+	text     string
+	offset   int
+	line     int // line number of text[0] in the whole text
+	from, to int
 }
 
+// splitIntoChunks splits text into chunks that end on a line boundary where
+// there is one within reach. Each reaches back the keyword window, for the
+// context of matches at its start, and forward chunkOverlap bytes.
 func (s *Scanner) splitIntoChunks(text string) []chunk {
-	const chunkSize = 10000
 	var chunks []chunk
+	line, counted := 1, 0
+	for from := 0; from < len(text); {
+		to := min(from+chunkSize, len(text))
+		if nl := strings.IndexByte(text[to:min(to+chunkOverlap, len(text))], '\n'); nl >= 0 {
+			to += nl + 1
+		}
+		start, end := max(from-s.keywordWindow, 0), min(to+chunkOverlap, len(text))
+		line += strings.Count(text[counted:start], "\n")
+		counted = start
+		chunks = append(chunks, chunk{text: text[start:end], offset: start, line: line, from: from, to: to})
+		from = to
+	}
+	return chunks
+}
 
-	for i := 0; i < len(text); i += chunkSize {
-		end := i + chunkSize
-		if end > len(text) {
-			end = len(text)
+// owned returns the results of c whose value starts in the part of the text
+// it reports on, with line numbers in the whole text
+func (c chunk) owned(results []Result) []Result {
+	kept := results[:0]
+	for _, result := range results {
+		if result.StartIndex >= c.from && result.StartIndex < c.to {
+			result.LineNumber += c.line - 1
+			if result.EndLineNumber > 0 {
+				result.EndLineNumber += c.line - 1
+			}
+			kept = append(kept, result)
 		}
-		chunks = append(chunks, chunk{
-			text:   text[i:end],
-			offset: i,
-		})
 	}
+	return kept
+}
 
-	return chunks
+// secretKeyName matches a value being assigned to a key whose name suggests a
+// credential, such as an Authorization header or apiKey argument in a GraphQL config
+var secretKeyName = regexp.MustCompile(`(?i)(?:authorization|api[_-]?key|admin[_-]?secret|apollo[_-]?key|access[_-]?token|auth[_-]?token|secret|password|token)['"]?\s*[:=]`)
+
+// baseConfidences are the confidence of matches of patterns whose format is
+// distinctive enough that a match is rarely anything else, such as a fixed
+// prefix or embedded marker
+var baseConfidences = map[string]float64{
+	"openai_api_key":                   0.9,
+	"openai_organization_id":           0.85,
+	"anthropic_api_key":                0.95,
+	"npm_access_token":                 0.9,
+	"pypi_upload_token":                0.95,
+	"terraform_cloud_token":            0.95,
+	"databricks_token":                 0.9,
+	"azure_ad_client_secret":           0.9,
+	"azure_sas_token":                  0.9,
+	"gcp_service_account":              0.95,
+	"slack_webhook_url":                0.95,
+	"discord_webhook_url":              0.95,
+	"discord_bot_token":                0.9,
+	"telegram_bot_token":               0.9,
+	"datadog_api_key":                  0.9,
+	"datadog_app_key":                  0.9,
+	"newrelic_user_api_key":            0.95,
+	"newrelic_insert_key":              0.95,
+	"newrelic_license_key":             0.95,
+	"cloudflare_api_token":             0.9,
+	"cloudflare_global_api_key":        0.9,
+	"okta_api_token":                   0.9,
+	"auth0_management_token":           0.95,
+	"vault_token":                      0.95,
+	"vault_legacy_token":               0.9,
+	"planetscale_service_token":        0.95,
+	"planetscale_oauth_token":          0.95,
+	"postman_api_key":                  0.95,
+	"netlify_access_token":             0.95,
+	"netlify_legacy_token":             0.9,
+	"vercel_token":                     0.9,
+	"github_fine_grained_pat":          0.95,
+	"github_app_installation_token":    0.85,
+	"gitlab_deploy_token":              0.95,
+	"gitlab_runner_registration_token": 0.95,
+	"gitlab_ci_job_token":              0.95,
+	"gitlab_pipeline_trigger_token":    0.95,
+	"aws_temporary_access_key":         0.9,
+	"aws_session_token":                0.95,
+	"stripe_restricted_key":            0.95,
+	"stripe_webhook_secret":            0.95,
+	"stripe_test_key":                  0.9,
+	"mongodb_connection_string":        0.9,
+	"redis_url":                        0.9,
+	"amqp_url":                         0.9,
+	"basic_auth_url":                   0.85,
+	"smtp_password":                    0.85,
+	"smtp_url":                         0.9,
+	"msmtp_password":                   0.9,
+	"sendmail_authinfo":                0.9,
+}
+
+// baseConfidence returns the confidence of a match of a pattern before it is
+// adjusted for its length and context
+func baseConfidence(patternType string) float64 {
+	if base, ok := baseConfidences[patternType]; ok {
+		return base
+	}
+	return 0.8 // Default high confidence
+}
+
+// preferred reports whether a match of pattern a wins over one of b with the
+// same confidence
+func preferred(a, b string) bool {
+	if baseA, baseB := baseConfidence(a), baseConfidence(b); baseA != baseB {
+		return baseA > baseB
+	}
+	return a < b
 }
 
-func calculateConfidence(secret string) float64 {
+func calculateConfidence(patternType, secret string) float64 {
 	// TODO: Implement more sophisticated confidence scoring
 	// Current implementation is a basic entropy-based score
-	var entropy float64 = 0.8 // Default high confidence
+	entropy := baseConfidence(patternType)
 	if len(secret) < 8 {
 		entropy *= 0.5
 	}
-	return entropy
+	// Matches that include a credential-like key name are more likely real secrets
+	if secretKeyName.MatchString(secret) {
+		entropy += 0.15
+	}
+	// Round so that float error does not decide between equal scores
+	return math.Round(math.Min(entropy, 1)*100) / 100
 }
 
 func getDescription(patternType string) string {
 	descriptions := map[string]string{
-		"aws_access_key":               "Possible AWS access key detected",
-		"aws_secret":                   "Possible AWS secret access key detected",
-		"github_token":                 "Possible GitHub token detected",
-		"google_api":                   "Possible Google API key detected",
-		"stripe_key":                   "Possible Stripe API key detected",
-		"slack_token":                  "Possible Slack token detected",
-		"twitter_bearer_token":         "Possible Twitter bearer token detected",
-		"facebook_access_token":        "Possible Facebook access token detected",
-		"azure_storage_account_key":    "Possible Azure Storage account key detected",
-		"digitalocean_access_token":    "Possible DigitalOcean access token detected",
-		"heroku_api_key":               "Possible Heroku API key detected",
-		"sendgrid_api_key":             "Possible SendGrid API key detected",
-		"twilio_api_key":               "Possible Twilio API key detected",
-		"mailgun_api_key":              "Possible Mailgun API key detected",
-		"paypal_bearer_token":          "Possible PayPal bearer token detected",
-		"firebase_api_key":             "Possible Firebase API key detected",
-		"square_access_token":          "Possible Square access token detected",
-		"shopify_access_token":         "Possible Shopify access token detected",
-		"pinterest_access_token":       "Possible Pinterest access token detected",
-		"asana_personal_access_token":  "Possible Asana personal access token detected",
-		"gitlab_personal_access_token": "Possible GitLab personal access token detected",
-		"twitch_access_token":          "Possible Twitch access token detected",
-		"dropbox_access_token":         "Possible Dropbox access token detected",
-		"microsoft_graph_access_token": "Possible Microsoft Graph access token detected",
-		"bitbucket_access_token":       "Possible Bitbucket access token detected",
-		"huggingface_token":            "Possible Hugging Face token detected",
-		"rsa_private":                  "Possible RSA private key detected",
-		"ssh_private":                  "Possible SSH private key detected",
-		"pgp_private":                  "Possible PGP private key detected",
-		"generic_private":              "Possible generic private key detected",
-		"dsa_private":                  "Possible DSA private key detected",
-		"ec_private":                   "Possible EC private key detected",
-		"putty_private":                "Possible PuTTY private key detected",
-		"jwt_private":                  "Possible JWT private key detected",
-		"pkcs8_private":                "Possible PKCS8 private key detected",
-		"pem_certificate":              "Possible PEM certificate detected",
-		"pkcs12_private":               "Possible PKCS12 private key detected",
-		"cosign_private":               "Possible Cosign private key detected",
-		"sigstore_private":             "Possible Sigstore private key detected",
-		"complex_password":             "Possible complex password detected",
+		"aws_access_key":                   "Possible AWS access key detected",
+		"aws_secret":                       "Possible AWS secret access key detected",
+		"github_token":                     "Possible GitHub token detected",
+		"google_api":                       "Possible Google API key detected",
+		"stripe_key":                       "Possible Stripe secret API key detected",
+		"slack_token":                      "Possible Slack token detected",
+		"twitter_bearer_token":             "Possible Twitter bearer token detected",
+		"facebook_access_token":            "Possible Facebook access token detected",
+		"azure_storage_account_key":        "Possible Azure Storage account key detected",
+		"digitalocean_access_token":        "Possible DigitalOcean access token detected",
+		"heroku_api_key":                   "Possible Heroku API key detected",
+		"sendgrid_api_key":                 "Possible SendGrid API key detected",
+		"twilio_api_key":                   "Possible Twilio API key detected",
+		"mailgun_api_key":                  "Possible Mailgun API key detected",
+		"paypal_bearer_token":              "Possible PayPal bearer token detected",
+		"firebase_api_key":                 "Possible Firebase API key detected",
+		"square_access_token":              "Possible Square access token detected",
+		"shopify_access_token":             "Possible Shopify access token detected",
+		"pinterest_access_token":           "Possible Pinterest access token detected",
+		"asana_personal_access_token":      "Possible Asana personal access token detected",
+		"gitlab_personal_access_token":     "Possible GitLab personal access token detected",
+		"twitch_access_token":              "Possible Twitch access token detected",
+		"dropbox_access_token":             "Possible Dropbox access token detected",
+		"microsoft_graph_access_token":     "Possible Microsoft Graph access token detected",
+		"bitbucket_access_token":           "Possible Bitbucket access token detected",
+		"huggingface_token":                "Possible Hugging Face token detected",
+		"openai_api_key":                   "Possible OpenAI API key detected",
+		"openai_organization_id":           "Possible OpenAI organization ID detected",
+		"anthropic_api_key":                "Possible Anthropic API key detected",
+		"npm_access_token":                 "Possible npm access token detected",
+		"npm_legacy_token":                 "Possible legacy npm token in an .npmrc file detected",
+		"pypi_upload_token":                "Possible PyPI upload token detected",
+		"terraform_cloud_token":            "Possible Terraform Cloud API token detected",
+		"hcp_client_secret":                "Possible HCP service principal client secret detected",
+		"databricks_token":                 "Possible Databricks personal access token detected",
+		"azure_ad_client_secret":           "Possible Azure AD application client secret detected",
+		"azure_sas_token":                  "Possible Azure Storage shared access signature detected",
+		"gcp_service_account":              "Possible Google Cloud service account key detected",
+		"slack_webhook_url":                "Possible Slack incoming webhook URL detected",
+		"discord_webhook_url":              "Possible Discord webhook URL detected",
+		"discord_bot_token":                "Possible Discord bot token detected",
+		"telegram_bot_token":               "Possible Telegram bot token detected",
+		"datadog_api_key":                  "Possible Datadog API key detected",
+		"datadog_app_key":                  "Possible Datadog application key detected",
+		"newrelic_user_api_key":            "Possible New Relic user API key detected",
+		"newrelic_insert_key":              "Possible New Relic Insights insert key detected",
+		"newrelic_license_key":             "Possible New Relic license key detected",
+		"cloudflare_api_token":             "Possible Cloudflare API token detected",
+		"cloudflare_global_api_key":        "Possible Cloudflare global API key detected",
+		"okta_api_token":                   "Possible Okta API token detected",
+		"auth0_client_secret":              "Possible Auth0 client secret detected",
+		"auth0_management_token":           "Possible Auth0 Management API token detected",
+		"vault_token":                      "Possible HashiCorp Vault token detected",
+		"vault_legacy_token":               "Possible legacy HashiCorp Vault token detected",
+		"planetscale_service_token":        "Possible PlanetScale service token detected",
+		"planetscale_oauth_token":          "Possible PlanetScale OAuth token detected",
+		"postman_api_key":                  "Possible Postman API key detected",
+		"netlify_access_token":             "Possible Netlify personal access token detected",
+		"netlify_legacy_token":             "Possible legacy Netlify access token detected",
+		"vercel_token":                     "Possible Vercel access token detected",
+		"github_fine_grained_pat":          "Possible GitHub fine-grained personal access token detected",
+		"github_app_installation_token":    "Possible GitHub App installation token detected",
+		"gitlab_deploy_token":              "Possible GitLab deploy token detected",
+		"gitlab_runner_registration_token": "Possible GitLab runner registration token detected",
+		"gitlab_ci_job_token":              "Possible GitLab CI/CD job token detected",
+		"gitlab_pipeline_trigger_token":    "Possible GitLab pipeline trigger token detected",
+		"aws_temporary_access_key":         "Possible AWS temporary access key ID detected",
+		"aws_session_token":                "Possible AWS session token detected",
+		"stripe_restricted_key":            "Possible Stripe restricted API key detected",
+		"stripe_webhook_secret":            "Possible Stripe webhook signing secret detected",
+		"stripe_test_key":                  "Possible Stripe test mode API key detected",
+		"mongodb_connection_string":        "Possible MongoDB connection string with credentials detected",
+		"redis_url":                        "Possible Redis URL with credentials detected",
+		"amqp_url":                         "Possible AMQP URL with credentials detected",
+		"basic_auth_url":                   "Possible credentials in URL detected",
+		"smtp_password":                    "Possible SMTP password detected",
+		"smtp_url":                         "Possible SMTP URL with credentials detected",
+		"msmtp_password":                   "Possible msmtp account password detected",
+		"sendmail_authinfo":                "Possible sendmail AuthInfo password detected",
+		"rsa_private":                      "Possible RSA private key detected",
+		"ssh_private":                      "Possible SSH private key detected",
+		"pgp_private":                      "Possible PGP private key detected",
+		"generic_private":                  "Possible generic private key detected",
+		"dsa_private":                      "Possible DSA private key detected",
+		"ec_private":                       "Possible EC private key detected",
+		"putty_private":                    "Possible PuTTY private key detected",
+		"jwt_private":                      "Possible JWT private key detected",
+		"pkcs8_private":                    "Possible PKCS8 private key detected",
+		"pem_certificate":                  "Possible PEM certificate detected",
+		"pkcs12_private":                   "Possible PKCS12 private key detected",
+		"putty_ppk_private":                "Possible PuTTY PPK private key file detected",
+		"cosign_private":                   "Possible Cosign private key detected",
+		"sigstore_private":                 "Possible Sigstore private key detected",
+		"basic_password":                   "Possible password assignment detected",
+		"complex_password":                 "Possible complex password detected",
+		"google_oauth_client_secret":       "Possible Google OAuth client secret detected",
+		"oauth_client_secret":              "Possible OAuth client secret detected",
+		"apollo_api_key":                   "Possible Apollo GraphOS API key detected",
+		"graphql_auth_header":              "Possible Authorization header value in GraphQL config detected",
+		"graphql_api_key_header":           "Possible API key header value in GraphQL config detected",
+		"graphql_argument_secret":          "Possible secret literal in GraphQL query argument detected",
+		"dotenv_secret":                    "Possible secret assigned to a sensitive environment variable detected",
+		"docker_build_arg_secret":          "Possible secret baked into an image as a build argument detected",
+		"docker_env_secret":                "Possible secret set as a container environment variable detected",
+		"kubernetes_secret":                "Possible secret stored in a Kubernetes Secret manifest detected",
+		"terraform_secret":                 "Possible secret in a Terraform state or variables file detected",
+		"helm_secret_value":                "Possible secret hard-coded in a Helm chart value detected",
+		"netrc_password":                   "Possible password stored in a .netrc file detected",
+		"pgpass_password":                  "Possible password stored in a PostgreSQL .pgpass file detected",
+		"npmrc_auth":                       "Possible npm registry credential stored in an .npmrc file detected",
+		"git_credentials_password":         "Possible password stored in a .git-credentials file detected",
 	}
 
 	if desc, ok := descriptions[patternType]; ok {
@@ -324,13 +886,90 @@ func getDescription(patternType string) string {
 	return "Unknown secret type detected"
 }
 
+// NewResult builds a result for a secret found by a detector other than a
+// pattern, such as one keyed on variable names, filling in the description,
+// severity, and fingerprint of rule. The value is dropped in secure mode.
+func (s *Scanner) NewResult(rule, value string, start, line int, confidence float64) Result {
+	s.patternMutex.RLock()
+	meta := s.rules[rule]
+	s.patternMutex.RUnlock()
+	result := Result{
+		Type:        rule,
+		RuleID:      meta.idOf(rule),
+		Value:       value,
+		StartIndex:  start,
+		EndIndex:    start + len(value),
+		LineNumber:  line,
+		Confidence:  withStrength(rule, value, confidence),
+		Severity:    meta.severityOf(rule),
+		Description: meta.descriptionOf(rule),
+		Fingerprint: s.Fingerprint(rule, value),
+	}
+	if lines := strings.Count(value, "\n"); lines > 0 {
+		result.EndLineNumber = line + lines
+	}
+	if s.secure {
+		result.Value = ""
+	}
+	return result
+}
+
+// Redact scans text and returns it with each detected secret replaced by the
+// scanner's placeholder, along with the findings
+func (s *Scanner) Redact(ctx context.Context, text string) (string, []Result, error) {
+	results, err := s.Scan(ctx, text)
+	if err != nil {
+		return "", nil, err
+	}
+	return redact(text, results, s.placeholder), results, nil
+}
+
+// RedactResults replaces the span of each result in text with the scanner's
+// placeholder, for results of an earlier scan of text; see RedactText
+func (s *Scanner) RedactResults(text string, results []Result) string {
+	return redact(text, results, s.placeholder)
+}
+
+// RedactText replaces the span of each result in text with a placeholder naming
+// its type, such as [REDACTED:aws_access_key]. Grouped results are replaced at
+// every one of their locations. Results that overlap an already redacted span
+// are skipped.
+func RedactText(text string, results []Result) string {
+	return redact(text, results, DefaultPlaceholder)
+}
+
+func redact(text string, results []Result, placeholder string) string {
+	sorted := make([]Result, 0, len(results))
+	for _, result := range results {
+		if len(result.Locations) == 0 {
+			sorted = append(sorted, result)
+			continue
+		}
+		for _, loc := range result.Locations {
+			sorted = append(sorted, Result{Type: result.Type, StartIndex: loc.StartIndex, EndIndex: loc.EndIndex})
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartIndex < sorted[j].StartIndex })
+
+	var b strings.Builder
+	last := 0
+	for _, result := range sorted {
+		if result.StartIndex < last || result.EndIndex > len(text) {
+			continue
+		}
+		b.WriteString(text[last:result.StartIndex])
+		b.WriteString(strings.ReplaceAll(placeholder, "{type}", result.Type))
+		last = result.EndIndex
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
 // ErrContextCancelled is returned when the context is cancelled
 var ErrContextCancelled = errors.New("operation cancelled by context")
 
-// MaskSecret masks the secret value for display
+// MaskSecret masks the secret value for display, exposing exposeCount
+// characters at each end
 func MaskSecret(secret string, exposeCount int) string {
-	if len(secret) <= exposeCount {
-		return strings.Repeat("*", len(secret))
-	}
-	return secret[:exposeCount] + strings.Repeat("*", len(secret)-2*exposeCount) + secret[len(secret)-exposeCount:]
+	return MaskOptions{Prefix: exposeCount, Suffix: exposeCount}.Mask(secret)
 }
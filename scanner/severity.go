@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import "fmt"
+
+// Severity ranks how much damage a leaked secret can do
+type Severity string
+
+const (
+	// SeverityCritical marks root-of-trust and cloud credentials
+	SeverityCritical Severity = "critical"
+	// SeverityHigh marks credentials that grant access to a service
+	SeverityHigh Severity = "high"
+	// SeverityMedium marks passwords and credentials of limited scope
+	SeverityMedium Severity = "medium"
+	// SeverityLow marks findings that are rarely exploitable on their own
+	SeverityLow Severity = "low"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// ParseSeverity parses a severity name such as "high"
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(name)
+	if _, ok := severityRanks[s]; !ok {
+		return "", fmt.Errorf("unknown severity %q", name)
+	}
+	return s, nil
+}
+
+// AtLeast reports whether s is as severe as other or more
+func (s Severity) AtLeast(other Severity) bool {
+	return severityRanks[s] >= severityRanks[other]
+}
+
+// Lower returns the next lower severity, stopping at SeverityLow
+func (s Severity) Lower() Severity {
+	switch s {
+	case SeverityCritical:
+		return SeverityHigh
+	case SeverityHigh:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// getSeverity returns the default severity of a pattern
+func getSeverity(patternType string) Severity {
+	severities := map[string]Severity{
+		"aws_access_key":                   SeverityCritical,
+		"aws_secret":                       SeverityCritical,
+		"azure_storage_account_key":        SeverityCritical,
+		"github_token":                     SeverityHigh,
+		"google_api":                       SeverityHigh,
+		"stripe_key":                       SeverityCritical,
+		"slack_token":                      SeverityHigh,
+		"twitter_bearer_token":             SeverityHigh,
+		"facebook_access_token":            SeverityHigh,
+		"digitalocean_access_token":        SeverityHigh,
+		"heroku_api_key":                   SeverityHigh,
+		"generic_api_key":                  SeverityMedium,
+		"sendgrid_api_key":                 SeverityHigh,
+		"twilio_api_key":                   SeverityHigh,
+		"mailgun_api_key":                  SeverityHigh,
+		"paypal_bearer_token":              SeverityCritical,
+		"firebase_api_key":                 SeverityMedium,
+		"square_access_token":              SeverityCritical,
+		"shopify_access_token":             SeverityHigh,
+		"pinterest_access_token":           SeverityMedium,
+		"asana_personal_access_token":      SeverityHigh,
+		"gitlab_personal_access_token":     SeverityHigh,
+		"dropbox_access_token":             SeverityHigh,
+		"microsoft_graph_access_token":     SeverityHigh,
+		"bitbucket_access_token":           SeverityHigh,
+		"huggingface_token":                SeverityHigh,
+		"openai_api_key":                   SeverityHigh,
+		"openai_organization_id":           SeverityLow,
+		"anthropic_api_key":                SeverityHigh,
+		"npm_access_token":                 SeverityHigh,
+		"npm_legacy_token":                 SeverityHigh,
+		"pypi_upload_token":                SeverityHigh,
+		"terraform_cloud_token":            SeverityCritical,
+		"hcp_client_secret":                SeverityHigh,
+		"databricks_token":                 SeverityHigh,
+		"azure_ad_client_secret":           SeverityCritical,
+		"azure_sas_token":                  SeverityHigh,
+		"gcp_service_account":              SeverityCritical,
+		"slack_webhook_url":                SeverityMedium,
+		"discord_webhook_url":              SeverityMedium,
+		"discord_bot_token":                SeverityHigh,
+		"telegram_bot_token":               SeverityHigh,
+		"datadog_api_key":                  SeverityHigh,
+		"datadog_app_key":                  SeverityHigh,
+		"newrelic_user_api_key":            SeverityHigh,
+		"newrelic_insert_key":              SeverityMedium,
+		"newrelic_license_key":             SeverityHigh,
+		"cloudflare_api_token":             SeverityHigh,
+		"cloudflare_global_api_key":        SeverityCritical,
+		"okta_api_token":                   SeverityCritical,
+		"auth0_client_secret":              SeverityHigh,
+		"auth0_management_token":           SeverityHigh,
+		"vault_token":                      SeverityCritical,
+		"vault_legacy_token":               SeverityCritical,
+		"planetscale_service_token":        SeverityHigh,
+		"planetscale_oauth_token":          SeverityHigh,
+		"postman_api_key":                  SeverityHigh,
+		"netlify_access_token":             SeverityHigh,
+		"netlify_legacy_token":             SeverityHigh,
+		"vercel_token":                     SeverityHigh,
+		"github_fine_grained_pat":          SeverityHigh,
+		"github_app_installation_token":    SeverityMedium,
+		"gitlab_deploy_token":              SeverityHigh,
+		"gitlab_runner_registration_token": SeverityMedium,
+		"gitlab_ci_job_token":              SeverityLow,
+		"gitlab_pipeline_trigger_token":    SeverityMedium,
+		"aws_temporary_access_key":         SeverityHigh,
+		"aws_session_token":                SeverityHigh,
+		"stripe_restricted_key":            SeverityHigh,
+		"stripe_webhook_secret":            SeverityHigh,
+		"stripe_test_key":                  SeverityLow,
+		"mongodb_connection_string":        SeverityHigh,
+		"redis_url":                        SeverityHigh,
+		"amqp_url":                         SeverityHigh,
+		"basic_auth_url":                   SeverityHigh,
+		"smtp_password":                    SeverityHigh,
+		"smtp_url":                         SeverityHigh,
+		"msmtp_password":                   SeverityHigh,
+		"sendmail_authinfo":                SeverityHigh,
+		"basic_password":                   SeverityMedium,
+		"complex_password":                 SeverityLow,
+		"rsa_private":                      SeverityHigh,
+		"ssh_private":                      SeverityHigh,
+		"pgp_private":                      SeverityHigh,
+		"generic_private":                  SeverityHigh,
+		"dsa_private":                      SeverityHigh,
+		"ec_private":                       SeverityHigh,
+		"putty_private":                    SeverityHigh,
+		"jwt_private":                      SeverityHigh,
+		"pkcs8_private":                    SeverityHigh,
+		"pem_certificate":                  SeverityLow,
+		"pkcs12_private":                   SeverityMedium,
+		"putty_ppk_private":                SeverityMedium,
+		"cosign_private":                   SeverityHigh,
+		"sigstore_private":                 SeverityHigh,
+		"google_oauth_client_secret":       SeverityHigh,
+		"oauth_client_secret":              SeverityHigh,
+		"apollo_api_key":                   SeverityHigh,
+		"graphql_auth_header":              SeverityHigh,
+		"graphql_api_key_header":           SeverityHigh,
+		"graphql_argument_secret":          SeverityMedium,
+		"dotenv_secret":                    SeverityHigh,
+		"docker_build_arg_secret":          SeverityHigh,
+		"docker_env_secret":                SeverityHigh,
+		"kubernetes_secret":                SeverityHigh,
+		"terraform_secret":                 SeverityHigh,
+		"helm_secret_value":                SeverityHigh,
+		"netrc_password":                   SeverityHigh,
+		"pgpass_password":                  SeverityHigh,
+		"npmrc_auth":                       SeverityHigh,
+		"git_credentials_password":         SeverityHigh,
+	}
+
+	if severity, ok := severities[patternType]; ok {
+		return severity
+	}
+	return SeverityMedium
+}
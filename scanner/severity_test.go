@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import "testing"
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+		atLeast Severity
+		want    bool
+		lower   Severity
+	}{
+		{name: "critical", atLeast: SeverityHigh, want: true, lower: SeverityHigh},
+		{name: "high", atLeast: SeverityHigh, want: true, lower: SeverityMedium},
+		{name: "medium", atLeast: SeverityHigh, want: false, lower: SeverityLow},
+		{name: "low", atLeast: SeverityLow, want: true, lower: SeverityLow},
+		{name: "urgent", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSeverity(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeverity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.AtLeast(tt.atLeast); got != tt.want {
+				t.Errorf("%s.AtLeast(%s) = %v, want %v", s, tt.atLeast, got, tt.want)
+			}
+			if got := s.Lower(); got != tt.lower {
+				t.Errorf("%s.Lower() = %s, want %s", s, got, tt.lower)
+			}
+		})
+	}
+}
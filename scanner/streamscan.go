@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultStreamWindow is the trailing context kept across fragments
+const defaultStreamWindow = 4096
+
+// StreamDetector scans text that arrives in arbitrarily small fragments, such
+// as SSE or LLM token streams. It keeps a rolling buffer so that secrets split
+// across fragment boundaries are still found, and reports each finding once
+// with offsets and line numbers relative to the whole stream. Like Scan, it
+// drops findings below the scanner's minimum confidence and scans the
+// unescaped, normalized, and decoded forms of the text when the scanner is
+// configured to.
+//
+// A StreamDetector is not safe for concurrent use.
+type StreamDetector struct {
+	scanner  *Scanner
+	window   int
+	buf      string
+	base     int         // stream offset of buf[0]
+	line     int         // line number of buf[0]
+	reported map[int]int // stream offsets of the reported findings, start to end
+}
+
+type streamKey struct {
+	start, end int
+}
+
+// StreamOption represents a function that modifies StreamDetector configuration
+type StreamOption func(*StreamDetector)
+
+// WithWindow sets how many bytes of trailing text are kept and rescanned with
+// each new fragment. It must be at least as long as the longest secret plus
+// any key name a pattern needs to see before it.
+func WithWindow(n int) StreamOption {
+	return func(d *StreamDetector) {
+		if n > 0 {
+			d.window = n
+		}
+	}
+}
+
+// NewStreamDetector creates a StreamDetector that uses the scanner's patterns
+func (s *Scanner) NewStreamDetector(opts ...StreamOption) *StreamDetector {
+	d := &StreamDetector{
+		scanner:  s,
+		window:   defaultStreamWindow,
+		line:     1,
+		reported: make(map[int]int),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Write appends a fragment to the stream and returns the findings it
+// completes. A match that reaches the end of the text seen so far may still
+// grow, and one within a PEM block whose END line has not arrived may be part
+// of it, so they are held back until a later fragment completes them or Flush
+// is called.
+func (d *StreamDetector) Write(ctx context.Context, fragment string) ([]Result, error) {
+	d.buf += fragment
+	results, spans, err := d.scan(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	d.trim(spans)
+	return results, nil
+}
+
+// Flush reports the findings held back at the end of the stream and resets the
+// buffer. Offsets of later fragments continue from the end of the stream.
+func (d *StreamDetector) Flush(ctx context.Context) ([]Result, error) {
+	results, _, err := d.scan(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	d.line += strings.Count(d.buf, "\n")
+	d.base += len(d.buf)
+	d.buf = ""
+	d.reported = make(map[int]int)
+	return results, nil
+}
+
+// scan matches the buffer and returns the findings not reported before, along
+// with the spans of every match, which must not be cut by trim
+func (d *StreamDetector) scan(ctx context.Context, final bool) ([]Result, []streamKey, error) {
+	s := d.scanner
+	matches, err := s.scanChunk(ctx, d.buf, 0)
+	if err == nil && (s.unescape || s.normalize || len(s.decoders) > 0) {
+		var encoded []Result
+		if encoded, err = s.scanEncoded(ctx, d.buf, matches); err == nil {
+			matches = append(matches, encoded...)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].StartIndex < matches[j].StartIndex
+	})
+
+	open := d.openBlock()
+	var results []Result
+	spans := make([]streamKey, 0, len(matches))
+	for _, result := range matches {
+		spans = append(spans, streamKey{start: d.base + result.StartIndex, end: d.base + result.EndIndex})
+		if !final && (result.EndIndex == len(d.buf) || (open >= 0 && result.StartIndex >= open)) {
+			continue
+		}
+		// A finding completed by a later fragment is reported once, at its
+		// first complete extent
+		result.StartIndex += d.base
+		result.EndIndex += d.base
+		if _, ok := d.reported[result.StartIndex]; ok || result.Confidence < s.minConfidence {
+			continue
+		}
+		d.reported[result.StartIndex] = result.EndIndex
+		result.LineNumber += d.line - 1
+		if result.EndLineNumber > 0 {
+			result.EndLineNumber += d.line - 1
+		}
+		results = append(results, result)
+	}
+	return results, spans, nil
+}
+
+// openBlock returns the index in the buffer of the last PEM BEGIN line without
+// a complete END line after it, or -1
+func (d *StreamDetector) openBlock() int {
+	begin := strings.LastIndex(d.buf, "-----BEGIN ")
+	if begin < 0 {
+		return -1
+	}
+	if end := strings.Index(d.buf[begin:], "-----END "); end >= 0 &&
+		strings.Contains(d.buf[begin+end+len("-----END "):], "-----") {
+		return -1
+	}
+	return begin
+}
+
+// trim drops text that has fallen out of the window, without cutting through
+// a match, so that reported secrets are not matched again as shorter ones
+func (d *StreamDetector) trim(spans []streamKey) {
+	cut := len(d.buf) - d.window
+	if open := d.openBlock(); open >= 0 && open < cut {
+		cut = open
+	}
+	for _, span := range spans {
+		if start := span.start - d.base; start < cut && span.end-d.base > cut {
+			cut = start
+		}
+	}
+	// Bound the buffer even if a match keeps growing, e.g. a long run of
+	// characters accepted by a permissive pattern
+	if limit := len(d.buf) - 4*d.window; cut < limit {
+		cut = limit
+	}
+	if cut <= 0 {
+		return
+	}
+	for cut < len(d.buf) && !utf8.RuneStart(d.buf[cut]) {
+		cut++
+	}
+
+	d.line += strings.Count(d.buf[:cut], "\n")
+	d.base += cut
+	d.buf = d.buf[cut:]
+	for start, end := range d.reported {
+		if end <= d.base {
+			delete(d.reported, start)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// passwordRules are the rules whose values are chosen by people, so that how
+// easily a value is guessed tells an example such as Password123 apart from a
+// real password
+var passwordRules = map[string]bool{
+	"basic_password":           true,
+	"complex_password":         true,
+	"smtp_password":            true,
+	"msmtp_password":           true,
+	"basic_auth_url":           true,
+	"netrc_password":           true,
+	"pgpass_password":          true,
+	"git_credentials_password": true,
+}
+
+// strengthAdjustment is added to the confidence of a password by its
+// PasswordStrength score. Strong values gain nothing, so that a password rule
+// never outranks a more distinctive pattern matching the same value.
+var strengthAdjustment = [...]float64{-0.3, -0.2, -0.1, 0, 0}
+
+// withStrength returns the confidence of value, adjusted by its strength if
+// pattern is a password rule
+func withStrength(pattern, value string, confidence float64) float64 {
+	if !passwordRules[pattern] {
+		return confidence
+	}
+	confidence += strengthAdjustment[PasswordStrength(value)]
+	return math.Round(math.Max(math.Min(confidence, 1), 0)*100) / 100
+}
+
+// commonPasswords are the most used passwords and password words, most common
+// first
+var commonPasswords = strings.Fields(`
+	password 123456 qwerty admin letmein welcome monkey dragon master login
+	abc123 iloveyou sunshine princess football baseball shadow secret
+	changeme trustno1 superman batman hunter killer jordan michael charlie
+	starwars whatever freedom passw0rd hello root test guest default user
+	summer winter spring autumn soccer hockey ranger buster thomas tigger
+	robert daniel access flower mustang love pass ninja azerty solo
+	computer internet service server database example demo sample temp
+`)
+
+// commonRanks maps each common password to its rank, starting at 1
+var commonRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, word := range commonPasswords {
+		ranks[word] = i + 1
+	}
+	return ranks
+}()
+
+// keyboardRows are the rows of a QWERTY keyboard, whose runs such as asdf are
+// guessed early
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// leet maps the digits and symbols of l33t speak to the letters they replace
+var leet = strings.NewReplacer("@", "a", "4", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+// maxStrengthLength is the length beyond which passwords are not analysed and
+// score 4
+const maxStrengthLength = 64
+
+// PasswordStrength scores how hard password is to guess, from 0 for one of
+// the first thousand guesses to 4 for beyond ten billion. Like zxcvbn, it
+// covers the password with the cheapest sequence of common passwords,
+// keyboard runs, sequences, repeats, years, and single characters.
+func PasswordStrength(password string) int {
+	guesses := log10Guesses(password)
+	for score, limit := range []float64{3, 6, 8, 10} {
+		if guesses < limit {
+			return score
+		}
+	}
+	return 4
+}
+
+// log10Guesses estimates the log10 of the number of guesses to find password
+func log10Guesses(password string) float64 {
+	if len(password) > maxStrengthLength || len(password) == 0 {
+		return math.Inf(1)
+	}
+	lower := strings.ToLower(password)
+	plain := leet.Replace(lower)
+
+	// best[j] is the cheapest cover of password[:j], plus the log10 of the
+	// orders its tokens can come in
+	best := make([]float64, len(password)+1)
+	tokens := make([]int, len(password)+1)
+	for j := 1; j <= len(password); j++ {
+		best[j] = math.Inf(1)
+		for i := 0; i < j; i++ {
+			cost := best[i] + tokenGuesses(password[i:j], lower[i:j], plain[i:j]) + math.Log10(float64(tokens[i]+1))
+			if cost < best[j] {
+				best[j], tokens[j] = cost, tokens[i]+1
+			}
+		}
+	}
+	return best[len(password)]
+}
+
+// tokenGuesses returns the log10 of the guesses to find token, given in its
+// original, lowercase, and l33t-decoded forms
+func tokenGuesses(token, lower, plain string) float64 {
+	if len(token) == 1 {
+		return math.Log10(charsetSize(rune(token[0])))
+	}
+	guesses := math.Inf(1)
+	if rank, ok := commonRanks[lower]; ok {
+		guesses = math.Log10(float64(rank)) + caseVariations(token)
+	} else if rank, ok := commonRanks[plain]; ok {
+		// Each l33t substitution roughly doubles the guesses of a word
+		guesses = math.Log10(float64(rank)) + caseVariations(token) + math.Log10(2)
+	}
+	n := float64(len(token))
+	switch {
+	case len(token) >= 3 && strings.Trim(lower, lower[:1]) == "":
+		guesses = math.Min(guesses, math.Log10(charsetSize(rune(token[0]))*n))
+	case len(token) >= 3 && isSequence(lower):
+		guesses = math.Min(guesses, math.Log10(charsetSize(rune(token[0]))*n))
+	case len(token) >= 4 && isKeyboardRun(lower):
+		guesses = math.Min(guesses, math.Log10(40*n))
+	case len(token) == 4 && (strings.HasPrefix(token, "19") || strings.HasPrefix(token, "20")) && isDigits(token):
+		guesses = math.Min(guesses, math.Log10(120))
+	}
+	return guesses
+}
+
+// caseVariations returns the log10 of the guesses added by the capitals of a
+// word: few for a capital first letter or all capitals, more for others
+func caseVariations(word string) float64 {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case upper == len(word) || (upper == 1 && unicode.IsUpper(rune(word[0]))):
+		return math.Log10(2)
+	default:
+		return float64(upper) * math.Log10(2)
+	}
+}
+
+// charsetSize returns the size of the character class of r
+func charsetSize(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r), unicode.IsUpper(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+// isSequence reports whether s steps by one character, as abc or 987 do
+func isSequence(s string) bool {
+	step := int(s[1]) - int(s[0])
+	if step != 1 && step != -1 {
+		return false
+	}
+	for i := 2; i < len(s); i++ {
+		if int(s[i])-int(s[i-1]) != step {
+			return false
+		}
+	}
+	return true
+}
+
+// isKeyboardRun reports whether s is a run of adjacent keys of one keyboard
+// row, in either direction
+func isKeyboardRun(s string) bool {
+	for _, row := range keyboardRows {
+		if strings.Contains(row, s) || strings.Contains(row, reverse(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{password: "password", want: 0},
+		{password: "Password123", want: 0},
+		{password: "P@ssw0rd!", want: 0},
+		{password: "qwertyuiop", want: 0},
+		{password: "hunter2hunter2", want: 2},
+		{password: "Summer2024!", want: 2},
+		{password: "Tr0ub4dor&3", want: 4},
+		{password: "9v$Kq#x2Lm!pT7", want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := PasswordStrength(tt.password); got != tt.want {
+				t.Errorf("PasswordStrength(%q) = %d (%.2f), want %d", tt.password, got, log10Guesses(tt.password), tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordStrengthConfidence(t *testing.T) {
+	s := New()
+	if err := s.AddPattern("basic_password", `password['":\s]*[=:]\s*['"]?(?P<secret>[^\s'"]{8,})['"]?`); err != nil {
+		t.Fatalf("Failed to add pattern: %v", err)
+	}
+	results, err := s.Scan(context.Background(), "password=Password123\npassword=9v$Kq#x2Lm!pT7\n")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Scan found %d results, want 2", len(results))
+	}
+	confidences := map[string]float64{}
+	for _, r := range results {
+		confidences[r.Value] = r.Confidence
+	}
+	if weak, strong := confidences["Password123"], confidences["9v$Kq#x2Lm!pT7"]; weak != 0.65 || strong != 0.95 {
+		t.Errorf("Scan() confidences = %v and %v, want 0.65 and 0.95", weak, strong)
+	}
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer reports spans to the globally registered OpenTelemetry provider,
+// which is a no-op unless the embedding application configures one
+var tracer = otel.Tracer("github.com/stackloklabs/secret-scanning-api/scanner")
+
+// scanChunkTraced wraps scanChunk in a span for a single chunk worker and
+// returns the results the chunk reports
+func (s *Scanner) scanChunkTraced(ctx context.Context, c chunk) ([]Result, error) {
+	ctx, span := tracer.Start(ctx, "Scanner.scanChunk", trace.WithAttributes(
+		attribute.Int("scanner.chunk_offset", c.offset),
+		attribute.Int("scanner.chunk_length", len(c.text)),
+	))
+	defer span.End()
+
+	results, err := s.scanChunk(ctx, c.text, c.offset)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	results = c.owned(results)
+	span.SetAttributes(attribute.Int("scanner.results", len(results)))
+	return results, nil
+}
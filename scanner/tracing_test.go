@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestScanTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	s := New(WithWorkers(2))
+	if err := s.AddPattern("aws_key", `(?i)AKIA[0-9A-Z]{16}`); err != nil {
+		t.Fatalf("Failed to add pattern: %v", err)
+	}
+
+	text := generateLargeText(25000)
+	for i := 0; i < 2; i++ {
+		if _, err := s.Scan(context.Background(), text); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+	}
+
+	var scans []sdktrace.ReadOnlySpan
+	chunks := 0
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "Scanner.Scan":
+			scans = append(scans, span)
+		case "Scanner.scanChunk":
+			chunks++
+		}
+	}
+
+	if len(scans) != 2 {
+		t.Fatalf("Got %d Scan spans, want 2", len(scans))
+	}
+	if chunks != 3 {
+		t.Errorf("Got %d chunk spans, want 3", chunks)
+	}
+
+	attrs := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+
+	first, second := attrs(scans[0]), attrs(scans[1])
+	if got := first["scanner.chunks"].AsInt64(); got != 3 {
+		t.Errorf("scanner.chunks = %d, want 3", got)
+	}
+	if got := first["scanner.patterns"].AsInt64(); got != 1 {
+		t.Errorf("scanner.patterns = %d, want 1", got)
+	}
+	if first["scanner.cache_hit"].AsBool() || !second["scanner.cache_hit"].AsBool() {
+		t.Errorf("Expected cache miss then hit, got %v then %v", first["scanner.cache_hit"], second["scanner.cache_hit"])
+	}
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// WithUnescaping makes Scan also match against the text with its backslash
+// escapes resolved, so that secrets written as \x41KIA... or PEM keys with
+// \n-escaped line breaks in JSON, Go, or shell string literals are found.
+// Findings are reported at their exact position in the original text, with
+// Encoded set and Encoding "escape".
+func WithUnescaping(enabled bool) ScannerOption {
+	return func(s *Scanner) {
+		s.unescape = enabled
+	}
+}
+
+// simpleEscapes maps the character after a backslash to what it stands for
+var simpleEscapes = map[byte]byte{
+	'n': '\n', 't': '\t', 'r': '\r', 'f': '\f', 'v': '\v', 'b': '\b', 'a': '\a',
+	'\\': '\\', '"': '"', '\'': '\'', '/': '/', '`': '`',
+}
+
+// unescape resolves the backslash escapes of text: \xNN, \uNNNN (including
+// UTF-16 surrogate pairs), \UNNNNNNNN, three-digit octal, and single-character
+// escapes such as \n. Unknown escapes are kept as they are. offsets[i] is the
+// index in text of the escape or character that produced byte i of the
+// result, and offsets[len(result)] is len(text).
+func unescape(text string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	emit := func(start int, s string) {
+		b.WriteString(s)
+		for j := 0; j < len(s); j++ {
+			offsets = append(offsets, start)
+		}
+	}
+
+	for i := 0; i < len(text); {
+		if text[i] != '\\' || i+1 == len(text) {
+			emit(i, text[i:i+1])
+			i++
+			continue
+		}
+
+		c := text[i+1]
+		if r, ok := simpleEscapes[c]; ok {
+			emit(i, string([]byte{r}))
+			i += 2
+			continue
+		}
+
+		switch {
+		case c == 'x' && i+4 <= len(text):
+			if v, err := strconv.ParseUint(text[i+2:i+4], 16, 8); err == nil {
+				emit(i, string([]byte{byte(v)}))
+				i += 4
+				continue
+			}
+		case c == 'u' && i+6 <= len(text):
+			v, err := strconv.ParseUint(text[i+2:i+6], 16, 16)
+			if err != nil {
+				break
+			}
+			r, n := rune(v), 6
+			if utf16.IsSurrogate(r) && i+12 <= len(text) && text[i+6:i+8] == `\u` {
+				if lo, err := strconv.ParseUint(text[i+8:i+12], 16, 16); err == nil {
+					r, n = utf16.DecodeRune(r, rune(lo)), 12
+				}
+			}
+			emit(i, string(r))
+			i += n
+			continue
+		case c == 'U' && i+10 <= len(text):
+			if v, err := strconv.ParseUint(text[i+2:i+10], 16, 32); err == nil && utf8.ValidRune(rune(v)) {
+				emit(i, string(rune(v)))
+				i += 10
+				continue
+			}
+		case c >= '0' && c <= '3' && i+4 <= len(text):
+			if v, err := strconv.ParseUint(text[i+1:i+4], 8, 8); err == nil {
+				emit(i, string([]byte{byte(v)}))
+				i += 4
+				continue
+			}
+		}
+		emit(i, text[i:i+1])
+		i++
+	}
+	offsets = append(offsets, len(text))
+	return b.String(), offsets
+}
+
+// scanUnescaped scans text with its escapes resolved and returns the findings
+// that the scan of the raw text, raw, missed, at their position in text
+func (s *Scanner) scanUnescaped(ctx context.Context, text string, raw []Result) ([]Result, error) {
+	if !strings.Contains(text, `\`) {
+		return nil, nil
+	}
+	plain, offsets := unescape(text)
+	if plain == text {
+		return nil, nil
+	}
+	results, err := s.scan(ctx, plain)
+	if err != nil {
+		return nil, err
+	}
+
+	return remap(text, results, offsets, raw, "escape"), nil
+}
+
+// remap moves results found in a transformed form of text, whose bytes come
+// from text at offsets, to their position in text and marks them with
+// encoding, dropping those that the scan of the raw text, raw, already found
+func remap(text string, results []Result, offsets []int, raw []Result, encoding string) []Result {
+	found := make(map[int]string, len(raw))
+	for _, r := range raw {
+		found[r.StartIndex] = r.Fingerprint
+	}
+	var moved []Result
+	for _, result := range results {
+		start, end := offsets[result.StartIndex], offsets[result.EndIndex]
+		if found[start] == result.Fingerprint {
+			continue
+		}
+		result.StartIndex, result.EndIndex = start, end
+		result.LineNumber = strings.Count(text[:start], "\n") + 1
+		result.EndLineNumber = 0
+		if lines := strings.Count(text[start:end], "\n"); lines > 0 {
+			result.EndLineNumber = result.LineNumber + lines
+		}
+		result.Encoded, result.Encoding = true, encoding
+		result.Context = ""
+		moved = append(moved, result)
+	}
+	return moved
+}
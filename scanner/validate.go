@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner
+
+import (
+	"encoding/base64"
+	"hash/crc32"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// validators check the internal structure of a pattern's matches offline,
+// such as an ID encoded in a token, and reject those that only look the part
+var validators = map[string]func(value string) bool{
+	"discord_bot_token":             validDiscordBotToken,
+	"auth0_management_token":        validAuth0Token,
+	"github_token":                  validGitHubToken,
+	"github_app_installation_token": validGitHubToken,
+	"basic_auth_url":                validURLPassword,
+	"complex_password":              validComplexPassword,
+}
+
+// Valid reports whether value passes the validator of pattern, if it has one
+func Valid(pattern, value string) bool {
+	validator, ok := validators[pattern]
+	return !ok || validator(value)
+}
+
+// validDiscordBotToken reports whether the first segment of a Discord bot
+// token decodes to a snowflake: the decimal ID of the bot user, whose top bits
+// are a timestamp since the Discord epoch
+func validDiscordBotToken(value string) bool {
+	segment, _, _ := strings.Cut(value, ".")
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil || len(decoded) < 17 || len(decoded) > 20 {
+		return false
+	}
+	id, err := strconv.ParseUint(string(decoded), 10, 64)
+	return err == nil && id>>22 > 0
+}
+
+// validAuth0Token reports whether value is a JWT issued by an Auth0 tenant,
+// such as https://acme.us.auth0.com/
+func validAuth0Token(value string) bool {
+	token := decodeJWT(value, time.Time{})
+	if token == nil {
+		return false
+	}
+	issuer, err := url.Parse(token.Issuer)
+	return err == nil && strings.HasSuffix(issuer.Hostname(), ".auth0.com")
+}
+
+// base62 is the alphabet of GitHub token checksums
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// validGitHubToken reports whether the last 6 characters of a GitHub token
+// such as ghp_ and ghs_ are the base62 CRC32 checksum of the 30 random
+// characters before them
+func validGitHubToken(value string) bool {
+	_, body, ok := strings.Cut(value, "_")
+	if !ok || len(body) != 36 {
+		return false
+	}
+	checksum := crc32.ChecksumIEEE([]byte(body[:30]))
+	var encoded [6]byte
+	for i := len(encoded) - 1; i >= 0; i-- {
+		encoded[i] = base62[checksum%62]
+		checksum /= 62
+	}
+	return checksum == 0 && string(encoded[:]) == body[30:]
+}
+
+// trivialPasswords are URL passwords of examples and documentation rather
+// than of real accounts
+var trivialPasswords = map[string]bool{
+	"password": true, "passwd": true, "pass": true, "secret": true, "changeme": true,
+	"example": true, "pwd": true, "token": true, "x-oauth-basic": true,
+}
+
+// validURLPassword reports whether value is a non-trivial URL password: at
+// least 6 characters, not a well-known example, a single repeated character,
+// or a template placeholder such as ${DB_PASSWORD}
+func validURLPassword(value string) bool {
+	if len(value) < 6 || trivialPasswords[strings.ToLower(value)] ||
+		strings.Trim(value, value[:1]) == "" || strings.ContainsAny(value, "$<>{}") {
+		return false
+	}
+	return true
+}
+
+// complexPasswordEntropy is the minimum entropy in bits per character of
+// values assigned to password variables, below which they are more likely
+// expressions such as os.getenv than passwords
+const complexPasswordEntropy = 2.5
+
+// validComplexPassword reports whether value mixes letters with digits or
+// symbols and is random enough to be a password
+func validComplexPassword(value string) bool {
+	if entropy(value) < complexPasswordEntropy {
+		return false
+	}
+	letter, other := false, false
+	for _, r := range value {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r) || strings.ContainsRune("@$!%*#?&^~+/=", r):
+			other = true
+		}
+	}
+	return letter && other
+}
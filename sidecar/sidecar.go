@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sidecar exposes the scanner as a gRPC service for deployment next to
+// applications that should scan text, such as logs, before shipping it, and
+// provides a small client for it. The service uses well-known protobuf types
+// so that clients in any language need no generated code:
+//
+//	secretscanning.v1.Scanner/Scan   google.protobuf.StringValue -> google.protobuf.Struct {"results": [...], "ruleset_version": "..."}
+//	secretscanning.v1.Scanner/Redact google.protobuf.StringValue -> google.protobuf.StringValue
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+const (
+	// ServiceName is the name of the gRPC service, also used for its health status
+	ServiceName = "secretscanning.v1.Scanner"
+	// ScanMethod is the full name of the Scan method
+	ScanMethod = "/" + ServiceName + "/Scan"
+	// RedactMethod is the full name of the Redact method
+	RedactMethod = "/" + ServiceName + "/Redact"
+)
+
+// ScannerServer is the server API of the scan service
+type ScannerServer interface {
+	Scan(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error)
+	Redact(context.Context, *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+// Service implements ScannerServer with a scanner
+type Service struct {
+	scanner *scanner.Scanner
+}
+
+// NewService creates a Service that scans with s. Use a scanner in secure mode
+// so that responses never carry secret values.
+func NewService(s *scanner.Scanner) *Service {
+	return &Service{scanner: s}
+}
+
+// Scan returns the findings in the text and the version of the ruleset that
+// found them
+func (svc *Service) Scan(ctx context.Context, in *wrapperspb.StringValue) (*structpb.Struct, error) {
+	results, err := svc.scanner.Scan(ctx, in.GetValue())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if results == nil {
+		results = []scanner.Result{}
+	}
+
+	// Round-trip through JSON so that results keep their JSON field names
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode results: %v", err)
+	}
+	var list []interface{}
+	if err := json.Unmarshal(encoded, &list); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode results: %v", err)
+	}
+	out, err := structpb.NewStruct(map[string]interface{}{"results": list, "ruleset_version": scanner.RulesetVersion})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode results: %v", err)
+	}
+	return out, nil
+}
+
+// Redact returns the text with each detected secret replaced by the scanner's
+// placeholder
+func (svc *Service) Redact(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	redacted, _, err := svc.scanner.Redact(ctx, in.GetValue())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return wrapperspb.String(redacted), nil
+}
+
+func statusError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Errorf(codes.Internal, "failed to scan: %v", err)
+}
+
+// Register registers the service on a gRPC server
+func Register(r grpc.ServiceRegistrar, svc ScannerServer) {
+	r.RegisterService(&serviceDesc, svc)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*ScannerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Scan", Handler: scanHandler},
+		{MethodName: "Redact", Handler: redactHandler},
+	},
+	Metadata: "secretscanning/v1/scanner.proto",
+}
+
+func scanHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScannerServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: ScanMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScannerServer).Scan(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func redactHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScannerServer).Redact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: RedactMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScannerServer).Redact(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Client calls a scan sidecar
+type Client struct {
+	conn grpc.ClientConnInterface
+	cc   *grpc.ClientConn // set when the client owns the connection
+}
+
+// NewClient creates a Client on an existing connection
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{conn: conn}
+}
+
+// Dial creates a Client connected to the sidecar at target, such as
+// "localhost:50051". The connection is plaintext unless opts configure
+// transport credentials, as sidecars are reached over the pod's loopback.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	cc, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to sidecar: %w", err)
+	}
+	return &Client{conn: cc, cc: cc}, nil
+}
+
+// Close closes the connection opened by Dial
+func (c *Client) Close() error {
+	if c.cc == nil {
+		return nil
+	}
+	return c.cc.Close()
+}
+
+// Scan returns the findings in text
+func (c *Client) Scan(ctx context.Context, text string) ([]scanner.Result, error) {
+	out := new(structpb.Struct)
+	if err := c.conn.Invoke(ctx, ScanMethod, wrapperspb.String(text), out); err != nil {
+		return nil, err
+	}
+
+	encoded, err := protojson.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode results: %w", err)
+	}
+	var decoded struct {
+		Results []scanner.Result `json:"results"`
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode results: %w", err)
+	}
+	return decoded.Results, nil
+}
+
+// Redact returns text with each detected secret replaced by a placeholder
+func (c *Client) Redact(ctx context.Context, text string) (string, error) {
+	out := new(wrapperspb.StringValue)
+	if err := c.conn.Invoke(ctx, RedactMethod, wrapperspb.String(text), out); err != nil {
+		return "", err
+	}
+	return out.GetValue(), nil
+}
@@ -0,0 +1,391 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package structscan scans the string values of JSON and YAML documents one at
+// a time and reports the key path of each finding, such as
+// spec.containers[0].env[3].value, alongside its line number
+package structscan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+// sensitiveKey matches key names whose values are credentials by name
+var sensitiveKey = regexp.MustCompile(`(?i)pass(?:wd|word)?|secret|token|api[_-]?key|credential|private[_-]?key|auth`)
+
+// keyBoost is added to the confidence of findings in the value of a key that
+// matches sensitiveKey
+const keyBoost = 0.1
+
+// IsStructured reports whether path names a JSON or YAML document by its
+// extension
+func IsStructured(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Scan parses content as one or more JSON or YAML documents and scans each
+// string value with s. Values of mapping keys are scanned as "key: value", so
+// that patterns keyed on names such as "password" still match. Results have
+// Path set, and positions and line numbers in content; positions inside
+// quoted values with escapes or folded block scalars are approximate.
+// Patterns that require a context keyword are also matched against the whole
+// document, since a value alone rarely names its provider.
+func Scan(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	w := walker{ctx: ctx, s: s, content: content, lines: lineStarts(content)}
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+		if err := w.walk(&doc, "", nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.scanContext(); err != nil {
+		return nil, err
+	}
+	return w.results, nil
+}
+
+type walker struct {
+	ctx     context.Context
+	s       *scanner.Scanner
+	content string
+	lines   []int
+	results []scanner.Result
+	// spans are the positions and paths of the scanned values
+	spans []span
+}
+
+type span struct {
+	start, end int
+	path       string
+}
+
+// walk scans the string scalars below node. key is the mapping key node
+// whose value node is, if any.
+func (w *walker) walk(node *yaml.Node, path string, key *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := w.walk(child, path, nil); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		account := serviceAccountText(node)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if account != "" && k.Value == "private_key" {
+				found, err := w.scanServiceAccount(account, v, join(path, k.Value))
+				if err != nil {
+					return err
+				}
+				if found {
+					continue
+				}
+			}
+			if err := w.walk(v, join(path, k.Value), k); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := w.walk(child, path+"["+strconv.Itoa(i)+"]", nil); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && node.Value != "" {
+			return w.scanValue(node, path, key)
+		}
+	}
+	return nil
+}
+
+// join appends key to path, bracketing keys that would be ambiguous in dotted
+// form
+func join(path, key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]\" ") {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func (w *walker) scanValue(node *yaml.Node, path string, key *yaml.Node) error {
+	text, prefix := node.Value, 0
+	if key != nil {
+		text = key.Value + ": " + node.Value
+		prefix = len(key.Value) + 2
+	}
+	w.spans = append(w.spans, span{w.offset(node, 0), w.offset(node, len(node.Value)), path})
+	results, err := w.s.Scan(w.ctx, text)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		result.Path = path
+		start, end := result.StartIndex-prefix, result.EndIndex-prefix
+		if start < 0 {
+			// The match begins in the key
+			start, end = w.offset(key, result.StartIndex), w.offset(node, end)
+		} else {
+			start, end = w.offset(node, start), w.offset(node, end)
+			if key != nil && sensitiveKey.MatchString(key.Value) {
+				result.Confidence = min(1, result.Confidence+keyBoost)
+			}
+		}
+		if end < start {
+			end = start
+		}
+		result.StartIndex, result.EndIndex = start, end
+		result.LineNumber = w.line(start)
+		result.EndLineNumber = 0
+		if endLine := w.line(max(start, end-1)); endLine > result.LineNumber {
+			result.EndLineNumber = endLine
+		}
+		result.Locations = nil
+		w.results = append(w.results, result)
+	}
+	return nil
+}
+
+// scanContext scans the whole document for the patterns that require a
+// context keyword, such as a datadog key below a datadog: mapping key, and
+// adds the findings that no value scan reported, with the path of the value
+// they start in
+func (w *walker) scanContext() error {
+	results, err := w.s.Scan(w.ctx, w.content)
+	if err != nil {
+		return err
+	}
+	added := false
+	for _, result := range results {
+		if !scanner.RequiresContext(result.Type) || w.reported(result.StartIndex, result.EndIndex) {
+			continue
+		}
+		for _, v := range w.spans {
+			if result.StartIndex >= v.start && result.StartIndex < v.end {
+				result.Path = v.path
+				break
+			}
+		}
+		result.Locations = nil
+		w.results = append(w.results, result)
+		added = true
+	}
+	if added {
+		sort.SliceStable(w.results, func(i, j int) bool {
+			return w.results[i].StartIndex < w.results[j].StartIndex
+		})
+	}
+	return nil
+}
+
+// reported reports whether a result already covers part of start to end
+func (w *walker) reported(start, end int) bool {
+	for _, r := range w.results {
+		if r.StartIndex < end && start < r.EndIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceAccountText returns a Google Cloud service account key file mapping
+// as JSON text in the field order of the files Google issues, so that the
+// service account rule sees the key together with the account it belongs to.
+// It returns "" if node is not such a mapping.
+func serviceAccountText(node *yaml.Node) string {
+	fields := make(map[string]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if k, v := node.Content[i], node.Content[i+1]; v.Kind == yaml.ScalarNode {
+			fields[k.Value] = v.Value
+		}
+	}
+	if fields["type"] != "service_account" || fields["private_key"] == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range []string{"type", "project_id", "private_key", "client_email"} {
+		if value, ok := fields[name]; ok {
+			quoted, _ := json.Marshal(value)
+			fmt.Fprintf(&b, "  %q: %s,\n", name, quoted)
+		}
+	}
+	return strings.TrimSuffix(b.String(), ",\n") + "\n}\n"
+}
+
+// scanServiceAccount scans the text of a service account key file mapping
+// and reports its service account findings at the private_key value node. It
+// returns whether there were any, so that the key is otherwise scanned as an
+// ordinary value.
+func (w *walker) scanServiceAccount(text string, node *yaml.Node, path string) (bool, error) {
+	results, err := w.s.Scan(w.ctx, text)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, result := range results {
+		if result.ServiceAccount == nil {
+			continue
+		}
+		found = true
+		result.Path = path
+		result.StartIndex, result.EndIndex = w.offset(node, 0), w.offset(node, len(node.Value))
+		result.LineNumber = w.line(result.StartIndex)
+		result.EndLineNumber = 0
+		if endLine := w.line(max(result.StartIndex, result.EndIndex-1)); endLine > result.LineNumber {
+			result.EndLineNumber = endLine
+		}
+		result.Locations = nil
+		w.results = append(w.results, result)
+	}
+	return found, nil
+}
+
+// offset returns the position in the document of byte i of node's value
+func (w *walker) offset(node *yaml.Node, i int) int {
+	i = max(0, min(i, len(node.Value)))
+	switch node.Style {
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		// Block content starts on the line after the indicator, indented
+		line := node.Line + strings.Count(node.Value[:i], "\n")
+		col := i - (strings.LastIndexByte(node.Value[:i], '\n') + 1)
+		if line >= len(w.lines) {
+			return len(w.content)
+		}
+		start := w.lines[line]
+		text := w.content[start:]
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		return min(len(w.content), start+indent+col)
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		return w.column(node) + 1 + i
+	}
+	return w.column(node) + i
+}
+
+// column returns the position in the document of node's first character
+func (w *walker) column(node *yaml.Node) int {
+	return nodeStart(w.content, w.lines, node)
+}
+
+// Value is a string value of a document
+type Value struct {
+	// Path is the key path of the value, as in spec.containers[0].image
+	Path string
+	// Key is the mapping key of the value, or empty for sequence items
+	Key   string
+	Value string
+	// Start and End span the value, without quotes
+	Start, End int
+	Line       int
+}
+
+// Values returns the non-empty string values of one or more JSON or YAML
+// documents, with the key paths Scan reports
+func Values(content string) ([]Value, error) {
+	w := walker{content: content, lines: lineStarts(content)}
+	var values []Value
+	var walk func(node *yaml.Node, path string, key *yaml.Node)
+	walk = func(node *yaml.Node, path string, key *yaml.Node) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path, nil)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], join(path, node.Content[i].Value), node.Content[i])
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, path+"["+strconv.Itoa(i)+"]", nil)
+			}
+		case yaml.ScalarNode:
+			if node.Tag != "!!str" || node.Value == "" {
+				return
+			}
+			v := Value{Path: path, Value: node.Value, Start: w.offset(node, 0), Line: node.Line}
+			v.End = w.offset(node, len(node.Value))
+			if key != nil {
+				v.Key = key.Value
+			}
+			values = append(values, v)
+		}
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return values, nil
+			}
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+		walk(&doc, "", nil)
+	}
+}
+
+// ValueOffset returns the position in content of the first character of the
+// value of a scalar node parsed from content, after any opening quote
+func ValueOffset(content string, node *yaml.Node) int {
+	w := walker{content: content, lines: lineStarts(content)}
+	return w.offset(node, 0)
+}
+
+func nodeStart(content string, lines []int, node *yaml.Node) int {
+	if node.Line < 1 || node.Line > len(lines) {
+		return 0
+	}
+	pos := lines[node.Line-1]
+	for col := 1; col < node.Column && pos < len(content); col++ {
+		_, size := utf8.DecodeRuneInString(content[pos:])
+		pos += size
+	}
+	return pos
+}
+
+// line returns the 1-based line number of position pos
+func (w *walker) line(pos int) int {
+	return max(sort.SearchInts(w.lines, pos+1), 1)
+}
+
+// lineStarts returns the position of the first character of each line
+func lineStarts(content string) []int {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' && i+1 < len(content) {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
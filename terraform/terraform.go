@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+// Package terraform scans Terraform state and variable files, which hold the
+// plain text values of every resource attribute and variable, including those
+// marked sensitive, and reports findings with the resource address
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/dotenv"
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/structscan"
+)
+
+// Rule is the result type of values flagged as sensitive by their attribute
+// or variable name, or because Terraform marks them sensitive
+const Rule = "terraform_secret"
+
+// Confidences of flagged values: Terraform's own sensitive marking is a
+// stronger signal than a name
+const (
+	markedConfidence = 0.8
+	nameConfidence   = 0.7
+)
+
+// sensitiveName matches attribute and variable names that hold secrets, such
+// as master_password, secret, primary_access_key, or primary_connection_string
+var sensitiveName = regexp.MustCompile(`(?i)(?:^|_)(?:password|passwd|secret|secret_key|access_key|private_key(?:_pem|_openssh)?|token|api_key|client_secret|connection_string|sas)$`)
+
+// Attribute is a string value of a resource attribute, output, or variable
+type Attribute struct {
+	// Address is the resource address, as in module.db.aws_db_instance.main[0],
+	// output.name, or var.name
+	Address string
+	// Name is the attribute path below the address, as in password or
+	// tags.Owner, and empty for outputs and top-level variables
+	Name  string
+	Value string
+	// Start and End span the value, without quotes
+	Start, End int
+	Line       int
+	// Sensitive is set when Terraform marks the value sensitive
+	Sensitive bool
+}
+
+// Path returns the address of the attribute, as in aws_db_instance.main.password
+func (a Attribute) Path() string {
+	if a.Name == "" {
+		return a.Address
+	}
+	if strings.HasPrefix(a.Name, "[") {
+		return a.Address + a.Name
+	}
+	return a.Address + "." + a.Name
+}
+
+// IsState reports whether path names a Terraform state file, such as
+// terraform.tfstate or terraform.tfstate.backup
+func IsState(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return strings.HasSuffix(base, ".tfstate") || strings.HasSuffix(base, ".tfstate.backup")
+}
+
+// ParseState returns the string values of the resource attributes and outputs
+// of a Terraform state file
+func ParseState(content string) ([]Attribute, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+
+	var attrs []Attribute
+	if resources := value(root, "resources"); resources != nil {
+		for _, resource := range resources.Content {
+			address := scalar(resource, "type") + "." + scalar(resource, "name")
+			if scalar(resource, "mode") == "data" {
+				address = "data." + address
+			}
+			if module := scalar(resource, "module"); module != "" {
+				address = module + "." + address
+			}
+			instances := value(resource, "instances")
+			if instances == nil {
+				continue
+			}
+			for _, instance := range instances.Content {
+				addr := address
+				if key := value(instance, "index_key"); key != nil && key.Kind == yaml.ScalarNode {
+					addr += index(key)
+				}
+				marked := sensitivePaths(value(instance, "sensitive_attributes"))
+				attrs = collect(content, attrs, value(instance, "attributes"), addr, "", marked)
+			}
+		}
+	}
+	if outputs := value(root, "outputs"); outputs != nil && outputs.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(outputs.Content); i += 2 {
+			name, output := outputs.Content[i].Value, outputs.Content[i+1]
+			marked := map[string]bool{}
+			if scalar(output, "sensitive") == "true" {
+				marked[""] = true
+			}
+			attrs = collect(content, attrs, value(output, "value"), "output."+name, "", marked)
+		}
+	}
+	return attrs, nil
+}
+
+// collect appends the string values below node. Values below a path in marked
+// are sensitive.
+func collect(content string, attrs []Attribute, node *yaml.Node, address, name string, marked map[string]bool) []Attribute {
+	if node == nil {
+		return attrs
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			attrs = collect(content, attrs, node.Content[i+1], address, join(name, node.Content[i].Value), marked)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			attrs = collect(content, attrs, child, address, name+"["+strconv.Itoa(i)+"]", marked)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" || node.Value == "" {
+			return attrs
+		}
+		start := structscan.ValueOffset(content, node)
+		attrs = append(attrs, Attribute{
+			Address:   address,
+			Name:      name,
+			Value:     node.Value,
+			Start:     start,
+			End:       start + len(node.Value),
+			Line:      node.Line,
+			Sensitive: isMarked(marked, name),
+		})
+	}
+	return attrs
+}
+
+// isMarked reports whether name or one of its parents is in marked
+func isMarked(marked map[string]bool, name string) bool {
+	for {
+		if marked[name] {
+			return true
+		}
+		i := strings.LastIndexAny(name, ".[")
+		if i < 0 {
+			return marked[""]
+		}
+		name = name[:i]
+	}
+}
+
+// sensitivePaths returns the attribute paths of an instance's
+// sensitive_attributes, which are lists of get_attr and index steps
+func sensitivePaths(node *yaml.Node) map[string]bool {
+	paths := map[string]bool{}
+	if node == nil {
+		return paths
+	}
+	for _, steps := range node.Content {
+		path := ""
+		for _, step := range steps.Content {
+			v := value(step, "value")
+			if v == nil {
+				continue
+			}
+			switch scalar(step, "type") {
+			case "get_attr":
+				path = join(path, v.Value)
+			case "index":
+				if key := value(v, "value"); key != nil {
+					path += index(key)
+				}
+			}
+		}
+		if path != "" {
+			paths[path] = true
+		}
+	}
+	return paths
+}
+
+// index returns the address suffix of an instance or index key, as in [0] or
+// ["primary"]
+func index(key *yaml.Node) string {
+	if key.Tag == "!!int" {
+		return "[" + key.Value + "]"
+	}
+	return "[" + strconv.Quote(key.Value) + "]"
+}
+
+// join appends key to a dotted attribute path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// value returns the value of key in a mapping node, or nil
+func value(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalar returns the scalar value of key in a mapping node, or ""
+func scalar(node *yaml.Node, key string) string {
+	if v := value(node, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// Sensitive reports whether an attribute holds a secret: Terraform marks it
+// sensitive, or its last name is one such as password or secret_key
+func Sensitive(a Attribute) bool {
+	if a.Sensitive {
+		return true
+	}
+	name := a.Name
+	if name == "" {
+		name = strings.TrimPrefix(a.Address, "var.")
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return sensitiveName.MatchString(name) || dotenv.Sensitive(name, a.Value)
+}
+
+// ScanState scans a Terraform state file with s and adds a result for each
+// sensitive attribute or output value that no pattern matched. Results in an
+// attribute value have Path set to its address, as in
+// aws_iam_access_key.ci.secret.
+func ScanState(ctx context.Context, s *scanner.Scanner, content string) ([]scanner.Result, error) {
+	attrs, err := ParseState(content)
+	if err != nil {
+		return nil, err
+	}
+	results, err := structscan.Scan(ctx, s, content)
+	if err != nil {
+		return nil, err
+	}
+	return flag(s, results, attrs), nil
+}
+
+// flag attributes results to the values they were found in and adds a result
+// for each sensitive value without one
+func flag(s *scanner.Scanner, results []scanner.Result, attrs []Attribute) []scanner.Result {
+	for _, a := range attrs {
+		matched := false
+		for i := range results {
+			r := &results[i]
+			if r.StartIndex < a.Start || r.StartIndex >= max(a.End, a.Start+1) {
+				continue
+			}
+			r.Path = a.Path()
+			matched = matched || r.Severity.AtLeast(scanner.SeverityMedium)
+		}
+		if matched || len(a.Value) < 4 || dotenv.IsReference(a.Value) || scanner.IsPlaceholder(a.Value) || !Sensitive(a) {
+			continue
+		}
+		confidence := nameConfidence
+		if a.Sensitive {
+			confidence = markedConfidence
+		}
+		result := s.NewResult(Rule, a.Value, a.Start, a.Line, confidence)
+		result.Path = a.Path()
+		results = append(results, result)
+	}
+	return results
+}
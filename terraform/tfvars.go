@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+	"github.com/stackloklabs/secret-scanning-api/structscan"
+)
+
+// IsVars reports whether path names a Terraform variable definitions file,
+// such as terraform.tfvars, prod.auto.tfvars, or vars.tfvars.json
+func IsVars(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return strings.HasSuffix(base, ".tfvars") || strings.HasSuffix(base, ".tfvars.json")
+}
+
+// definition matches an argument or object attribute up to its value, as in
+// db_password = or "Owner":
+var definition = regexp.MustCompile(`^[ \t]*"?([A-Za-z0-9_.-]+)"?[ \t]*[=:][ \t]*`)
+
+// closing matches a line that closes an object
+var closing = regexp.MustCompile(`^[ \t]*}`)
+
+// ParseVars returns the quoted string values of a variable definitions file,
+// including those of nested objects, with addresses such as var.db_password
+// and names such as tags.Owner. Heredocs and values spanning lines are skipped.
+func ParseVars(content string) []Attribute {
+	var attrs []Attribute
+	var objects []string
+	line := 1
+	for pos := 0; pos < len(content); line++ {
+		end := strings.IndexByte(content[pos:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += pos
+		}
+		text := content[pos:end]
+		trimmed := strings.TrimSpace(text)
+
+		switch m := definition.FindStringSubmatchIndex(text); {
+		case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//"):
+		case m != nil:
+			name := text[m[2]:m[3]]
+			start := pos + m[1]
+			switch {
+			case start < end && content[start] == '{' && !strings.Contains(content[start:end], "}"):
+				objects = append(objects, name)
+			case start < end && content[start] == '"':
+				if valueEnd := closingQuote(content, start+1, end); valueEnd > start+1 {
+					address := "var." + name
+					attrName := ""
+					if len(objects) > 0 {
+						address = "var." + objects[0]
+						attrName = strings.Join(append(objects[1:len(objects):len(objects)], name), ".")
+					}
+					attrs = append(attrs, Attribute{Address: address, Name: attrName, Value: content[start+1 : valueEnd], Start: start + 1, End: valueEnd, Line: line})
+				}
+			}
+		case closing.MatchString(text) && len(objects) > 0:
+			objects = objects[:len(objects)-1]
+		}
+		pos = end + 1
+	}
+	return attrs
+}
+
+// closingQuote returns the position of the quote closing the string starting
+// at pos, or -1
+func closingQuote(content string, pos, end int) int {
+	for i := pos; i < end; i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseVarsJSON returns the string values of a JSON variable definitions file
+func parseVarsJSON(content string) ([]Attribute, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse variables: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var attrs []Attribute
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		attrs = collect(content, attrs, root.Content[i+1], "var."+root.Content[i].Value, "", nil)
+	}
+	return attrs, nil
+}
+
+// ScanVars scans a variable definitions file, in HCL or JSON syntax according
+// to path, with s and adds a result for each sensitive variable value that no
+// pattern matched. Results in a value have Path set to the variable, as in
+// var.db_password.
+func ScanVars(ctx context.Context, s *scanner.Scanner, path, content string) ([]scanner.Result, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		attrs, err := parseVarsJSON(content)
+		if err != nil {
+			return nil, err
+		}
+		results, err := structscan.Scan(ctx, s, content)
+		if err != nil {
+			return nil, err
+		}
+		return flag(s, results, attrs), nil
+	}
+
+	results, err := s.Scan(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	return flag(s, results, ParseVars(content)), nil
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: Copyright 2023 Stacklok
+// SPDX-License-Identifier: Apache-2.0
+
+package terraform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stackloklabs/secret-scanning-api/scanner"
+)
+
+func TestParseVars(t *testing.T) {
+	content := "# database\n" +
+		"db_password = \"hunter2-prod\"\n" +
+		"instance_count = 3\n" +
+		"tags = {\n" +
+		"  Owner = \"platform\"\n" +
+		"  \"cost-center\" = \"42\"\n" +
+		"}\n" +
+		"region = \"us-east-1\" // default\n"
+
+	want := []struct{ path, value string }{
+		{"var.db_password", "hunter2-prod"},
+		{"var.tags.Owner", "platform"},
+		{"var.tags.cost-center", "42"},
+		{"var.region", "us-east-1"},
+	}
+	attrs := ParseVars(content)
+	if len(attrs) != len(want) {
+		t.Fatalf("ParseVars found %d values, want %d: %+v", len(attrs), len(want), attrs)
+	}
+	for i, w := range want {
+		a := attrs[i]
+		if a.Path() != w.path || a.Value != w.value || content[a.Start:a.End] != w.value {
+			t.Errorf("ParseVars()[%d] = %s %q, want %s %q", i, a.Path(), a.Value, w.path, w.value)
+		}
+	}
+	if attrs[0].Line != 2 || attrs[3].Line != 8 {
+		t.Errorf("ParseVars() lines = %d, %d, want 2, 8", attrs[0].Line, attrs[3].Line)
+	}
+}
+
+func TestScanVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string
+	}{
+		{
+			name:    "HCL",
+			path:    "prod.tfvars",
+			content: "api_token = \"c2VjcmV0LXRva2VuLXZhbHVl\"\nregion = \"eu-west-1\"\n",
+			want:    "var.api_token",
+		},
+		{
+			name:    "JSON",
+			path:    "prod.tfvars.json",
+			content: `{"region": "eu-west-1", "db": {"admin_password": "hunter2-prod"}}`,
+			want:    "var.db.admin_password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := ScanVars(context.Background(), scanner.New(), tt.path, tt.content)
+			if err != nil {
+				t.Fatalf("ScanVars failed: %v", err)
+			}
+			var paths []string
+			for _, r := range results {
+				if r.Type == Rule {
+					paths = append(paths, r.Path)
+				}
+			}
+			if len(paths) != 1 || paths[0] != tt.want {
+				t.Errorf("ScanVars() flagged %v, want [%s]", paths, tt.want)
+			}
+		})
+	}
+}